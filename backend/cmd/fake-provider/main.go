@@ -0,0 +1,112 @@
+// fake-provider - локальная замена провайдера платежей: подписывает событие
+// тем же секретом, что и сервер, и отправляет его на приёмник вебхуков.
+//
+//	go run ./cmd/fake-provider -payment 42 -type payment.succeeded
+//	go run ./cmd/fake-provider -payment 42 -type payment.refunded -amount 500
+//	go run ./cmd/fake-provider -payment 42 -type payment.failed -repeat 3   # дубликаты
+//	go run ./cmd/fake-provider -payment 42 -type payment.succeeded -skew -10m  # просроченная подпись
+//
+// Секрет берётся из -secret или PAYMENT_WEBHOOK_SECRET.
+package main
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"time"
+
+	"db200/internal/webhook"
+)
+
+// event повторяет формат, который ждёт handlers.PaymentWebhookRequest
+type event struct {
+	ID      string    `json:"id"`
+	Type    string    `json:"type"`
+	Created int64     `json:"created"`
+	Data    eventData `json:"data"`
+}
+
+type eventData struct {
+	PaymentID   int32 `json:"payment_id"`
+	AmountCents int64 `json:"amount_cents"`
+}
+
+func main() {
+	url := flag.String("url", "http://localhost:8100/webhooks/payments", "адрес приёмника вебхуков")
+	secret := flag.String("secret", os.Getenv("PAYMENT_WEBHOOK_SECRET"), "секрет подписи")
+	eventType := flag.String("type", "payment.succeeded", "payment.succeeded, payment.failed или payment.refunded")
+	paymentID := flag.Int("payment", 0, "id платежа")
+	amount := flag.Int64("amount", 0, "сумма в центах, 0 - весь остаток")
+	eventID := flag.String("id", "", "id события (по умолчанию случайный)")
+	repeat := flag.Int("repeat", 1, "сколько раз отправить одно и то же событие")
+	skew := flag.Duration("skew", 0, "сдвиг времени подписи, чтобы проверить допуск")
+	flag.Parse()
+
+	if *secret == "" {
+		log.Fatal("-secret or PAYMENT_WEBHOOK_SECRET is required")
+	}
+	if *paymentID <= 0 {
+		log.Fatal("-payment is required")
+	}
+	if *eventID == "" {
+		*eventID = randomEventID()
+	}
+
+	body, err := json.Marshal(event{
+		ID:      *eventID,
+		Type:    *eventType,
+		Created: time.Now().Unix(),
+		Data: eventData{
+			PaymentID:   int32(*paymentID),
+			AmountCents: *amount,
+		},
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	client := &http.Client{Timeout: 10 * time.Second}
+	for i := 0; i < *repeat; i++ {
+		if err := send(client, *url, []byte(*secret), body, time.Now().Add(*skew)); err != nil {
+			log.Fatal(err)
+		}
+	}
+}
+
+// send подписывает тело на момент signedAt и печатает ответ сервера
+func send(client *http.Client, url string, secret, body []byte, signedAt time.Time) error {
+	request, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set(webhook.SignatureHeader, webhook.Sign(secret, signedAt, body))
+
+	response, err := client.Do(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+
+	reply, err := io.ReadAll(response.Body)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("%s %s\n", response.Status, bytes.TrimSpace(reply))
+	return nil
+}
+
+func randomEventID() string {
+	b := make([]byte, 12)
+	if _, err := rand.Read(b); err != nil {
+		log.Fatal(err)
+	}
+	return "evt_" + hex.EncodeToString(b)
+}
@@ -0,0 +1,229 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"db200/service"
+)
+
+// commandDeps - сервисы, доступные подкомандам
+type commandDeps struct {
+	products        *service.ProductService
+	reconciliations *service.ReconciliationService
+	users           *service.AuthService
+	roles           *service.RoleService
+}
+
+func runCommand(ctx context.Context, name string, args []string, deps commandDeps) error {
+	switch name {
+	case "import-products":
+		return importProductsCommand(ctx, args, deps)
+	case "reconcile":
+		return reconcileCommand(ctx, args, deps)
+	case "grant-role":
+		return grantRoleCommand(ctx, args, deps)
+	}
+	return fmt.Errorf("unknown command %q", name)
+}
+
+// importProductsCommand: import-products -file catalog.csv [-format csv|jsonl] [-upsert]
+// Отчёт по строкам печатается в stdout в JSON.
+func importProductsCommand(ctx context.Context, args []string, deps commandDeps) error {
+	fs := flag.NewFlagSet("import-products", flag.ContinueOnError)
+	file := fs.String("file", "", "путь к CSV или JSON Lines файлу")
+	format := fs.String("format", "", "csv или jsonl (по умолчанию - по расширению файла)")
+	upsert := fs.Bool("upsert", false, "обновлять продукты с существующим slug")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *file == "" {
+		return fmt.Errorf("-file is required")
+	}
+
+	if *format == "" {
+		*format = strings.TrimPrefix(filepath.Ext(*file), ".")
+	}
+	importFormat, err := service.ParseImportFormat(*format)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.Open(*file)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	report, err := deps.products.Import(ctx, f, service.ImportOptions{
+		Format: importFormat,
+		Upsert: *upsert,
+	})
+	if err != nil {
+		return err
+	}
+
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(report); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(os.Stderr, "accepted: %d (created %d, updated %d), rejected: %d\n",
+		report.Accepted, report.Created, report.Updated, report.Rejected)
+	return nil
+}
+
+// grantRoleCommand: grant-role -email admin@example.com -role admin
+// Так выдаётся первая роль администратора, дальше роли назначаются через API.
+func grantRoleCommand(ctx context.Context, args []string, deps commandDeps) error {
+	fs := flag.NewFlagSet("grant-role", flag.ContinueOnError)
+	email := fs.String("email", "", "email пользователя")
+	role := fs.String("role", "", "имя роли")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *email == "" || *role == "" {
+		return fmt.Errorf("-email and -role are required")
+	}
+
+	user, err := deps.users.GetByEmail(ctx, *email)
+	if err != nil {
+		return err
+	}
+	assigned, err := deps.roles.AssignRole(ctx, user.ID, *role, 0)
+	if err != nil {
+		return err
+	}
+
+	if assigned {
+		fmt.Fprintf(os.Stderr, "role %q granted to user %d\n", *role, user.ID)
+	} else {
+		fmt.Fprintf(os.Stderr, "user %d already has role %q\n", user.ID, *role)
+	}
+	return nil
+}
+
+// reconcileCommand: reconcile -file settlement.csv -date 2026-02-10 [-export json|csv] [-out report.csv]
+// Колонки файла задаются флагами, период - -date (сутки) или -from/-to.
+// Отчёт сохраняется в базе и выгружается в -out или stdout, сводка - в stderr.
+func reconcileCommand(ctx context.Context, args []string, deps commandDeps) error {
+	fs := flag.NewFlagSet("reconcile", flag.ContinueOnError)
+	file := fs.String("file", "", "путь к CSV файлу расчётов провайдера")
+	date := fs.String("date", "", "день расчётов, YYYY-MM-DD")
+	from := fs.String("from", "", "начало периода, YYYY-MM-DD или RFC 3339")
+	to := fs.String("to", "", "конец периода (не включая), YYYY-MM-DD или RFC 3339")
+	invoiceColumn := fs.String("invoice-column", "invoice_id", "колонка со счётом")
+	amountColumn := fs.String("amount-column", "amount", "колонка с суммой")
+	referenceColumn := fs.String("reference-column", "", "колонка с id операции у провайдера")
+	amountFormat := fs.String("amount-format", service.SettlementAmountMinor, "minor (копейки) или major (1234.50)")
+	currency := fs.String("currency", "", "валюта major-сумм (по умолчанию - валюта каталога)")
+	invoiceKey := fs.String("invoice-key", service.SettlementInvoiceByID, "id или number счёта в файле")
+	delimiter := fs.String("delimiter", ",", `разделитель полей, \t - табуляция`)
+	export := fs.String("export", "json", "формат выгрузки: json или csv")
+	out := fs.String("out", "", "файл для выгрузки (по умолчанию stdout)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *file == "" {
+		return fmt.Errorf("-file is required")
+	}
+
+	exportFormat, err := service.ParseReconciliationFormat(*export)
+	if err != nil {
+		return err
+	}
+	periodFrom, periodTo, err := reconcilePeriod(*date, *from, *to)
+	if err != nil {
+		return err
+	}
+	sep, size := utf8.DecodeRuneInString(*delimiter)
+	if *delimiter == `\t` {
+		sep, size = '\t', 2
+	}
+	if size != len(*delimiter) {
+		return fmt.Errorf("-delimiter must be a single character")
+	}
+
+	f, err := os.Open(*file)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	details, err := deps.reconciliations.Reconcile(ctx, f, service.ReconcileInput{
+		Source: filepath.Base(*file),
+		From:   periodFrom,
+		To:     periodTo,
+		Mapping: service.SettlementMapping{
+			InvoiceColumn:   *invoiceColumn,
+			AmountColumn:    *amountColumn,
+			ReferenceColumn: *referenceColumn,
+			AmountFormat:    *amountFormat,
+			Currency:        *currency,
+			InvoiceKey:      *invoiceKey,
+			Delimiter:       sep,
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	w := os.Stdout
+	if *out != "" {
+		w, err = os.Create(*out)
+		if err != nil {
+			return err
+		}
+		defer w.Close()
+	}
+	if err := service.WriteReconciliation(w, details, exportFormat); err != nil {
+		return err
+	}
+
+	report := details.Report
+	fmt.Fprintf(os.Stderr, "report %d: rows: %d, matched: %d, missing: %d, extra: %d, amount mismatch: %d, invalid: %d\n",
+		report.ID, report.RowsTotal, report.Matched, report.Missing, report.Extra, report.Mismatched, report.Invalid)
+	return nil
+}
+
+// reconcilePeriod - либо сутки date, либо [from, to)
+func reconcilePeriod(date, from, to string) (time.Time, time.Time, error) {
+	if date != "" {
+		if from != "" || to != "" {
+			return time.Time{}, time.Time{}, fmt.Errorf("-date cannot be combined with -from/-to")
+		}
+		day, err := time.Parse(time.DateOnly, date)
+		if err != nil {
+			return time.Time{}, time.Time{}, fmt.Errorf("invalid -date: %w", err)
+		}
+		return day, day.AddDate(0, 0, 1), nil
+	}
+
+	if from == "" || to == "" {
+		return time.Time{}, time.Time{}, fmt.Errorf("either -date or both -from and -to are required")
+	}
+	periodFrom, err := parsePeriodTime(from)
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("invalid -from: %w", err)
+	}
+	periodTo, err := parsePeriodTime(to)
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("invalid -to: %w", err)
+	}
+	return periodFrom, periodTo, nil
+}
+
+func parsePeriodTime(s string) (time.Time, error) {
+	if t, err := time.Parse(time.DateOnly, s); err == nil {
+		return t, nil
+	}
+	return time.Parse(time.RFC3339, s)
+}
@@ -0,0 +1,46 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE product_search(
+    product_id INTEGER PRIMARY KEY REFERENCES products(id) ON DELETE CASCADE,
+    document TSVECTOR NOT NULL
+);
+
+CREATE INDEX product_search_document_idx ON product_search USING GIN (document);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE OR REPLACE FUNCTION product_search_refresh() RETURNS TRIGGER AS $$
+BEGIN
+    INSERT INTO product_search (product_id, document)
+    VALUES (
+        NEW.id,
+        setweight(to_tsvector('russian', NEW.title), 'A') ||
+        setweight(to_tsvector('russian', NEW.description), 'B')
+    )
+    ON CONFLICT (product_id) DO UPDATE SET document = EXCLUDED.document;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE TRIGGER products_search_refresh
+AFTER INSERT OR UPDATE OF title, description ON products
+FOR EACH ROW EXECUTE FUNCTION product_search_refresh();
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+INSERT INTO product_search (product_id, document)
+SELECT id,
+       setweight(to_tsvector('russian', title), 'A') ||
+       setweight(to_tsvector('russian', description), 'B')
+FROM products
+ON CONFLICT (product_id) DO NOTHING;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TRIGGER IF EXISTS products_search_refresh ON products;
+DROP FUNCTION IF EXISTS product_search_refresh();
+DROP TABLE IF EXISTS product_search;
+-- +goose StatementEnd
@@ -0,0 +1,20 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE product_prices(
+    id BIGSERIAL PRIMARY KEY,
+    product_id INTEGER NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+    price_cents INTEGER NOT NULL,
+    valid_from TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX product_prices_product_valid_from_idx ON product_prices (product_id, valid_from DESC, id DESC);
+
+-- Текущие цены считаем действующими с момента создания продукта
+INSERT INTO product_prices (product_id, price_cents, valid_from)
+SELECT id, price_cents, created_at FROM products;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS product_prices;
+-- +goose StatementEnd
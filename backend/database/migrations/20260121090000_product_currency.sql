@@ -0,0 +1,28 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE products ALTER COLUMN price_cents TYPE BIGINT;
+ALTER TABLE products ADD COLUMN currency TEXT NOT NULL DEFAULT 'RUB' CHECK (currency ~ '^[A-Z]{3}$');
+
+ALTER TABLE product_prices ALTER COLUMN price_cents TYPE BIGINT;
+ALTER TABLE product_prices ADD COLUMN currency TEXT NOT NULL DEFAULT 'RUB' CHECK (currency ~ '^[A-Z]{3}$');
+
+-- Курс: сколько единиц quote за одну единицу base
+CREATE TABLE exchange_rates(
+    base TEXT NOT NULL,
+    quote TEXT NOT NULL,
+    rate NUMERIC(20, 10) NOT NULL CHECK (rate > 0),
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (base, quote)
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS exchange_rates;
+
+ALTER TABLE product_prices DROP COLUMN IF EXISTS currency;
+ALTER TABLE product_prices ALTER COLUMN price_cents TYPE INTEGER;
+
+ALTER TABLE products DROP COLUMN IF EXISTS currency;
+ALTER TABLE products ALTER COLUMN price_cents TYPE INTEGER;
+-- +goose StatementEnd
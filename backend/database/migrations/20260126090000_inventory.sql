@@ -0,0 +1,43 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Остатки: on_hand - физически на складе, reserved - под активными резервами.
+-- Доступно к продаже on_hand - reserved, ограничения не дают уйти в минус.
+CREATE TABLE inventory(
+    product_id INTEGER PRIMARY KEY REFERENCES products(id) ON DELETE CASCADE,
+    on_hand BIGINT NOT NULL DEFAULT 0 CHECK (on_hand >= 0),
+    reserved BIGINT NOT NULL DEFAULT 0 CHECK (reserved >= 0 AND reserved <= on_hand),
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE stock_reservations(
+    id BIGSERIAL PRIMARY KEY,
+    product_id INTEGER NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+    quantity BIGINT NOT NULL CHECK (quantity > 0),
+    status TEXT NOT NULL DEFAULT 'active' CHECK (status IN ('active', 'committed', 'released', 'expired')),
+    expires_at TIMESTAMP NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX stock_reservations_active_expires_at_idx ON stock_reservations (expires_at) WHERE status = 'active';
+
+-- Журнал движения остатков: quantity - изменение on_hand со знаком
+CREATE TABLE stock_movements(
+    id BIGSERIAL PRIMARY KEY,
+    product_id INTEGER NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+    kind TEXT NOT NULL CHECK (kind IN ('receipt', 'sale', 'adjustment')),
+    quantity BIGINT NOT NULL,
+    reservation_id BIGINT REFERENCES stock_reservations(id) ON DELETE SET NULL,
+    note TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX stock_movements_product_created_at_idx ON stock_movements (product_id, created_at DESC, id DESC);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS stock_movements;
+DROP TABLE IF EXISTS stock_reservations;
+DROP TABLE IF EXISTS inventory;
+-- +goose StatementEnd
@@ -0,0 +1,47 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Таблица payments раньше создавалась вне миграций
+CREATE TABLE IF NOT EXISTS payments(
+    id SERIAL PRIMARY KEY,
+    invoice_id TEXT NOT NULL,
+    amount_cents INTEGER NOT NULL,
+    status TEXT ,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Свободный текст приводим к жизненному циклу платежа:
+-- пустой и pending - ещё не авторизован, всё остальное незнакомое - неуспешный
+UPDATE payments SET status = 'created' WHERE status IS NULL OR status = 'pending';
+UPDATE payments SET status = 'failed'
+WHERE status NOT IN ('created', 'authorized', 'captured', 'partially_refunded', 'refunded', 'failed', 'cancelled');
+
+ALTER TABLE payments
+    ALTER COLUMN status SET DEFAULT 'created',
+    ALTER COLUMN status SET NOT NULL,
+    ADD CONSTRAINT payments_status_check CHECK (status IN (
+        'created', 'authorized', 'captured', 'partially_refunded', 'refunded', 'failed', 'cancelled'
+    )),
+    ADD COLUMN created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    ADD COLUMN status_changed_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP;
+
+CREATE TABLE payment_status_transitions(
+    id BIGSERIAL PRIMARY KEY,
+    payment_id INTEGER NOT NULL REFERENCES payments(id) ON DELETE CASCADE,
+    from_status TEXT NOT NULL,
+    to_status TEXT NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX payment_status_transitions_payment_idx ON payment_status_transitions (payment_id, id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS payment_status_transitions;
+ALTER TABLE payments
+    DROP COLUMN IF EXISTS status_changed_at,
+    DROP COLUMN IF EXISTS created_at,
+    DROP CONSTRAINT IF EXISTS payments_status_check,
+    ALTER COLUMN status DROP NOT NULL,
+    ALTER COLUMN status DROP DEFAULT;
+-- +goose StatementEnd
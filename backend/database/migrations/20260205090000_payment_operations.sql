@@ -0,0 +1,31 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE payment_operations(
+    id BIGSERIAL PRIMARY KEY,
+    payment_id INTEGER NOT NULL REFERENCES payments(id) ON DELETE CASCADE,
+    kind TEXT NOT NULL CHECK (kind IN ('authorize', 'capture', 'refund', 'void')),
+    amount_cents BIGINT NOT NULL CHECK (amount_cents >= 0),
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX payment_operations_payment_idx ON payment_operations (payment_id, id);
+
+-- Платежи, переведённые в статус вручную, получают операции на полную сумму.
+-- Сумму частичного возврата восстановить нельзя - такие платежи остаются без возврата.
+INSERT INTO payment_operations (payment_id, kind, amount_cents)
+SELECT id, 'authorize', amount_cents FROM payments
+WHERE status IN ('authorized', 'captured', 'partially_refunded', 'refunded');
+
+INSERT INTO payment_operations (payment_id, kind, amount_cents)
+SELECT id, 'capture', amount_cents FROM payments
+WHERE status IN ('captured', 'partially_refunded', 'refunded');
+
+INSERT INTO payment_operations (payment_id, kind, amount_cents)
+SELECT id, 'refund', amount_cents FROM payments
+WHERE status = 'refunded';
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS payment_operations;
+-- +goose StatementEnd
@@ -0,0 +1,21 @@
+-- +goose Up
+-- +goose StatementBegin
+-- События провайдера, по записи на event_id: повторная доставка не применяется дважды.
+-- payment_id без внешнего ключа - события по неизвестным платежам тоже сохраняются.
+CREATE TABLE payment_webhook_events(
+    event_id TEXT PRIMARY KEY,
+    type TEXT NOT NULL,
+    payment_id INTEGER NOT NULL,
+    payload JSONB NOT NULL,
+    outcome TEXT NOT NULL CHECK (outcome IN ('applied', 'ignored')),
+    reason TEXT NOT NULL DEFAULT '',
+    received_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX payment_webhook_events_payment_idx ON payment_webhook_events (payment_id, received_at);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS payment_webhook_events;
+-- +goose StatementEnd
@@ -0,0 +1,115 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE customers(
+    id SERIAL PRIMARY KEY,
+    name TEXT NOT NULL,
+    email TEXT NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE UNIQUE INDEX customers_email_key ON customers (lower(email));
+
+-- Последний выданный номер счёта по году; строка блокируется до конца транзакции,
+-- поэтому номера идут без пропусков
+CREATE TABLE invoice_number_sequences(
+    year INTEGER PRIMARY KEY,
+    last_number INTEGER NOT NULL
+);
+
+-- Счёт: draft -> issued -> paid, draft и issued можно аннулировать (void).
+-- Номер присваивается при выставлении. Итоги пересчитываются при каждом изменении черновика:
+-- total = subtotal - discount + tax, налог считается от суммы после скидки.
+CREATE TABLE invoices(
+    id SERIAL PRIMARY KEY,
+    number TEXT UNIQUE,
+    customer_id INTEGER NOT NULL REFERENCES customers(id),
+    status TEXT NOT NULL DEFAULT 'draft' CHECK (status IN ('draft', 'issued', 'paid', 'void')),
+    currency TEXT NOT NULL DEFAULT 'RUB',
+    subtotal_cents BIGINT NOT NULL DEFAULT 0,
+    discount_cents BIGINT NOT NULL DEFAULT 0 CHECK (discount_cents >= 0),
+    -- Ставка налога в базисных пунктах: 2000 - 20%
+    tax_rate_bp INTEGER NOT NULL DEFAULT 0 CHECK (tax_rate_bp BETWEEN 0 AND 10000),
+    tax_cents BIGINT NOT NULL DEFAULT 0,
+    total_cents BIGINT NOT NULL DEFAULT 0 CHECK (total_cents >= 0),
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    issued_at TIMESTAMP,
+    paid_at TIMESTAMP,
+    voided_at TIMESTAMP
+);
+
+CREATE INDEX invoices_customer_idx ON invoices (customer_id, created_at, id);
+
+-- Строки счёта; цена и название продукта копируются на момент добавления
+-- и дальше от каталога не зависят
+CREATE TABLE invoice_lines(
+    id BIGSERIAL PRIMARY KEY,
+    invoice_id INTEGER NOT NULL REFERENCES invoices(id) ON DELETE CASCADE,
+    product_id INTEGER NOT NULL REFERENCES products(id),
+    description TEXT NOT NULL,
+    quantity INTEGER NOT NULL CHECK (quantity > 0),
+    price_cents BIGINT NOT NULL CHECK (price_cents >= 0),
+    amount_cents BIGINT NOT NULL CHECK (amount_cents >= 0),
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX invoice_lines_invoice_idx ON invoice_lines (invoice_id, id);
+
+-- payments.invoice_id был свободным текстом. Для каждого такого значения
+-- заводим выставленный счёт без строк на сумму его платежей с номером LEGACY-<старый id>.
+INSERT INTO customers (name, email)
+SELECT 'Legacy payments', 'legacy-payments@invalid'
+WHERE EXISTS (SELECT 1 FROM payments);
+
+INSERT INTO invoices (number, customer_id, status, subtotal_cents, total_cents, created_at, issued_at)
+SELECT 'LEGACY-' || p.invoice_id,
+       (SELECT id FROM customers WHERE email = 'legacy-payments@invalid'),
+       'issued', sum(p.amount_cents), sum(p.amount_cents), min(p.created_at), min(p.created_at)
+FROM payments p
+GROUP BY p.invoice_id;
+
+ALTER TABLE payments ADD COLUMN invoice_ref INTEGER;
+
+UPDATE payments p SET invoice_ref = i.id
+FROM invoices i WHERE i.number = 'LEGACY-' || p.invoice_id;
+
+DROP INDEX IF EXISTS payments_invoice_id_idx;
+ALTER TABLE payments DROP COLUMN invoice_id;
+ALTER TABLE payments RENAME COLUMN invoice_ref TO invoice_id;
+ALTER TABLE payments
+    ALTER COLUMN invoice_id SET NOT NULL,
+    ADD CONSTRAINT payments_invoice_id_fkey FOREIGN KEY (invoice_id) REFERENCES invoices(id);
+
+CREATE INDEX payments_invoice_id_idx ON payments (invoice_id, created_at, id);
+
+-- Перенесённые счета, покрытые списаниями, сразу оплачены
+UPDATE invoices i SET status = 'paid', paid_at = CURRENT_TIMESTAMP
+WHERE i.status = 'issued' AND i.total_cents <= (
+    SELECT coalesce(sum(o.amount_cents) FILTER (WHERE o.kind = 'capture'), 0)
+         - coalesce(sum(o.amount_cents) FILTER (WHERE o.kind = 'refund'), 0)
+    FROM payment_operations o JOIN payments p ON p.id = o.payment_id
+    WHERE p.invoice_id = i.id
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE payments ADD COLUMN invoice_ref TEXT;
+
+UPDATE payments p SET invoice_ref = CASE
+    WHEN i.number LIKE 'LEGACY-%' THEN substr(i.number, 8)
+    ELSE i.id::text
+END
+FROM invoices i WHERE i.id = p.invoice_id;
+
+DROP INDEX IF EXISTS payments_invoice_id_idx;
+ALTER TABLE payments DROP COLUMN invoice_id;
+ALTER TABLE payments RENAME COLUMN invoice_ref TO invoice_id;
+ALTER TABLE payments ALTER COLUMN invoice_id SET NOT NULL;
+CREATE INDEX payments_invoice_id_idx ON payments (invoice_id, created_at, id);
+
+DROP TABLE IF EXISTS invoice_lines;
+DROP TABLE IF EXISTS invoices;
+DROP TABLE IF EXISTS invoice_number_sequences;
+DROP TABLE IF EXISTS customers;
+-- +goose StatementEnd
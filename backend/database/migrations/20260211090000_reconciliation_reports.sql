@@ -0,0 +1,42 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Сверка файла расчётов эквайера со списаниями за период [period_from, period_to)
+CREATE TABLE reconciliation_reports(
+    id SERIAL PRIMARY KEY,
+    source TEXT NOT NULL,
+    period_from TIMESTAMP NOT NULL,
+    period_to TIMESTAMP NOT NULL,
+    rows_total INTEGER NOT NULL,
+    matched INTEGER NOT NULL,
+    missing INTEGER NOT NULL,
+    extra INTEGER NOT NULL,
+    mismatched INTEGER NOT NULL,
+    invalid INTEGER NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CHECK (period_from < period_to)
+);
+
+CREATE INDEX reconciliation_reports_created_idx ON reconciliation_reports (created_at DESC, id DESC);
+
+-- Результат по строке файла или по платежу, которого в файле нет (missing, line IS NULL)
+CREATE TABLE reconciliation_entries(
+    id BIGSERIAL PRIMARY KEY,
+    report_id INTEGER NOT NULL REFERENCES reconciliation_reports(id) ON DELETE CASCADE,
+    status TEXT NOT NULL CHECK (status IN ('matched', 'missing', 'extra', 'amount_mismatch', 'invalid')),
+    line INTEGER,
+    invoice_ref TEXT NOT NULL DEFAULT '',
+    settlement_ref TEXT NOT NULL DEFAULT '',
+    payment_id INTEGER REFERENCES payments(id) ON DELETE SET NULL,
+    settled_cents BIGINT,
+    expected_cents BIGINT,
+    note TEXT NOT NULL DEFAULT ''
+);
+
+CREATE INDEX reconciliation_entries_report_idx ON reconciliation_entries (report_id, id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS reconciliation_entries;
+DROP TABLE IF EXISTS reconciliation_reports;
+-- +goose StatementEnd
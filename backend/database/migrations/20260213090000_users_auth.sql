@@ -0,0 +1,35 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Раньше таблицу создавал GORM AutoMigrate: без id и с паролем открытым текстом
+CREATE TABLE IF NOT EXISTS users (
+    id SERIAL PRIMARY KEY,
+    name TEXT NOT NULL,
+    email TEXT UNIQUE NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+ALTER TABLE users ADD COLUMN IF NOT EXISTS id SERIAL PRIMARY KEY;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP;
+UPDATE users SET name = '' WHERE name IS NULL;
+ALTER TABLE users ALTER COLUMN name SET NOT NULL;
+ALTER TABLE users ALTER COLUMN email SET NOT NULL;
+
+-- PHC-строка: алгоритм, параметры и соль хранятся вместе с хэшем.
+-- Пустая строка - пароля нет, войти нельзя, пока его не зададут заново.
+ALTER TABLE users ADD COLUMN password_hash TEXT NOT NULL DEFAULT '';
+ALTER TABLE users ADD COLUMN password_changed_at TIMESTAMP;
+
+-- Открытые пароли не переносим
+ALTER TABLE users DROP COLUMN IF EXISTS password;
+
+CREATE UNIQUE INDEX users_email_lower_key ON users (lower(email));
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS users_email_lower_key;
+ALTER TABLE users DROP COLUMN IF EXISTS password_changed_at;
+ALTER TABLE users DROP COLUMN IF EXISTS password_hash;
+-- +goose StatementEnd
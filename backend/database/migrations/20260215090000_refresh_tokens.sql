@@ -0,0 +1,27 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE refresh_tokens (
+    id BIGSERIAL PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    -- Семейство - цепочка ротаций от одного входа; повтор старого токена отзывает всю цепочку
+    family_id TEXT NOT NULL,
+    -- SHA-256 от токена, сам токен не хранится
+    token_hash BYTEA NOT NULL UNIQUE,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    -- В UTC, сравнивается со временем приложения
+    expires_at TIMESTAMP NOT NULL,
+    -- Когда токен обменяли на следующий
+    used_at TIMESTAMP,
+    revoked_at TIMESTAMP,
+    revoked_reason TEXT NOT NULL DEFAULT ''
+);
+
+CREATE INDEX refresh_tokens_family_idx ON refresh_tokens (family_id);
+CREATE INDEX refresh_tokens_user_active_idx ON refresh_tokens (user_id) WHERE revoked_at IS NULL;
+CREATE INDEX refresh_tokens_expires_idx ON refresh_tokens (expires_at);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS refresh_tokens;
+-- +goose StatementEnd
@@ -0,0 +1,88 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE roles (
+    id SERIAL PRIMARY KEY,
+    name TEXT NOT NULL UNIQUE,
+    description TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Разрешение - "ресурс:действие", его и проверяют маршруты
+CREATE TABLE permissions (
+    id SERIAL PRIMARY KEY,
+    name TEXT NOT NULL UNIQUE,
+    description TEXT NOT NULL DEFAULT ''
+);
+
+CREATE TABLE role_permissions (
+    role_id INTEGER NOT NULL REFERENCES roles(id) ON DELETE CASCADE,
+    permission_id INTEGER NOT NULL REFERENCES permissions(id) ON DELETE CASCADE,
+    PRIMARY KEY (role_id, permission_id)
+);
+
+CREATE TABLE user_roles (
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    role_id INTEGER NOT NULL REFERENCES roles(id) ON DELETE CASCADE,
+    granted_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    -- Кто выдал роль; NULL - миграция или консольная команда
+    granted_by INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    PRIMARY KEY (user_id, role_id)
+);
+
+CREATE INDEX user_roles_role_idx ON user_roles (role_id);
+
+INSERT INTO permissions (name, description) VALUES
+    ('products:write', 'изменение каталога, категорий, тегов, остатков и курсов'),
+    ('payments:read', 'просмотр платежей и сверок'),
+    ('payments:write', 'проведение платежей: создание, захват, возврат, отмена'),
+    ('users:manage', 'назначение ролей пользователям'),
+    ('system:debug', 'отладочные эндпоинты');
+
+INSERT INTO roles (name, description) VALUES
+    ('admin', 'все разрешения'),
+    ('manager', 'ведение каталога'),
+    ('accountant', 'платежи и сверки');
+
+INSERT INTO role_permissions (role_id, permission_id)
+SELECT r.id, p.id FROM roles r JOIN permissions p ON
+    r.name = 'admin'
+    OR (r.name = 'manager' AND p.name IN ('products:write', 'payments:read'))
+    OR (r.name = 'accountant' AND p.name IN ('payments:read', 'payments:write'));
+
+-- GORM создавал users.types, но его никто не читал. Значения (через запятую)
+-- становятся ролями без разрешений, чтобы их можно было настроить позже.
+DO $$
+BEGIN
+    IF EXISTS (SELECT 1 FROM information_schema.columns
+               WHERE table_name = 'users' AND column_name = 'types') THEN
+        INSERT INTO roles (name, description)
+        SELECT DISTINCT lower(t.name), 'перенесено из users.types'
+        FROM users u CROSS JOIN LATERAL regexp_split_to_table(trim(u.types), '\s*,\s*') AS t(name)
+        WHERE t.name <> ''
+        ON CONFLICT (name) DO NOTHING;
+
+        INSERT INTO user_roles (user_id, role_id)
+        SELECT DISTINCT u.id, r.id
+        FROM users u CROSS JOIN LATERAL regexp_split_to_table(trim(u.types), '\s*,\s*') AS t(name)
+        JOIN roles r ON r.name = lower(t.name)
+        ON CONFLICT DO NOTHING;
+
+        ALTER TABLE users DROP COLUMN types;
+    END IF;
+END $$;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE users ADD COLUMN IF NOT EXISTS types TEXT;
+UPDATE users u SET types = (
+    SELECT string_agg(r.name, ',' ORDER BY r.name)
+    FROM user_roles ur JOIN roles r ON r.id = ur.role_id
+    WHERE ur.user_id = u.id
+);
+
+DROP TABLE IF EXISTS user_roles;
+DROP TABLE IF EXISTS role_permissions;
+DROP TABLE IF EXISTS permissions;
+DROP TABLE IF EXISTS roles;
+-- +goose StatementEnd
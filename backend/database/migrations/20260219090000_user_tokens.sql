@@ -0,0 +1,28 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE users ADD COLUMN email_verified_at TIMESTAMP;
+
+-- Одноразовые токены из писем: подтверждение email и сброс пароля
+CREATE TABLE user_tokens (
+    id BIGSERIAL PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    purpose TEXT NOT NULL CHECK (purpose IN ('email_verification', 'password_reset')),
+    -- SHA-256 от токена, сам токен есть только в письме
+    token_hash BYTEA NOT NULL UNIQUE,
+    -- Адрес, на который ушло письмо; подтверждается только он
+    email TEXT NOT NULL,
+    -- Время приложения в UTC
+    created_at TIMESTAMP NOT NULL,
+    expires_at TIMESTAMP NOT NULL,
+    used_at TIMESTAMP
+);
+
+CREATE INDEX user_tokens_user_purpose_idx ON user_tokens (user_id, purpose, created_at);
+CREATE INDEX user_tokens_expires_idx ON user_tokens (expires_at);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS user_tokens;
+ALTER TABLE users DROP COLUMN IF EXISTS email_verified_at;
+-- +goose StatementEnd
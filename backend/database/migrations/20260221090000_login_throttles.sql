@@ -0,0 +1,39 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Неудачные входы подряд: по аккаунту (key - email в нижнем регистре,
+-- в том числе несуществующий) и по IP. Время - время приложения в UTC.
+CREATE TABLE login_throttles (
+    scope TEXT NOT NULL CHECK (scope IN ('account', 'ip')),
+    key TEXT NOT NULL,
+    failures INTEGER NOT NULL,
+    last_failure_at TIMESTAMP NOT NULL,
+    -- До этого момента вход не проверяется вовсе
+    blocked_until TIMESTAMP,
+    -- true - блокировка после N неудач, false - пауза между попытками
+    locked BOOLEAN NOT NULL DEFAULT FALSE,
+    PRIMARY KEY (scope, key)
+);
+
+CREATE INDEX login_throttles_last_failure_idx ON login_throttles (last_failure_at);
+
+CREATE TABLE security_events (
+    id BIGSERIAL PRIMARY KEY,
+    kind TEXT NOT NULL,
+    scope TEXT NOT NULL,
+    -- email или IP, к которому относится событие
+    subject TEXT NOT NULL,
+    -- IP запроса, вызвавшего событие; пусто для действий администратора
+    ip TEXT NOT NULL DEFAULT '',
+    -- Администратор, если событие - его действие
+    actor_id INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX security_events_created_idx ON security_events (created_at);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS security_events;
+DROP TABLE IF EXISTS login_throttles;
+-- +goose StatementEnd
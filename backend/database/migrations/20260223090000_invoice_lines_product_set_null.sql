@@ -0,0 +1,31 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Строки счёта хранят копию названия и цены, ссылка на продукт нужна только
+-- для справки. Без ON DELETE окончательное удаление продукта из корзины,
+-- попавшего хоть в один счёт, падало и останавливало всю очистку.
+ALTER TABLE invoice_lines ALTER COLUMN product_id DROP NOT NULL;
+ALTER TABLE invoice_lines DROP CONSTRAINT invoice_lines_product_id_fkey;
+ALTER TABLE invoice_lines ADD CONSTRAINT invoice_lines_product_id_fkey
+    FOREIGN KEY (product_id) REFERENCES products(id) ON DELETE SET NULL;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+-- Строки удалённых продуктов вернуть к ссылке уже нельзя, а удалять их -
+-- значит менять выставленные счета. Откат останавливается, пока они есть.
+DO $$
+DECLARE
+    orphaned BIGINT;
+BEGIN
+    SELECT count(*) INTO orphaned FROM invoice_lines WHERE product_id IS NULL;
+    IF orphaned > 0 THEN
+        RAISE EXCEPTION 'invoice_lines: % lines reference deleted products', orphaned
+            USING HINT = 'product_id cannot be restored to NOT NULL without changing issued invoices';
+    END IF;
+END
+$$;
+ALTER TABLE invoice_lines DROP CONSTRAINT invoice_lines_product_id_fkey;
+ALTER TABLE invoice_lines ADD CONSTRAINT invoice_lines_product_id_fkey
+    FOREIGN KEY (product_id) REFERENCES products(id);
+ALTER TABLE invoice_lines ALTER COLUMN product_id SET NOT NULL;
+-- +goose StatementEnd
@@ -0,0 +1,11 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Токен владельца блокировки: после того как брошенный ключ забрал другой
+-- запрос, прежний владелец не должен ни сохранить свой ответ, ни удалить ключ.
+ALTER TABLE idempotency_keys ADD COLUMN locked_by TEXT NOT NULL DEFAULT '';
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE idempotency_keys DROP COLUMN IF EXISTS locked_by;
+-- +goose StatementEnd
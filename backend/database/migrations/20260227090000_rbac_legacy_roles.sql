@@ -0,0 +1,38 @@
+-- +goose Up
+-- +goose StatementBegin
+-- 20260217090000_rbac переносил users.types в роли по имени, и значения admin,
+-- manager и accountant получали засеянные роли со всеми их разрешениями.
+-- Такие назначения переезжают на роли legacy:<имя> без разрешений.
+-- Перенесённые назначения отличаются от выданных позже: granted_by пустой,
+-- а granted_at совпадает с created_at роли - обе записи сделаны в одной
+-- транзакции той миграции.
+CREATE TEMPORARY TABLE legacy_grants ON COMMIT DROP AS
+SELECT ur.user_id, r.id AS role_id, r.name AS role_name, ur.granted_at
+FROM user_roles ur
+JOIN roles r ON r.id = ur.role_id
+WHERE r.name IN ('admin', 'manager', 'accountant')
+  AND ur.granted_by IS NULL
+  AND ur.granted_at = r.created_at;
+
+INSERT INTO roles (name, description)
+SELECT DISTINCT 'legacy:' || role_name, 'перенесено из users.types'
+FROM legacy_grants
+ON CONFLICT (name) DO NOTHING;
+
+INSERT INTO user_roles (user_id, role_id, granted_at)
+SELECT g.user_id, r.id, g.granted_at
+FROM legacy_grants g
+JOIN roles r ON r.name = 'legacy:' || g.role_name
+ON CONFLICT DO NOTHING;
+
+DELETE FROM user_roles ur
+USING legacy_grants g
+WHERE ur.user_id = g.user_id AND ur.role_id = g.role_id;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+-- Откат не возвращает разрешения назначениям из users.types: роли legacy:*
+-- остаются, администратор выдаёт нужные роли сам
+SELECT 1;
+-- +goose StatementEnd
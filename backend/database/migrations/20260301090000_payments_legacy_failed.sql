@@ -0,0 +1,29 @@
+-- +goose Up
+-- +goose StatementBegin
+-- 20260130090000_payments_status переводил в failed любой незнакомый старый
+-- статус, в том числе paid и success. Исходные значения не сохранились, и
+-- вернуть их нельзя. Такие платежи отличаются от упавших позже: у них нет
+-- ни одного перехода, а status_changed_at совпадает с created_at - обе
+-- колонки добавлены той миграцией. Миграция называет их, чтобы их сверили
+-- с провайдером вручную.
+DO $$
+DECLARE
+    coerced TEXT;
+BEGIN
+    SELECT string_agg(p.id::TEXT, ', ' ORDER BY p.id) INTO coerced
+    FROM payments p
+    WHERE p.status = 'failed'
+      AND p.status_changed_at = p.created_at
+      AND NOT EXISTS (SELECT 1 FROM payment_status_transitions t WHERE t.payment_id = p.id);
+    IF coerced IS NOT NULL THEN
+        RAISE WARNING 'payments: legacy statuses were coerced to failed for payments %', coerced
+            USING HINT = 'check them against the provider and fix the status by hand';
+    END IF;
+END
+$$;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+SELECT 1;
+-- +goose StatementEnd
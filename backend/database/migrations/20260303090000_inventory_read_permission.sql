@@ -0,0 +1,18 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Остатки и журнал движений раньше отдавались без входа. Теперь их читают
+-- по отдельному разрешению; засеянные роли, которые ведут каталог, его получают.
+INSERT INTO permissions (name, description) VALUES
+    ('inventory:read', 'просмотр остатков и движений по складу')
+ON CONFLICT (name) DO NOTHING;
+
+INSERT INTO role_permissions (role_id, permission_id)
+SELECT r.id, p.id FROM roles r JOIN permissions p ON p.name = 'inventory:read'
+WHERE r.name IN ('admin', 'manager')
+ON CONFLICT DO NOTHING;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DELETE FROM permissions WHERE name = 'inventory:read';
+-- +goose StatementEnd
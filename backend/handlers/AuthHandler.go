@@ -0,0 +1,346 @@
+package handlers
+
+import (
+	"errors"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/sirupsen/logrus"
+
+	usersdb "db200/internal/db/users"
+	"db200/internal/token"
+	"db200/service"
+)
+
+// UserContextKey - ключ, под которым JWT-middleware кладёт токен в c.Locals
+const UserContextKey = "user"
+
+type (
+	RegisterRequest struct {
+		Email    string `json:"email"`
+		Name     string `json:"name"`
+		Password string `json:"password"`
+	}
+
+	LoginRequest struct {
+		Email    string `json:"email"`
+		Password string `json:"password"`
+	}
+
+	// TokenResponse - ответ на вход и обновление токенов
+	TokenResponse struct {
+		AccessToken      string    `json:"access_token"`
+		TokenType        string    `json:"token_type"`
+		ExpiresIn        int64     `json:"expires_in"`
+		RefreshToken     string    `json:"refresh_token"`
+		RefreshExpiresAt time.Time `json:"refresh_expires_at"`
+	}
+
+	RefreshRequest struct {
+		RefreshToken string `json:"refresh_token"`
+	}
+
+	RevokeAllResponse struct {
+		Revoked int64 `json:"revoked"`
+	}
+
+	UserResponse struct {
+		ID              int32      `json:"id"`
+		Email           string     `json:"email"`
+		Name            string     `json:"name"`
+		EmailVerified   bool       `json:"email_verified"`
+		EmailVerifiedAt *time.Time `json:"email_verified_at,omitempty"`
+		CreatedAt       *time.Time `json:"created_at,omitempty"`
+	}
+
+	ConfirmEmailRequest struct {
+		Token string `json:"token"`
+	}
+
+	PasswordResetRequest struct {
+		Email string `json:"email"`
+	}
+
+	ConfirmPasswordResetRequest struct {
+		Token    string `json:"token"`
+		Password string `json:"password"`
+	}
+)
+
+type AuthHandler struct {
+	Service  *service.AuthService
+	Tokens   *service.TokenService
+	Accounts *service.AccountService
+	Throttle *service.LoginThrottleService
+}
+
+func NewAuthHandler(authService *service.AuthService, tokenService *service.TokenService, accountService *service.AccountService, throttleService *service.LoginThrottleService) *AuthHandler {
+	return &AuthHandler{
+		Service:  authService,
+		Tokens:   tokenService,
+		Accounts: accountService,
+		Throttle: throttleService,
+	}
+}
+
+func (h *AuthHandler) Register(c *fiber.Ctx) error {
+	var request RegisterRequest
+	if err := c.BodyParser(&request); err != nil {
+		return respondError(c, fiber.StatusBadRequest, "invalid JSON")
+	}
+
+	user, err := h.Service.Register(c.UserContext(), service.RegisterInput{
+		Email:    request.Email,
+		Name:     request.Name,
+		Password: request.Password,
+	})
+	if err != nil {
+		return authError(c, err)
+	}
+
+	// Письмо можно запросить повторно, поэтому его ошибка не отменяет регистрацию
+	if err := h.Accounts.RequestEmailVerification(c.UserContext(), user.ID); err != nil {
+		logrus.WithError(err).WithField("user_id", user.ID).Error("send verification email")
+	}
+
+	return respondData(c, fiber.StatusCreated, toUserResponse(user))
+}
+
+// Login проверяет пароль и открывает сессию: токен доступа и refresh-токен.
+// Неудачи считаются по email и IP: после каждой растёт пауза, после
+// нескольких подряд вход временно блокируется (429 с Retry-After).
+func (h *AuthHandler) Login(c *fiber.Ctx) error {
+	var request LoginRequest
+	if err := c.BodyParser(&request); err != nil {
+		return respondError(c, fiber.StatusBadRequest, "invalid JSON")
+	}
+
+	// Пока вход закрыт паузой или блокировкой, пароль даже не проверяется
+	attempt, err := h.Throttle.Begin(c.UserContext(), request.Email, c.IP())
+	if err != nil {
+		return authError(c, err)
+	}
+
+	user, err := h.Service.Authenticate(c.UserContext(), request.Email, request.Password)
+	if err != nil {
+		if errors.Is(err, service.ErrBadCredentials) {
+			h.Throttle.Failed(c.UserContext(), attempt)
+		} else {
+			h.Throttle.Cancel(c.UserContext(), attempt)
+		}
+		return authError(c, err)
+	}
+	if err := h.Throttle.Succeeded(c.UserContext(), attempt); err != nil {
+		logrus.WithError(err).WithField("user_id", user.ID).Error("reset login throttle")
+	}
+
+	pair, err := h.Tokens.Issue(c.UserContext(), user)
+	if err != nil {
+		return authError(c, err)
+	}
+
+	return respondData(c, fiber.StatusOK, toTokenResponse(pair))
+}
+
+// Refresh обменивает refresh-токен на новую пару; старый больше не действует
+func (h *AuthHandler) Refresh(c *fiber.Ctx) error {
+	var request RefreshRequest
+	if err := c.BodyParser(&request); err != nil {
+		return respondError(c, fiber.StatusBadRequest, "invalid JSON")
+	}
+
+	pair, err := h.Tokens.Refresh(c.UserContext(), request.RefreshToken)
+	if err != nil {
+		return authError(c, err)
+	}
+
+	return respondData(c, fiber.StatusOK, toTokenResponse(pair))
+}
+
+// Logout отзывает сессию, к которой относится refresh-токен
+func (h *AuthHandler) Logout(c *fiber.Ctx) error {
+	var request RefreshRequest
+	if err := c.BodyParser(&request); err != nil {
+		return respondError(c, fiber.StatusBadRequest, "invalid JSON")
+	}
+
+	if err := h.Tokens.Revoke(c.UserContext(), request.RefreshToken); err != nil {
+		return authError(c, err)
+	}
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// LogoutAll отзывает все сессии пользователя из токена доступа
+func (h *AuthHandler) LogoutAll(c *fiber.Ctx) error {
+	userID, ok := tokenUserID(c)
+	if !ok {
+		return respondError(c, fiber.StatusUnauthorized, "invalid token")
+	}
+
+	count, err := h.Tokens.RevokeAll(c.UserContext(), userID)
+	if err != nil {
+		return authError(c, err)
+	}
+
+	return respondData(c, fiber.StatusOK, RevokeAllResponse{
+		Revoked: count,
+	})
+}
+
+// Profile - пользователь из токена доступа
+func (h *AuthHandler) Profile(c *fiber.Ctx) error {
+	userID, ok := tokenUserID(c)
+	if !ok {
+		return respondError(c, fiber.StatusUnauthorized, "invalid token")
+	}
+
+	user, err := h.Service.Get(c.UserContext(), userID)
+	if err != nil {
+		return authError(c, err)
+	}
+
+	return respondData(c, fiber.StatusOK, toUserResponse(user))
+}
+
+// RequestEmailVerification отправляет новое письмо подтверждения на email из профиля
+func (h *AuthHandler) RequestEmailVerification(c *fiber.Ctx) error {
+	userID, ok := tokenUserID(c)
+	if !ok {
+		return respondError(c, fiber.StatusUnauthorized, "invalid token")
+	}
+
+	if err := h.Accounts.RequestEmailVerification(c.UserContext(), userID); err != nil {
+		return authError(c, err)
+	}
+
+	return c.SendStatus(fiber.StatusAccepted)
+}
+
+// ConfirmEmail подтверждает email токеном из письма
+func (h *AuthHandler) ConfirmEmail(c *fiber.Ctx) error {
+	var request ConfirmEmailRequest
+	if err := c.BodyParser(&request); err != nil {
+		return respondError(c, fiber.StatusBadRequest, "invalid JSON")
+	}
+
+	if err := h.Accounts.ConfirmEmail(c.UserContext(), request.Token); err != nil {
+		return authError(c, err)
+	}
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// RequestPasswordReset всегда отвечает 202: есть ли такой email, не сообщаем
+func (h *AuthHandler) RequestPasswordReset(c *fiber.Ctx) error {
+	var request PasswordResetRequest
+	if err := c.BodyParser(&request); err != nil {
+		return respondError(c, fiber.StatusBadRequest, "invalid JSON")
+	}
+
+	if err := h.Accounts.RequestPasswordReset(c.UserContext(), request.Email); err != nil {
+		return authError(c, err)
+	}
+
+	return c.SendStatus(fiber.StatusAccepted)
+}
+
+// ConfirmPasswordReset ставит новый пароль токеном из письма; все сессии завершаются,
+// блокировка входа в аккаунт снимается - владелец почты доказал, что аккаунт его
+func (h *AuthHandler) ConfirmPasswordReset(c *fiber.Ctx) error {
+	var request ConfirmPasswordResetRequest
+	if err := c.BodyParser(&request); err != nil {
+		return respondError(c, fiber.StatusBadRequest, "invalid JSON")
+	}
+
+	userID, err := h.Accounts.ResetPassword(c.UserContext(), request.Token, request.Password)
+	if err != nil {
+		return authError(c, err)
+	}
+	if err := h.Throttle.ResetUser(c.UserContext(), userID); err != nil {
+		// Пароль уже сменён; блокировка истечёт сама
+		logrus.WithError(err).WithField("user_id", userID).Error("reset login throttle after password reset")
+	}
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// TokenError - ответ JWT-middleware на отсутствующий или недействительный токен доступа
+func TokenError(c *fiber.Ctx, err error) error {
+	return respondError(c, fiber.StatusUnauthorized, service.ErrInvalidToken.Error())
+}
+
+// tokenClaims - содержимое токена доступа, проверенного JWT-middleware
+func tokenClaims(c *fiber.Ctx) (*token.AccessClaims, bool) {
+	t, ok := c.Locals(UserContextKey).(*jwt.Token)
+	if !ok {
+		return nil, false
+	}
+	claims, ok := t.Claims.(*token.AccessClaims)
+	return claims, ok
+}
+
+// tokenUserID достаёт id пользователя из sub проверенного токена
+func tokenUserID(c *fiber.Ctx) (int32, bool) {
+	claims, ok := tokenClaims(c)
+	if !ok {
+		return 0, false
+	}
+	id, err := strconv.ParseInt(claims.Subject, 10, 32)
+	if err != nil || id <= 0 {
+		return 0, false
+	}
+	return int32(id), true
+}
+
+func authError(c *fiber.Ctx, err error) error {
+	var throttled *service.LoginThrottledError
+	if errors.As(err, &throttled) {
+		c.Set(fiber.HeaderRetryAfter, strconv.Itoa(int(math.Ceil(throttled.RetryAfter.Seconds()))))
+		return respondError(c, fiber.StatusTooManyRequests, throttled.Error())
+	}
+
+	switch {
+	case errors.Is(err, service.ErrBadCredentials):
+		return respondError(c, fiber.StatusUnauthorized, service.ErrBadCredentials.Error())
+	case errors.Is(err, service.ErrTokenReused):
+		return respondError(c, fiber.StatusUnauthorized, service.ErrTokenReused.Error())
+	case errors.Is(err, service.ErrInvalidToken):
+		return respondError(c, fiber.StatusUnauthorized, service.ErrInvalidToken.Error())
+	case errors.Is(err, service.ErrNotFound):
+		return respondError(c, fiber.StatusNotFound, err.Error())
+	case errors.Is(err, service.ErrInvalidInput):
+		return respondError(c, fiber.StatusBadRequest, err.Error())
+	case errors.Is(err, service.ErrConflict):
+		return respondError(c, fiber.StatusConflict, err.Error())
+	case errors.Is(err, service.ErrRateLimited):
+		return respondError(c, fiber.StatusTooManyRequests, service.ErrRateLimited.Error())
+	}
+
+	logrus.WithError(err).Error("auth handler")
+	return respondError(c, fiber.StatusInternalServerError, "internal server error")
+}
+
+func toTokenResponse(pair service.TokenPair) TokenResponse {
+	return TokenResponse{
+		AccessToken:      pair.AccessToken,
+		TokenType:        "Bearer",
+		ExpiresIn:        int64(time.Until(pair.AccessExpiresAt).Seconds()),
+		RefreshToken:     pair.RefreshToken,
+		RefreshExpiresAt: pair.RefreshExpiresAt,
+	}
+}
+
+func toUserResponse(user usersdb.User) UserResponse {
+	return UserResponse{
+		ID:              user.ID,
+		Email:           user.Email,
+		Name:            user.Name,
+		EmailVerified:   user.EmailVerifiedAt.Valid,
+		EmailVerifiedAt: nullTime(user.EmailVerifiedAt),
+		CreatedAt:       nullTime(user.CreatedAt),
+	}
+}
@@ -0,0 +1,47 @@
+package handlers
+
+import (
+	"bytes"
+	"io"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// BodyLimit - middleware, ограничивающее тело запроса limit байтами (413 при превышении).
+// Сервер работает со StreamRequestBody: тело больше fiber.Config.BodyLimit
+// не отвергается при чтении, а отдаётся потоком, и лимит действует здесь.
+// Тело до limit байт дочитывается в память, дальше обработчик работает как обычно.
+// skip пропускает запросы, у которых лимит свой (nil - не пропускать ничего).
+func BodyLimit(limit int, skip func(*fiber.Ctx) bool) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if skip != nil && skip(c) {
+			return c.Next()
+		}
+
+		request := c.Request()
+		if request.Header.ContentLength() > limit {
+			return bodyTooLarge(c)
+		}
+		stream := request.BodyStream()
+		if stream == nil {
+			return c.Next()
+		}
+
+		var body bytes.Buffer
+		if _, err := body.ReadFrom(io.LimitReader(stream, int64(limit)+1)); err != nil {
+			return respondError(c, fiber.StatusBadRequest, "cannot read request body")
+		}
+		if body.Len() > limit {
+			return bodyTooLarge(c)
+		}
+		request.SetBody(body.Bytes())
+		return c.Next()
+	}
+}
+
+// bodyTooLarge отвечает 413 и закрывает соединение: недочитанный остаток тела
+// иначе был бы принят за следующий запрос
+func bodyTooLarge(c *fiber.Ctx) error {
+	c.Response().SetConnectionClose()
+	return respondError(c, fiber.StatusRequestEntityTooLarge, "request body too large")
+}
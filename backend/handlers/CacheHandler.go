@@ -0,0 +1,32 @@
+package handlers
+
+import (
+	"github.com/gofiber/fiber/v2"
+
+	"db200/internal/store"
+)
+
+type CacheStatsResponse struct {
+	Hits         int64   `json:"hits"`
+	NegativeHits int64   `json:"negative_hits"`
+	Misses       int64   `json:"misses"`
+	Errors       int64   `json:"errors"`
+	HitRatio     float64 `json:"hit_ratio"`
+}
+
+// CacheStats отдаёт счётчики кэша; hit_ratio считает и отрицательные попадания
+func CacheStats(stats func() store.CacheStats) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		s := stats()
+		response := CacheStatsResponse{
+			Hits:         s.Hits,
+			NegativeHits: s.NegativeHits,
+			Misses:       s.Misses,
+			Errors:       s.Errors,
+		}
+		if total := s.Hits + s.NegativeHits + s.Misses; total > 0 {
+			response.HitRatio = float64(s.Hits+s.NegativeHits) / float64(total)
+		}
+		return respondData(c, fiber.StatusOK, response)
+	}
+}
@@ -0,0 +1,328 @@
+package handlers
+
+import (
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+
+	productsdb "db200/internal/db/products"
+	"db200/service"
+)
+
+type (
+	// CategoryRequest - parent_id null или 0 делает категорию корневой
+	CategoryRequest struct {
+		ParentID *int32 `json:"parent_id"`
+		Slug     string `json:"slug"`
+		Name     string `json:"name"`
+	}
+
+	CategoryResponse struct {
+		ID        int32     `json:"id"`
+		ParentID  *int32    `json:"parent_id"`
+		Slug      string    `json:"slug"`
+		Name      string    `json:"name"`
+		CreatedAt time.Time `json:"created_at"`
+	}
+
+	CategoryNodeResponse struct {
+		CategoryResponse
+		Children []CategoryNodeResponse `json:"children"`
+	}
+
+	TagRequest struct {
+		Name string `json:"name"`
+	}
+
+	TagResponse struct {
+		ID   int32  `json:"id"`
+		Name string `json:"name"`
+	}
+
+	SetProductCategoriesRequest struct {
+		CategoryIDs []int32 `json:"category_ids"`
+	}
+
+	SetProductTagsRequest struct {
+		Tags []string `json:"tags"`
+	}
+)
+
+type CategoryHandler struct {
+	Service *service.CategoryService
+}
+
+func NewCategoryHandler(categoryService *service.CategoryService) *CategoryHandler {
+	return &CategoryHandler{
+		Service: categoryService,
+	}
+}
+
+func (h *CategoryHandler) CreateCategory(c *fiber.Ctx) error {
+	var request CategoryRequest
+	if err := c.BodyParser(&request); err != nil {
+		return respondError(c, fiber.StatusBadRequest, "invalid JSON")
+	}
+
+	category, err := h.Service.CreateCategory(c.UserContext(), request.input())
+	if err != nil {
+		return productError(c, err)
+	}
+
+	return respondData(c, fiber.StatusCreated, toCategoryResponse(category))
+}
+
+// ListCategories - плоский список, с ?tree=true - дерево
+func (h *CategoryHandler) ListCategories(c *fiber.Ctx) error {
+	if c.QueryBool("tree") {
+		tree, err := h.Service.CategoryTree(c.UserContext())
+		if err != nil {
+			return productError(c, err)
+		}
+		return respondData(c, fiber.StatusOK, toCategoryNodesResponse(tree))
+	}
+
+	categories, err := h.Service.ListCategories(c.UserContext())
+	if err != nil {
+		return productError(c, err)
+	}
+
+	return respondData(c, fiber.StatusOK, toCategoriesResponse(categories))
+}
+
+func (h *CategoryHandler) GetCategory(c *fiber.Ctx) error {
+	id, err := idParam(c, "id")
+	if err != nil {
+		return respondError(c, fiber.StatusBadRequest, err.Error())
+	}
+
+	category, err := h.Service.GetCategory(c.UserContext(), id)
+	if err != nil {
+		return productError(c, err)
+	}
+
+	return respondData(c, fiber.StatusOK, toCategoryResponse(category))
+}
+
+func (h *CategoryHandler) UpdateCategory(c *fiber.Ctx) error {
+	id, err := idParam(c, "id")
+	if err != nil {
+		return respondError(c, fiber.StatusBadRequest, err.Error())
+	}
+
+	var request CategoryRequest
+	if err := c.BodyParser(&request); err != nil {
+		return respondError(c, fiber.StatusBadRequest, "invalid JSON")
+	}
+
+	category, err := h.Service.UpdateCategory(c.UserContext(), id, request.input())
+	if err != nil {
+		return productError(c, err)
+	}
+
+	return respondData(c, fiber.StatusOK, toCategoryResponse(category))
+}
+
+func (h *CategoryHandler) DeleteCategory(c *fiber.Ctx) error {
+	id, err := idParam(c, "id")
+	if err != nil {
+		return respondError(c, fiber.StatusBadRequest, err.Error())
+	}
+
+	if err := h.Service.DeleteCategory(c.UserContext(), id); err != nil {
+		return productError(c, err)
+	}
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+func (h *CategoryHandler) CreateTag(c *fiber.Ctx) error {
+	var request TagRequest
+	if err := c.BodyParser(&request); err != nil {
+		return respondError(c, fiber.StatusBadRequest, "invalid JSON")
+	}
+
+	tag, err := h.Service.CreateTag(c.UserContext(), request.Name)
+	if err != nil {
+		return productError(c, err)
+	}
+
+	return respondData(c, fiber.StatusCreated, toTagResponse(tag))
+}
+
+func (h *CategoryHandler) ListTags(c *fiber.Ctx) error {
+	tags, err := h.Service.ListTags(c.UserContext())
+	if err != nil {
+		return productError(c, err)
+	}
+
+	return respondData(c, fiber.StatusOK, toTagsResponse(tags))
+}
+
+func (h *CategoryHandler) RenameTag(c *fiber.Ctx) error {
+	id, err := idParam(c, "id")
+	if err != nil {
+		return respondError(c, fiber.StatusBadRequest, err.Error())
+	}
+
+	var request TagRequest
+	if err := c.BodyParser(&request); err != nil {
+		return respondError(c, fiber.StatusBadRequest, "invalid JSON")
+	}
+
+	tag, err := h.Service.RenameTag(c.UserContext(), id, request.Name)
+	if err != nil {
+		return productError(c, err)
+	}
+
+	return respondData(c, fiber.StatusOK, toTagResponse(tag))
+}
+
+func (h *CategoryHandler) DeleteTag(c *fiber.Ctx) error {
+	id, err := idParam(c, "id")
+	if err != nil {
+		return respondError(c, fiber.StatusBadRequest, err.Error())
+	}
+
+	if err := h.Service.DeleteTag(c.UserContext(), id); err != nil {
+		return productError(c, err)
+	}
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+func (h *CategoryHandler) ProductCategories(c *fiber.Ctx) error {
+	id, err := productIDParam(c)
+	if err != nil {
+		return respondError(c, fiber.StatusBadRequest, err.Error())
+	}
+
+	categories, err := h.Service.ProductCategories(c.UserContext(), id)
+	if err != nil {
+		return productError(c, err)
+	}
+
+	return respondData(c, fiber.StatusOK, toCategoriesResponse(categories))
+}
+
+// SetProductCategories заменяет категории продукта целиком
+func (h *CategoryHandler) SetProductCategories(c *fiber.Ctx) error {
+	id, err := productIDParam(c)
+	if err != nil {
+		return respondError(c, fiber.StatusBadRequest, err.Error())
+	}
+
+	var request SetProductCategoriesRequest
+	if err := c.BodyParser(&request); err != nil {
+		return respondError(c, fiber.StatusBadRequest, "invalid JSON")
+	}
+
+	categories, err := h.Service.SetProductCategories(c.UserContext(), id, request.CategoryIDs)
+	if err != nil {
+		return productError(c, err)
+	}
+
+	return respondData(c, fiber.StatusOK, toCategoriesResponse(categories))
+}
+
+func (h *CategoryHandler) ProductTags(c *fiber.Ctx) error {
+	id, err := productIDParam(c)
+	if err != nil {
+		return respondError(c, fiber.StatusBadRequest, err.Error())
+	}
+
+	tags, err := h.Service.ProductTags(c.UserContext(), id)
+	if err != nil {
+		return productError(c, err)
+	}
+
+	return respondData(c, fiber.StatusOK, toTagsResponse(tags))
+}
+
+// SetProductTags заменяет теги продукта целиком, новые теги создаются
+func (h *CategoryHandler) SetProductTags(c *fiber.Ctx) error {
+	id, err := productIDParam(c)
+	if err != nil {
+		return respondError(c, fiber.StatusBadRequest, err.Error())
+	}
+
+	var request SetProductTagsRequest
+	if err := c.BodyParser(&request); err != nil {
+		return respondError(c, fiber.StatusBadRequest, "invalid JSON")
+	}
+
+	tags, err := h.Service.SetProductTags(c.UserContext(), id, request.Tags)
+	if err != nil {
+		return productError(c, err)
+	}
+
+	return respondData(c, fiber.StatusOK, toTagsResponse(tags))
+}
+
+func (r CategoryRequest) input() service.CategoryInput {
+	input := service.CategoryInput{
+		Slug: r.Slug,
+		Name: r.Name,
+	}
+	if r.ParentID != nil {
+		input.ParentID = *r.ParentID
+	}
+	return input
+}
+
+func idParam(c *fiber.Ctx, key string) (int32, error) {
+	id, err := strconv.ParseInt(c.Params(key), 10, 32)
+	if err != nil || id <= 0 {
+		return 0, errors.New("invalid " + key)
+	}
+	return int32(id), nil
+}
+
+func toCategoryResponse(category productsdb.Category) CategoryResponse {
+	response := CategoryResponse{
+		ID:        category.ID,
+		Slug:      category.Slug,
+		Name:      category.Name,
+		CreatedAt: category.CreatedAt,
+	}
+	if category.ParentID.Valid {
+		response.ParentID = &category.ParentID.Int32
+	}
+	return response
+}
+
+func toCategoriesResponse(categories []productsdb.Category) []CategoryResponse {
+	response := make([]CategoryResponse, 0, len(categories))
+	for _, category := range categories {
+		response = append(response, toCategoryResponse(category))
+	}
+	return response
+}
+
+func toCategoryNodesResponse(nodes []service.CategoryNode) []CategoryNodeResponse {
+	response := make([]CategoryNodeResponse, 0, len(nodes))
+	for _, node := range nodes {
+		response = append(response, CategoryNodeResponse{
+			CategoryResponse: toCategoryResponse(node.Category),
+			Children:         toCategoryNodesResponse(node.Children),
+		})
+	}
+	return response
+}
+
+func toTagResponse(tag productsdb.Tag) TagResponse {
+	return TagResponse{
+		ID:   tag.ID,
+		Name: tag.Name,
+	}
+}
+
+func toTagsResponse(tags []productsdb.Tag) []TagResponse {
+	response := make([]TagResponse, 0, len(tags))
+	for _, tag := range tags {
+		response = append(response, toTagResponse(tag))
+	}
+	return response
+}
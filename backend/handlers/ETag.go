@@ -0,0 +1,59 @@
+package handlers
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+
+	productsdb "db200/internal/db/products"
+	"db200/internal/store"
+)
+
+// productETag - сильный ETag из версии продукта: "3"
+func productETag(version int32) string {
+	return strconv.Quote(strconv.FormatInt(int64(version), 10))
+}
+
+func setProductETag(c *fiber.Ctx, product productsdb.Product) {
+	c.Set(fiber.HeaderETag, productETag(product.Version))
+}
+
+// errIfMatchRequired - изменение без If-Match; ответ 428 Precondition Required
+var errIfMatchRequired = errors.New("precondition required: send If-Match with the product ETag or \"*\"")
+
+// ifMatchVersion разбирает If-Match. "*" - store.AnyVersion: клиент явно согласен
+// на любую версию. Без заголовка - errIfMatchRequired, если required, иначе тоже
+// store.AnyVersion. Слабые и составные ETag не поддерживаются: If-Match требует
+// сильного сравнения.
+func ifMatchVersion(c *fiber.Ctx, required bool) (int32, error) {
+	value := strings.TrimSpace(c.Get(fiber.HeaderIfMatch))
+	if value == "" {
+		if required {
+			return 0, errIfMatchRequired
+		}
+		return store.AnyVersion, nil
+	}
+	if value == "*" {
+		return store.AnyVersion, nil
+	}
+
+	unquoted, err := strconv.Unquote(value)
+	if err != nil {
+		return 0, errors.New("precondition failed: malformed If-Match")
+	}
+	version, err := strconv.ParseInt(unquoted, 10, 32)
+	if err != nil || version <= 0 {
+		return 0, errors.New("precondition failed: unknown ETag in If-Match")
+	}
+	return int32(version), nil
+}
+
+// preconditionError - 428 без обязательного If-Match, 412 на некорректный
+func preconditionError(c *fiber.Ctx, err error) error {
+	if errors.Is(err, errIfMatchRequired) {
+		return respondError(c, fiber.StatusPreconditionRequired, err.Error())
+	}
+	return respondError(c, fiber.StatusPreconditionFailed, err.Error())
+}
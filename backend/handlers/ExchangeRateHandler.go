@@ -0,0 +1,61 @@
+package handlers
+
+import (
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+
+	productsdb "db200/internal/db/products"
+)
+
+type (
+	// SetExchangeRateRequest - курс строкой, чтобы не терять точность на float
+	SetExchangeRateRequest struct {
+		Rate string `json:"rate"`
+	}
+
+	ExchangeRateResponse struct {
+		Base      string    `json:"base"`
+		Quote     string    `json:"quote"`
+		Rate      string    `json:"rate"`
+		UpdatedAt time.Time `json:"updated_at"`
+	}
+)
+
+func (h *ProductHandler) ListExchangeRates(c *fiber.Ctx) error {
+	rates, err := h.Service.ListExchangeRates(c.UserContext())
+	if err != nil {
+		return productError(c, err)
+	}
+
+	response := make([]ExchangeRateResponse, 0, len(rates))
+	for _, r := range rates {
+		response = append(response, toExchangeRateResponse(r))
+	}
+
+	return respondData(c, fiber.StatusOK, response)
+}
+
+// SetExchangeRate - PUT /exchange-rates/:base/:quote, курс: сколько quote за одну единицу base
+func (h *ProductHandler) SetExchangeRate(c *fiber.Ctx) error {
+	var request SetExchangeRateRequest
+	if err := c.BodyParser(&request); err != nil {
+		return respondError(c, fiber.StatusBadRequest, "invalid JSON")
+	}
+
+	rate, err := h.Service.SetExchangeRate(c.UserContext(), c.Params("base"), c.Params("quote"), request.Rate)
+	if err != nil {
+		return productError(c, err)
+	}
+
+	return respondData(c, fiber.StatusOK, toExchangeRateResponse(rate))
+}
+
+func toExchangeRateResponse(r productsdb.ExchangeRate) ExchangeRateResponse {
+	return ExchangeRateResponse{
+		Base:      r.Base,
+		Quote:     r.Quote,
+		Rate:      r.Rate,
+		UpdatedAt: r.UpdatedAt,
+	}
+}
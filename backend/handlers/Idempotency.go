@@ -0,0 +1,98 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/sirupsen/logrus"
+
+	"db200/service"
+)
+
+const (
+	HeaderIdempotencyKey     = "Idempotency-Key"
+	HeaderIdempotentReplayed = "Idempotent-Replayed"
+
+	maxIdempotencyKeyLength = 255
+)
+
+// Idempotency - middleware для мутирующих запросов с заголовком Idempotency-Key.
+// Первый запрос выполняется и его ответ сохраняется; повтор с тем же ключом
+// и тем же телом получает сохранённый ответ, с другим телом - 422.
+// Ответы 5xx не сохраняются: ключ освобождается, и повтор выполнится заново.
+// Ключи разных пользователей не пересекаются, поэтому middleware ставится
+// после проверки токена. Без заголовка запрос проходит как обычно.
+func Idempotency(idempotency *service.IdempotencyService) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		key := strings.TrimSpace(c.Get(HeaderIdempotencyKey))
+		if key == "" {
+			return c.Next()
+		}
+		if len(key) > maxIdempotencyKeyLength {
+			return respondError(c, fiber.StatusBadRequest, "idempotency key too long")
+		}
+
+		// Ключ действует в пределах пользователя, метода и пути, отпечаток - хеш тела
+		claims, ok := tokenClaims(c)
+		if !ok || claims.Subject == "" {
+			return respondError(c, fiber.StatusUnauthorized, service.ErrInvalidToken.Error())
+		}
+		scope := "user:" + claims.Subject + " " + c.Method() + " " + c.Path()
+		sum := sha256.Sum256(c.Body())
+		fingerprint := hex.EncodeToString(sum[:])
+
+		// Запись ответа не должна зависеть от того, дождался ли его клиент
+		ctx := context.WithoutCancel(c.UserContext())
+
+		record, started, err := idempotency.Begin(ctx, scope, key, fingerprint)
+		if err != nil {
+			switch {
+			case errors.Is(err, service.ErrIdempotencyKeyReused):
+				return respondError(c, fiber.StatusUnprocessableEntity, err.Error())
+			case errors.Is(err, service.ErrIdempotencyKeyInProgress):
+				return respondError(c, fiber.StatusConflict, err.Error())
+			}
+			logrus.WithError(err).Error("idempotency middleware")
+			return respondError(c, fiber.StatusInternalServerError, "internal server error")
+		}
+
+		if !started {
+			c.Set(HeaderIdempotentReplayed, "true")
+			if record.ContentType != "" {
+				c.Set(fiber.HeaderContentType, record.ContentType)
+			}
+			return c.Status(int(record.StatusCode.Int32)).Send(record.ResponseBody)
+		}
+
+		if err := c.Next(); err != nil {
+			releaseIdempotencyKey(ctx, idempotency, scope, key, record.LockedBy)
+			return err
+		}
+
+		status := c.Response().StatusCode()
+		if status >= fiber.StatusInternalServerError {
+			releaseIdempotencyKey(ctx, idempotency, scope, key, record.LockedBy)
+			return nil
+		}
+
+		body := bytes.Clone(c.Response().Body())
+		contentType := string(c.Response().Header.ContentType())
+		if err := idempotency.Complete(ctx, scope, key, record.LockedBy, status, contentType, body); err != nil {
+			// Ответ уже готов; ключ освободится по таймауту блокировки,
+			// а если его забрал другой запрос - ответ сохранит тот
+			logrus.WithError(err).WithField("scope", scope).Error("save idempotent response")
+		}
+		return nil
+	}
+}
+
+func releaseIdempotencyKey(ctx context.Context, idempotency *service.IdempotencyService, scope, key, lockedBy string) {
+	if err := idempotency.Release(ctx, scope, key, lockedBy); err != nil {
+		logrus.WithError(err).WithField("scope", scope).Error("release idempotency key")
+	}
+}
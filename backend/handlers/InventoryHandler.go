@@ -0,0 +1,259 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+
+	productsdb "db200/internal/db/products"
+	"db200/service"
+)
+
+type (
+	StockChangeRequest struct {
+		Quantity int64  `json:"quantity"`
+		Note     string `json:"note"`
+	}
+
+	StockAdjustmentRequest struct {
+		Delta int64  `json:"delta"`
+		Note  string `json:"note"`
+	}
+
+	StockResponse struct {
+		ProductID int32 `json:"product_id"`
+		OnHand    int64 `json:"on_hand"`
+		Reserved  int64 `json:"reserved"`
+		Available int64 `json:"available"`
+	}
+
+	StockMovementResponse struct {
+		ID            int64     `json:"id"`
+		ProductID     int32     `json:"product_id"`
+		Kind          string    `json:"kind"`
+		Quantity      int64     `json:"quantity"`
+		ReservationID *int64    `json:"reservation_id,omitempty"`
+		Note          string    `json:"note,omitempty"`
+		CreatedAt     time.Time `json:"created_at"`
+	}
+
+	// ReserveStockRequest - ttl_seconds 0 означает срок по умолчанию
+	ReserveStockRequest struct {
+		ProductID  int32 `json:"product_id"`
+		Quantity   int64 `json:"quantity"`
+		TTLSeconds int64 `json:"ttl_seconds"`
+	}
+
+	ReservationResponse struct {
+		ID        int64     `json:"id"`
+		ProductID int32     `json:"product_id"`
+		Quantity  int64     `json:"quantity"`
+		Status    string    `json:"status"`
+		ExpiresAt time.Time `json:"expires_at"`
+		CreatedAt time.Time `json:"created_at"`
+		UpdatedAt time.Time `json:"updated_at"`
+	}
+)
+
+type InventoryHandler struct {
+	Service *service.InventoryService
+}
+
+func NewInventoryHandler(inventoryService *service.InventoryService) *InventoryHandler {
+	return &InventoryHandler{
+		Service: inventoryService,
+	}
+}
+
+func (h *InventoryHandler) GetStock(c *fiber.Ctx) error {
+	id, err := productIDParam(c)
+	if err != nil {
+		return respondError(c, fiber.StatusBadRequest, err.Error())
+	}
+
+	stock, err := h.Service.Stock(c.UserContext(), id)
+	if err != nil {
+		return productError(c, err)
+	}
+
+	return respondData(c, fiber.StatusOK, toStockResponse(stock))
+}
+
+func (h *InventoryHandler) ReceiveStock(c *fiber.Ctx) error {
+	return h.changeStock(c, h.Service.Receive)
+}
+
+func (h *InventoryHandler) SellStock(c *fiber.Ctx) error {
+	return h.changeStock(c, h.Service.Sell)
+}
+
+func (h *InventoryHandler) changeStock(c *fiber.Ctx, change func(ctx context.Context, productID int32, quantity int64, note string) (service.StockLevel, error)) error {
+	id, err := productIDParam(c)
+	if err != nil {
+		return respondError(c, fiber.StatusBadRequest, err.Error())
+	}
+
+	var request StockChangeRequest
+	if err := c.BodyParser(&request); err != nil {
+		return respondError(c, fiber.StatusBadRequest, "invalid JSON")
+	}
+
+	stock, err := change(c.UserContext(), id, request.Quantity, request.Note)
+	if err != nil {
+		return productError(c, err)
+	}
+
+	return respondData(c, fiber.StatusOK, toStockResponse(stock))
+}
+
+func (h *InventoryHandler) AdjustStock(c *fiber.Ctx) error {
+	id, err := productIDParam(c)
+	if err != nil {
+		return respondError(c, fiber.StatusBadRequest, err.Error())
+	}
+
+	var request StockAdjustmentRequest
+	if err := c.BodyParser(&request); err != nil {
+		return respondError(c, fiber.StatusBadRequest, "invalid JSON")
+	}
+
+	stock, err := h.Service.Adjust(c.UserContext(), id, request.Delta, request.Note)
+	if err != nil {
+		return productError(c, err)
+	}
+
+	return respondData(c, fiber.StatusOK, toStockResponse(stock))
+}
+
+func (h *InventoryHandler) StockMovements(c *fiber.Ctx) error {
+	id, err := productIDParam(c)
+	if err != nil {
+		return respondError(c, fiber.StatusBadRequest, err.Error())
+	}
+	limit, err := int32Query(c, "limit")
+	if err != nil {
+		return respondError(c, fiber.StatusBadRequest, err.Error())
+	}
+
+	movements, err := h.Service.Movements(c.UserContext(), id, limit)
+	if err != nil {
+		return productError(c, err)
+	}
+
+	response := make([]StockMovementResponse, 0, len(movements))
+	for _, m := range movements {
+		response = append(response, toStockMovementResponse(m))
+	}
+
+	return respondData(c, fiber.StatusOK, response)
+}
+
+func (h *InventoryHandler) Reserve(c *fiber.Ctx) error {
+	var request ReserveStockRequest
+	if err := c.BodyParser(&request); err != nil {
+		return respondError(c, fiber.StatusBadRequest, "invalid JSON")
+	}
+	if request.TTLSeconds < 0 || request.TTLSeconds > int64(service.MaxReservationTTL/time.Second) {
+		return respondError(c, fiber.StatusBadRequest, "invalid ttl_seconds")
+	}
+
+	reservation, err := h.Service.Reserve(c.UserContext(), service.ReserveInput{
+		ProductID: request.ProductID,
+		Quantity:  request.Quantity,
+		TTL:       time.Duration(request.TTLSeconds) * time.Second,
+	})
+	if err != nil {
+		return productError(c, err)
+	}
+
+	return respondData(c, fiber.StatusCreated, toReservationResponse(reservation))
+}
+
+func (h *InventoryHandler) GetReservation(c *fiber.Ctx) error {
+	id, err := reservationIDParam(c)
+	if err != nil {
+		return respondError(c, fiber.StatusBadRequest, err.Error())
+	}
+
+	reservation, err := h.Service.GetReservation(c.UserContext(), id)
+	if err != nil {
+		return productError(c, err)
+	}
+
+	return respondData(c, fiber.StatusOK, toReservationResponse(reservation))
+}
+
+func (h *InventoryHandler) CommitReservation(c *fiber.Ctx) error {
+	id, err := reservationIDParam(c)
+	if err != nil {
+		return respondError(c, fiber.StatusBadRequest, err.Error())
+	}
+
+	reservation, err := h.Service.Commit(c.UserContext(), id)
+	if err != nil {
+		return productError(c, err)
+	}
+
+	return respondData(c, fiber.StatusOK, toReservationResponse(reservation))
+}
+
+func (h *InventoryHandler) ReleaseReservation(c *fiber.Ctx) error {
+	id, err := reservationIDParam(c)
+	if err != nil {
+		return respondError(c, fiber.StatusBadRequest, err.Error())
+	}
+
+	reservation, err := h.Service.Release(c.UserContext(), id)
+	if err != nil {
+		return productError(c, err)
+	}
+
+	return respondData(c, fiber.StatusOK, toReservationResponse(reservation))
+}
+
+func reservationIDParam(c *fiber.Ctx) (int64, error) {
+	id, err := strconv.ParseInt(c.Params("id"), 10, 64)
+	if err != nil || id <= 0 {
+		return 0, errors.New("invalid reservation id")
+	}
+	return id, nil
+}
+
+func toStockResponse(stock service.StockLevel) StockResponse {
+	return StockResponse{
+		ProductID: stock.ProductID,
+		OnHand:    stock.OnHand,
+		Reserved:  stock.Reserved,
+		Available: stock.Available,
+	}
+}
+
+func toStockMovementResponse(m productsdb.StockMovement) StockMovementResponse {
+	response := StockMovementResponse{
+		ID:        m.ID,
+		ProductID: m.ProductID,
+		Kind:      m.Kind,
+		Quantity:  m.Quantity,
+		Note:      m.Note,
+		CreatedAt: m.CreatedAt,
+	}
+	if m.ReservationID.Valid {
+		response.ReservationID = &m.ReservationID.Int64
+	}
+	return response
+}
+
+func toReservationResponse(r productsdb.StockReservation) ReservationResponse {
+	return ReservationResponse{
+		ID:        r.ID,
+		ProductID: r.ProductID,
+		Quantity:  r.Quantity,
+		Status:    r.Status,
+		ExpiresAt: r.ExpiresAt,
+		CreatedAt: r.CreatedAt,
+		UpdatedAt: r.UpdatedAt,
+	}
+}
@@ -0,0 +1,336 @@
+package handlers
+
+import (
+	"database/sql"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/sirupsen/logrus"
+
+	paymentsdb "db200/internal/db/payments"
+	"db200/service"
+)
+
+type (
+	CreateCustomerRequest struct {
+		Name  string `json:"name"`
+		Email string `json:"email"`
+	}
+
+	CustomerResponse struct {
+		ID        int32     `json:"id"`
+		Name      string    `json:"name"`
+		Email     string    `json:"email"`
+		CreatedAt time.Time `json:"created_at"`
+	}
+
+	// CreateInvoiceRequest - currency пусто - валюта каталога; tax_rate_bp - ставка в базисных пунктах (2000 = 20%)
+	CreateInvoiceRequest struct {
+		CustomerID int32  `json:"customer_id"`
+		Currency   string `json:"currency"`
+		TaxRateBp  int32  `json:"tax_rate_bp"`
+	}
+
+	// AdjustInvoiceRequest - отсутствующее поле не меняется
+	AdjustInvoiceRequest struct {
+		DiscountCents *int64 `json:"discount_cents"`
+		TaxRateBp     *int32 `json:"tax_rate_bp"`
+	}
+
+	AddInvoiceLineRequest struct {
+		ProductID int32 `json:"product_id"`
+		Quantity  int32 `json:"quantity"`
+	}
+
+	InvoiceResponse struct {
+		ID            int32      `json:"id"`
+		Number        string     `json:"number,omitempty"`
+		CustomerID    int32      `json:"customer_id"`
+		Status        string     `json:"status"`
+		Currency      string     `json:"currency"`
+		SubtotalCents int64      `json:"subtotal_cents"`
+		DiscountCents int64      `json:"discount_cents"`
+		TaxRateBp     int32      `json:"tax_rate_bp"`
+		TaxCents      int64      `json:"tax_cents"`
+		TotalCents    int64      `json:"total_cents"`
+		CreatedAt     time.Time  `json:"created_at"`
+		UpdatedAt     time.Time  `json:"updated_at"`
+		IssuedAt      *time.Time `json:"issued_at,omitempty"`
+		PaidAt        *time.Time `json:"paid_at,omitempty"`
+		VoidedAt      *time.Time `json:"voided_at,omitempty"`
+	}
+
+	InvoiceDetailsResponse struct {
+		InvoiceResponse
+		CapturedCents int64                 `json:"captured_cents"`
+		Lines         []InvoiceLineResponse `json:"lines"`
+	}
+
+	// InvoiceLineResponse.ProductID - null, если продукт уже окончательно
+	// удалён из каталога
+	InvoiceLineResponse struct {
+		ID          int64  `json:"id"`
+		ProductID   *int32 `json:"product_id"`
+		Description string `json:"description"`
+		Quantity    int32  `json:"quantity"`
+		PriceCents  int64  `json:"price_cents"`
+		AmountCents int64  `json:"amount_cents"`
+	}
+)
+
+type InvoiceHandler struct {
+	Service *service.InvoiceService
+}
+
+func NewInvoiceHandler(invoiceService *service.InvoiceService) *InvoiceHandler {
+	return &InvoiceHandler{
+		Service: invoiceService,
+	}
+}
+
+func (h *InvoiceHandler) CreateCustomer(c *fiber.Ctx) error {
+	var request CreateCustomerRequest
+	if err := c.BodyParser(&request); err != nil {
+		return respondError(c, fiber.StatusBadRequest, "invalid JSON")
+	}
+
+	customer, err := h.Service.CreateCustomer(c.UserContext(), service.CreateCustomerInput{
+		Name:  request.Name,
+		Email: request.Email,
+	})
+	if err != nil {
+		return invoiceError(c, err)
+	}
+
+	return respondData(c, fiber.StatusCreated, toCustomerResponse(customer))
+}
+
+func (h *InvoiceHandler) GetCustomer(c *fiber.Ctx) error {
+	id, err := idParam(c, "id")
+	if err != nil {
+		return respondError(c, fiber.StatusBadRequest, err.Error())
+	}
+
+	customer, err := h.Service.GetCustomer(c.UserContext(), id)
+	if err != nil {
+		return invoiceError(c, err)
+	}
+
+	return respondData(c, fiber.StatusOK, toCustomerResponse(customer))
+}
+
+func (h *InvoiceHandler) CustomerInvoices(c *fiber.Ctx) error {
+	id, err := idParam(c, "id")
+	if err != nil {
+		return respondError(c, fiber.StatusBadRequest, err.Error())
+	}
+
+	invoices, err := h.Service.ListByCustomer(c.UserContext(), id)
+	if err != nil {
+		return invoiceError(c, err)
+	}
+
+	response := make([]InvoiceResponse, 0, len(invoices))
+	for _, invoice := range invoices {
+		response = append(response, toInvoiceResponse(invoice))
+	}
+
+	return respondData(c, fiber.StatusOK, response)
+}
+
+func (h *InvoiceHandler) CreateInvoice(c *fiber.Ctx) error {
+	var request CreateInvoiceRequest
+	if err := c.BodyParser(&request); err != nil {
+		return respondError(c, fiber.StatusBadRequest, "invalid JSON")
+	}
+
+	invoice, err := h.Service.Create(c.UserContext(), service.CreateInvoiceInput{
+		CustomerID: request.CustomerID,
+		Currency:   request.Currency,
+		TaxRateBp:  request.TaxRateBp,
+	})
+	if err != nil {
+		return invoiceError(c, err)
+	}
+
+	return respondData(c, fiber.StatusCreated, toInvoiceResponse(invoice))
+}
+
+func (h *InvoiceHandler) GetInvoice(c *fiber.Ctx) error {
+	id, err := idParam(c, "id")
+	if err != nil {
+		return respondError(c, fiber.StatusBadRequest, err.Error())
+	}
+
+	details, err := h.Service.Get(c.UserContext(), id)
+	if err != nil {
+		return invoiceError(c, err)
+	}
+
+	return respondData(c, fiber.StatusOK, toInvoiceDetailsResponse(details))
+}
+
+// AdjustInvoice меняет скидку и ставку налога черновика
+func (h *InvoiceHandler) AdjustInvoice(c *fiber.Ctx) error {
+	id, err := idParam(c, "id")
+	if err != nil {
+		return respondError(c, fiber.StatusBadRequest, err.Error())
+	}
+
+	var request AdjustInvoiceRequest
+	if err := c.BodyParser(&request); err != nil {
+		return respondError(c, fiber.StatusBadRequest, "invalid JSON")
+	}
+
+	details, err := h.Service.Adjust(c.UserContext(), id, service.InvoiceAdjustments{
+		DiscountCents: request.DiscountCents,
+		TaxRateBp:     request.TaxRateBp,
+	})
+	if err != nil {
+		return invoiceError(c, err)
+	}
+
+	return respondData(c, fiber.StatusOK, toInvoiceDetailsResponse(details))
+}
+
+// AddInvoiceLine добавляет продукт в черновик по текущей цене каталога
+func (h *InvoiceHandler) AddInvoiceLine(c *fiber.Ctx) error {
+	id, err := idParam(c, "id")
+	if err != nil {
+		return respondError(c, fiber.StatusBadRequest, err.Error())
+	}
+
+	var request AddInvoiceLineRequest
+	if err := c.BodyParser(&request); err != nil {
+		return respondError(c, fiber.StatusBadRequest, "invalid JSON")
+	}
+
+	details, err := h.Service.AddLine(c.UserContext(), id, request.ProductID, request.Quantity)
+	if err != nil {
+		return invoiceError(c, err)
+	}
+
+	return respondData(c, fiber.StatusCreated, toInvoiceDetailsResponse(details))
+}
+
+func (h *InvoiceHandler) RemoveInvoiceLine(c *fiber.Ctx) error {
+	id, err := idParam(c, "id")
+	if err != nil {
+		return respondError(c, fiber.StatusBadRequest, err.Error())
+	}
+	lineID, err := strconv.ParseInt(c.Params("line_id"), 10, 64)
+	if err != nil || lineID <= 0 {
+		return respondError(c, fiber.StatusBadRequest, "invalid line id")
+	}
+
+	details, err := h.Service.RemoveLine(c.UserContext(), id, lineID)
+	if err != nil {
+		return invoiceError(c, err)
+	}
+
+	return respondData(c, fiber.StatusOK, toInvoiceDetailsResponse(details))
+}
+
+// IssueInvoice выставляет черновик и присваивает номер
+func (h *InvoiceHandler) IssueInvoice(c *fiber.Ctx) error {
+	id, err := idParam(c, "id")
+	if err != nil {
+		return respondError(c, fiber.StatusBadRequest, err.Error())
+	}
+
+	details, err := h.Service.Issue(c.UserContext(), id)
+	if err != nil {
+		return invoiceError(c, err)
+	}
+
+	return respondData(c, fiber.StatusOK, toInvoiceDetailsResponse(details))
+}
+
+func (h *InvoiceHandler) VoidInvoice(c *fiber.Ctx) error {
+	id, err := idParam(c, "id")
+	if err != nil {
+		return respondError(c, fiber.StatusBadRequest, err.Error())
+	}
+
+	details, err := h.Service.Void(c.UserContext(), id)
+	if err != nil {
+		return invoiceError(c, err)
+	}
+
+	return respondData(c, fiber.StatusOK, toInvoiceDetailsResponse(details))
+}
+
+func invoiceError(c *fiber.Ctx, err error) error {
+	switch {
+	case errors.Is(err, service.ErrNotFound):
+		return respondError(c, fiber.StatusNotFound, err.Error())
+	case errors.Is(err, service.ErrInvalidInput):
+		return respondError(c, fiber.StatusBadRequest, err.Error())
+	case errors.Is(err, service.ErrConflict):
+		return respondError(c, fiber.StatusConflict, err.Error())
+	}
+
+	logrus.WithError(err).Error("invoice handler")
+	return respondError(c, fiber.StatusInternalServerError, "internal server error")
+}
+
+func toCustomerResponse(customer paymentsdb.Customer) CustomerResponse {
+	return CustomerResponse{
+		ID:        customer.ID,
+		Name:      customer.Name,
+		Email:     customer.Email,
+		CreatedAt: customer.CreatedAt,
+	}
+}
+
+func toInvoiceResponse(invoice paymentsdb.Invoice) InvoiceResponse {
+	return InvoiceResponse{
+		ID:            invoice.ID,
+		Number:        invoice.Number.String,
+		CustomerID:    invoice.CustomerID,
+		Status:        invoice.Status,
+		Currency:      invoice.Currency,
+		SubtotalCents: invoice.SubtotalCents,
+		DiscountCents: invoice.DiscountCents,
+		TaxRateBp:     invoice.TaxRateBp,
+		TaxCents:      invoice.TaxCents,
+		TotalCents:    invoice.TotalCents,
+		CreatedAt:     invoice.CreatedAt,
+		UpdatedAt:     invoice.UpdatedAt,
+		IssuedAt:      nullTime(invoice.IssuedAt),
+		PaidAt:        nullTime(invoice.PaidAt),
+		VoidedAt:      nullTime(invoice.VoidedAt),
+	}
+}
+
+func toInvoiceDetailsResponse(details service.InvoiceDetails) InvoiceDetailsResponse {
+	lines := make([]InvoiceLineResponse, 0, len(details.Lines))
+	for _, line := range details.Lines {
+		response := InvoiceLineResponse{
+			ID:          line.ID,
+			Description: line.Description,
+			Quantity:    line.Quantity,
+			PriceCents:  line.PriceCents,
+			AmountCents: line.AmountCents,
+		}
+		if line.ProductID.Valid {
+			response.ProductID = &line.ProductID.Int32
+		}
+		lines = append(lines, response)
+	}
+
+	return InvoiceDetailsResponse{
+		InvoiceResponse: toInvoiceResponse(details.Invoice),
+		CapturedCents:   details.CapturedCents,
+		Lines:           lines,
+	}
+}
+
+func nullTime(t sql.NullTime) *time.Time {
+	if !t.Valid {
+		return nil
+	}
+	return &t.Time
+}
@@ -0,0 +1,265 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/sirupsen/logrus"
+
+	paymentsdb "db200/internal/db/payments"
+	"db200/service"
+)
+
+type (
+	CreatePaymentRequest struct {
+		InvoiceID   int32 `json:"invoice_id"`
+		AmountCents int32 `json:"amount_cents"`
+	}
+
+	// PaymentAmountRequest - сумма списания или возврата; 0 или пустое тело - весь остаток
+	PaymentAmountRequest struct {
+		AmountCents int64 `json:"amount_cents"`
+	}
+
+	PaymentResponse struct {
+		ID              int32     `json:"id"`
+		InvoiceID       int32     `json:"invoice_id"`
+		AmountCents     int32     `json:"amount_cents"`
+		Status          string    `json:"status"`
+		CreatedAt       time.Time `json:"created_at"`
+		UpdatedAt       time.Time `json:"updated_at"`
+		StatusChangedAt time.Time `json:"status_changed_at"`
+	}
+
+	PaymentLedgerResponse struct {
+		PaymentResponse
+		AuthorizedCents int64 `json:"authorized_cents"`
+		CapturedCents   int64 `json:"captured_cents"`
+		RefundedCents   int64 `json:"refunded_cents"`
+	}
+
+	PaymentOperationResponse struct {
+		ID          int64     `json:"id"`
+		Kind        string    `json:"kind"`
+		AmountCents int64     `json:"amount_cents"`
+		CreatedAt   time.Time `json:"created_at"`
+	}
+
+	PaymentStatusTransitionResponse struct {
+		From      string    `json:"from"`
+		To        string    `json:"to"`
+		CreatedAt time.Time `json:"created_at"`
+	}
+)
+
+type PaymentHandler struct {
+	Service *service.PaymentService
+}
+
+func NewPaymentHandler(paymentService *service.PaymentService) *PaymentHandler {
+	return &PaymentHandler{
+		Service: paymentService,
+	}
+}
+
+func (h *PaymentHandler) CreatePayment(c *fiber.Ctx) error {
+	var request CreatePaymentRequest
+	if err := c.BodyParser(&request); err != nil {
+		return respondError(c, fiber.StatusBadRequest, "invalid JSON")
+	}
+
+	payment, err := h.Service.Create(c.UserContext(), service.CreatePaymentInput{
+		InvoiceID:   request.InvoiceID,
+		AmountCents: request.AmountCents,
+	})
+	if err != nil {
+		return paymentError(c, err)
+	}
+
+	return respondData(c, fiber.StatusCreated, toPaymentResponse(payment))
+}
+
+func (h *PaymentHandler) GetPayment(c *fiber.Ctx) error {
+	id, err := idParam(c, "id")
+	if err != nil {
+		return respondError(c, fiber.StatusBadRequest, err.Error())
+	}
+
+	ledger, err := h.Service.Ledger(c.UserContext(), id)
+	if err != nil {
+		return paymentError(c, err)
+	}
+
+	return respondData(c, fiber.StatusOK, toPaymentLedgerResponse(ledger))
+}
+
+// ListPayments - платежи по счёту: ?invoice_id=<id>
+func (h *PaymentHandler) ListPayments(c *fiber.Ctx) error {
+	invoiceID, err := int32Query(c, "invoice_id")
+	if err != nil {
+		return respondError(c, fiber.StatusBadRequest, err.Error())
+	}
+
+	payments, err := h.Service.ListByInvoice(c.UserContext(), invoiceID)
+	if err != nil {
+		return paymentError(c, err)
+	}
+
+	response := make([]PaymentResponse, 0, len(payments))
+	for _, p := range payments {
+		response = append(response, toPaymentResponse(p))
+	}
+
+	return respondData(c, fiber.StatusOK, response)
+}
+
+// InvoicePayments - платежи по счёту из пути /invoices/:id/payments
+func (h *PaymentHandler) InvoicePayments(c *fiber.Ctx) error {
+	id, err := idParam(c, "id")
+	if err != nil {
+		return respondError(c, fiber.StatusBadRequest, err.Error())
+	}
+
+	payments, err := h.Service.ListByInvoice(c.UserContext(), id)
+	if err != nil {
+		return paymentError(c, err)
+	}
+
+	response := make([]PaymentResponse, 0, len(payments))
+	for _, p := range payments {
+		response = append(response, toPaymentResponse(p))
+	}
+
+	return respondData(c, fiber.StatusOK, response)
+}
+
+// AuthorizePayment авторизует полную сумму платежа
+func (h *PaymentHandler) AuthorizePayment(c *fiber.Ctx) error {
+	return h.paymentOperation(c, func(ctx context.Context, id int32, _ int64) (service.PaymentLedger, error) {
+		return h.Service.Authorize(ctx, id)
+	})
+}
+
+// CapturePayment списывает сумму из авторизованной, можно частями
+func (h *PaymentHandler) CapturePayment(c *fiber.Ctx) error {
+	return h.paymentOperation(c, h.Service.Capture)
+}
+
+// RefundPayment возвращает сумму из списанной, не больше списанного
+func (h *PaymentHandler) RefundPayment(c *fiber.Ctx) error {
+	return h.paymentOperation(c, h.Service.Refund)
+}
+
+// VoidPayment отменяет платёж до списания
+func (h *PaymentHandler) VoidPayment(c *fiber.Ctx) error {
+	return h.paymentOperation(c, func(ctx context.Context, id int32, _ int64) (service.PaymentLedger, error) {
+		return h.Service.Void(ctx, id)
+	})
+}
+
+func (h *PaymentHandler) paymentOperation(c *fiber.Ctx, apply func(context.Context, int32, int64) (service.PaymentLedger, error)) error {
+	id, err := idParam(c, "id")
+	if err != nil {
+		return respondError(c, fiber.StatusBadRequest, err.Error())
+	}
+
+	var request PaymentAmountRequest
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&request); err != nil {
+			return respondError(c, fiber.StatusBadRequest, "invalid JSON")
+		}
+	}
+
+	ledger, err := apply(c.UserContext(), id, request.AmountCents)
+	if err != nil {
+		return paymentError(c, err)
+	}
+
+	return respondData(c, fiber.StatusOK, toPaymentLedgerResponse(ledger))
+}
+
+func (h *PaymentHandler) PaymentOperations(c *fiber.Ctx) error {
+	id, err := idParam(c, "id")
+	if err != nil {
+		return respondError(c, fiber.StatusBadRequest, err.Error())
+	}
+
+	operations, err := h.Service.Operations(c.UserContext(), id)
+	if err != nil {
+		return paymentError(c, err)
+	}
+
+	response := make([]PaymentOperationResponse, 0, len(operations))
+	for _, o := range operations {
+		response = append(response, PaymentOperationResponse{
+			ID:          o.ID,
+			Kind:        o.Kind,
+			AmountCents: o.AmountCents,
+			CreatedAt:   o.CreatedAt,
+		})
+	}
+
+	return respondData(c, fiber.StatusOK, response)
+}
+
+func (h *PaymentHandler) PaymentStatusHistory(c *fiber.Ctx) error {
+	id, err := idParam(c, "id")
+	if err != nil {
+		return respondError(c, fiber.StatusBadRequest, err.Error())
+	}
+
+	transitions, err := h.Service.StatusHistory(c.UserContext(), id)
+	if err != nil {
+		return paymentError(c, err)
+	}
+
+	response := make([]PaymentStatusTransitionResponse, 0, len(transitions))
+	for _, t := range transitions {
+		response = append(response, PaymentStatusTransitionResponse{
+			From:      t.FromStatus,
+			To:        t.ToStatus,
+			CreatedAt: t.CreatedAt,
+		})
+	}
+
+	return respondData(c, fiber.StatusOK, response)
+}
+
+func paymentError(c *fiber.Ctx, err error) error {
+	switch {
+	case errors.Is(err, service.ErrNotFound):
+		return respondError(c, fiber.StatusNotFound, err.Error())
+	case errors.Is(err, service.ErrInvalidInput):
+		return respondError(c, fiber.StatusBadRequest, err.Error())
+	case errors.Is(err, service.ErrConflict):
+		return respondError(c, fiber.StatusConflict, err.Error())
+	case errors.Is(err, service.ErrForbidden):
+		return respondError(c, fiber.StatusForbidden, err.Error())
+	}
+
+	logrus.WithError(err).Error("payment handler")
+	return respondError(c, fiber.StatusInternalServerError, "internal server error")
+}
+
+func toPaymentResponse(p paymentsdb.Payment) PaymentResponse {
+	return PaymentResponse{
+		ID:              p.ID,
+		InvoiceID:       p.InvoiceID,
+		AmountCents:     p.AmountCents,
+		Status:          p.Status,
+		CreatedAt:       p.CreatedAt,
+		UpdatedAt:       p.UpdatedAt,
+		StatusChangedAt: p.StatusChangedAt,
+	}
+}
+
+func toPaymentLedgerResponse(l service.PaymentLedger) PaymentLedgerResponse {
+	return PaymentLedgerResponse{
+		PaymentResponse: toPaymentResponse(l.Payment),
+		AuthorizedCents: l.AuthorizedCents,
+		CapturedCents:   l.CapturedCents,
+		RefundedCents:   l.RefundedCents,
+	}
+}
@@ -0,0 +1,140 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/sirupsen/logrus"
+
+	"db200/internal/webhook"
+	"db200/service"
+)
+
+type (
+	// PaymentWebhookRequest - тело события провайдера
+	PaymentWebhookRequest struct {
+		ID   string `json:"id"`
+		Type string `json:"type"`
+		Data struct {
+			PaymentID   int32 `json:"payment_id"`
+			AmountCents int64 `json:"amount_cents"`
+		} `json:"data"`
+	}
+
+	PaymentWebhookResponse struct {
+		EventID string `json:"event_id"`
+		Outcome string `json:"outcome"`
+		Reason  string `json:"reason,omitempty"`
+	}
+
+	PaymentWebhookEventResponse struct {
+		EventID    string          `json:"event_id"`
+		Type       string          `json:"type"`
+		Outcome    string          `json:"outcome"`
+		Reason     string          `json:"reason,omitempty"`
+		Payload    json.RawMessage `json:"payload"`
+		ReceivedAt time.Time       `json:"received_at"`
+	}
+)
+
+// PaymentWebhookHandler принимает события провайдера платежей.
+// Тело подписывается секретом Secret, см. пакет webhook.
+type PaymentWebhookHandler struct {
+	Service   *service.PaymentService
+	Secret    []byte
+	Tolerance time.Duration
+}
+
+func NewPaymentWebhookHandler(paymentService *service.PaymentService, secret []byte, tolerance time.Duration) *PaymentWebhookHandler {
+	if tolerance <= 0 {
+		tolerance = webhook.DefaultTolerance
+	}
+	return &PaymentWebhookHandler{
+		Service:   paymentService,
+		Secret:    secret,
+		Tolerance: tolerance,
+	}
+}
+
+// ReceivePaymentEvent - приём события. 2xx провайдер считает доставкой,
+// на остальные коды повторяет. Дубликаты и события, которые не применить
+// никогда, отвечают 200 с outcome duplicate/ignored; событие по ещё
+// неизвестному платежу - 404, с недопустимым пока переходом - 409,
+// чтобы провайдер прислал его снова.
+func (h *PaymentWebhookHandler) ReceivePaymentEvent(c *fiber.Ctx) error {
+	body := c.Body()
+	if err := webhook.Verify(h.Secret, c.Get(webhook.SignatureHeader), body, time.Now(), h.Tolerance); err != nil {
+		logrus.WithError(err).WithField("ip", c.IP()).Warn("rejected payment webhook")
+		return respondError(c, fiber.StatusUnauthorized, err.Error())
+	}
+
+	var request PaymentWebhookRequest
+	if err := json.Unmarshal(body, &request); err != nil {
+		return respondError(c, fiber.StatusBadRequest, "invalid JSON")
+	}
+
+	result, err := h.Service.HandleWebhookEvent(c.UserContext(), service.PaymentWebhookEvent{
+		ID:          request.ID,
+		Type:        request.Type,
+		PaymentID:   request.Data.PaymentID,
+		AmountCents: request.Data.AmountCents,
+		Payload:     bytes.Clone(body),
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrInvalidInput):
+			return respondError(c, fiber.StatusBadRequest, err.Error())
+		case errors.Is(err, service.ErrNotFound):
+			return respondError(c, fiber.StatusNotFound, err.Error())
+		case errors.Is(err, service.ErrConflict):
+			logrus.WithError(err).WithField("event_id", request.ID).Warn("deferred payment webhook")
+			return respondError(c, fiber.StatusConflict, err.Error())
+		}
+		logrus.WithError(err).WithField("event_id", request.ID).Error("payment webhook")
+		return respondError(c, fiber.StatusInternalServerError, "internal server error")
+	}
+
+	if result.Outcome == service.WebhookEventIgnored {
+		logrus.WithFields(logrus.Fields{
+			"event_id": request.ID,
+			"type":     request.Type,
+			"reason":   result.Reason,
+		}).Warn("ignored payment webhook")
+	}
+
+	return respondData(c, fiber.StatusOK, PaymentWebhookResponse{
+		EventID: request.ID,
+		Outcome: result.Outcome,
+		Reason:  result.Reason,
+	})
+}
+
+// PaymentWebhookEvents - события провайдера по платежу
+func (h *PaymentWebhookHandler) PaymentWebhookEvents(c *fiber.Ctx) error {
+	id, err := idParam(c, "id")
+	if err != nil {
+		return respondError(c, fiber.StatusBadRequest, err.Error())
+	}
+
+	events, err := h.Service.WebhookEvents(c.UserContext(), id)
+	if err != nil {
+		return paymentError(c, err)
+	}
+
+	response := make([]PaymentWebhookEventResponse, 0, len(events))
+	for _, e := range events {
+		response = append(response, PaymentWebhookEventResponse{
+			EventID:    e.EventID,
+			Type:       e.Type,
+			Outcome:    e.Outcome,
+			Reason:     e.Reason,
+			Payload:    e.Payload,
+			ReceivedAt: e.ReceivedAt,
+		})
+	}
+
+	return respondData(c, fiber.StatusOK, response)
+}
@@ -0,0 +1,614 @@
+package handlers
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/sirupsen/logrus"
+
+	productsdb "db200/internal/db/products"
+	"db200/service"
+)
+
+type (
+	CreateProductRequest struct {
+		Slug        string `json:"slug"`
+		Title       string `json:"title"`
+		Description string `json:"description"`
+		PriceCents  int64  `json:"price_cents"`
+		Currency    string `json:"currency"`
+	}
+
+	// UpdateProductPriceRequest - без currency валюта продукта не меняется
+	UpdateProductPriceRequest struct {
+		PriceCents int64  `json:"price_cents"`
+		Currency   string `json:"currency"`
+	}
+
+	ProductResponse struct {
+		ID          int32      `json:"id"`
+		Slug        string     `json:"slug"`
+		Title       string     `json:"title"`
+		Description string     `json:"description"`
+		PriceCents  int64      `json:"price_cents"`
+		Currency    string     `json:"currency"`
+		Version     int32      `json:"version"`
+		CreatedAt   time.Time  `json:"created_at"`
+		DeletedAt   *time.Time `json:"deleted_at,omitempty"`
+	}
+
+	ListProductsMeta struct {
+		Limit  int32 `json:"limit"`
+		Offset int32 `json:"offset"`
+		Count  int   `json:"count"`
+	}
+
+	ProductsPageMeta struct {
+		Limit      int32  `json:"limit"`
+		Count      int    `json:"count"`
+		HasMore    bool   `json:"has_more"`
+		NextCursor string `json:"next_cursor,omitempty"`
+		PrevCursor string `json:"prev_cursor,omitempty"`
+	}
+
+	SearchProductResponse struct {
+		ProductResponse
+		Rank               float32 `json:"rank"`
+		TitleSnippet       string  `json:"title_snippet"`
+		DescriptionSnippet string  `json:"description_snippet"`
+	}
+
+	ProductPriceResponse struct {
+		ProductID  int32     `json:"product_id"`
+		PriceCents int64     `json:"price_cents"`
+		Currency   string    `json:"currency"`
+		ValidFrom  time.Time `json:"valid_from"`
+	}
+
+	SearchProductsMeta struct {
+		Query  string `json:"query"`
+		Limit  int32  `json:"limit"`
+		Offset int32  `json:"offset"`
+		Total  int64  `json:"total"`
+	}
+)
+
+type ProductHandler struct {
+	Service *service.ProductService
+}
+
+func NewProductHandler(productService *service.ProductService) *ProductHandler {
+	return &ProductHandler{
+		Service: productService,
+	}
+}
+
+func (h *ProductHandler) CreateProduct(c *fiber.Ctx) error {
+	var request CreateProductRequest
+	if err := c.BodyParser(&request); err != nil {
+		return respondError(c, fiber.StatusBadRequest, "invalid JSON")
+	}
+
+	product, err := h.Service.Create(c.UserContext(), service.CreateProductInput{
+		Slug:        request.Slug,
+		Title:       request.Title,
+		Description: request.Description,
+		PriceCents:  request.PriceCents,
+		Currency:    request.Currency,
+	})
+	if err != nil {
+		return productError(c, err)
+	}
+
+	setProductETag(c, product)
+	return respondData(c, fiber.StatusCreated, toProductResponse(product))
+}
+
+// GetProduct - ?currency=EUR пересчитывает цену, ?include_deleted=true показывает удалённые
+// (в исходной валюте, только с products:write - см. registerRoutes).
+// Отдаёт ETag, на совпавший If-None-Match отвечает 304. Пересчитанная цена
+// зависит ещё и от курса, а ETag - только от версии, поэтому такой ответ
+// идёт без ETag.
+func (h *ProductHandler) GetProduct(c *fiber.Ctx) error {
+	id, err := productIDParam(c)
+	if err != nil {
+		return respondError(c, fiber.StatusBadRequest, err.Error())
+	}
+
+	var product productsdb.Product
+	converted := false
+	if c.QueryBool("include_deleted") {
+		product, err = h.Service.GetIncludingDeleted(c.UserContext(), id)
+	} else {
+		converted = c.Query("currency") != ""
+		product, err = h.Service.GetInCurrency(c.UserContext(), id, c.Query("currency"))
+	}
+	if err != nil {
+		return productError(c, err)
+	}
+	if converted {
+		return respondData(c, fiber.StatusOK, toProductResponse(product))
+	}
+
+	setProductETag(c, product)
+	if c.Get(fiber.HeaderIfNoneMatch) == productETag(product.Version) {
+		return c.SendStatus(fiber.StatusNotModified)
+	}
+	return respondData(c, fiber.StatusOK, toProductResponse(product))
+}
+
+func (h *ProductHandler) GetProductBySlug(c *fiber.Ctx) error {
+	product, err := h.Service.GetBySlug(c.UserContext(), c.Params("slug"))
+	if err != nil {
+		return productError(c, err)
+	}
+
+	return respondData(c, fiber.StatusOK, toProductResponse(product))
+}
+
+// ListProducts по умолчанию работает в прежнем режиме LIMIT/OFFSET, чтобы старые
+// клиенты с ?limit= не получили другой формат ответа. Страница по курсору -
+// с after/before или с paginate=cursor для первой страницы.
+// Фильтры: category=<id> (с подкатегориями), tags_any=a,b и tags_all=a,b,
+// min_price_cents/max_price_cents, created_from/created_to (RFC3339) и slug_prefix.
+// sort работает только со смещением и с курсором не сочетается.
+func (h *ProductHandler) ListProducts(c *fiber.Ctx) error {
+	limit, err := int32Query(c, "limit")
+	if err != nil {
+		return respondError(c, fiber.StatusBadRequest, err.Error())
+	}
+	filter, err := productFilterQuery(c)
+	if err != nil {
+		return respondError(c, fiber.StatusBadRequest, err.Error())
+	}
+	cursorMode, err := cursorPagination(c)
+	if err != nil {
+		return respondError(c, fiber.StatusBadRequest, err.Error())
+	}
+	if cursorMode {
+		return h.listProductsPage(c, limit, filter)
+	}
+
+	offset, err := int32Query(c, "offset")
+	if err != nil {
+		return respondError(c, fiber.StatusBadRequest, err.Error())
+	}
+
+	products, err := h.Service.List(c.UserContext(), service.ListInput{
+		Limit:          limit,
+		Offset:         offset,
+		IncludeDeleted: c.QueryBool("include_deleted"),
+		Currency:       c.Query("currency"),
+		Filter:         filter,
+		Sort:           c.Query("sort"),
+	})
+	if err != nil {
+		return productError(c, err)
+	}
+
+	response := make([]ProductResponse, 0, len(products))
+	for _, p := range products {
+		response = append(response, toProductResponse(p))
+	}
+
+	return respondList(c, response, ListProductsMeta{
+		Limit:  limit,
+		Offset: offset,
+		Count:  len(response),
+	})
+}
+
+// cursorPagination - нужен ли режим курсора: paginate=cursor или after/before
+func cursorPagination(c *fiber.Ctx) (bool, error) {
+	var cursor bool
+	switch paginate := c.Query("paginate"); paginate {
+	case "":
+		cursor = c.Query("after") != "" || c.Query("before") != ""
+	case "offset":
+	case "cursor":
+		cursor = true
+	default:
+		return false, fmt.Errorf("unknown paginate %q, expected offset or cursor", paginate)
+	}
+	if cursor && (c.Query("offset") != "" || c.Query("sort") != "") {
+		return false, errors.New("offset and sort cannot be combined with cursor pagination")
+	}
+	return cursor, nil
+}
+
+func (h *ProductHandler) listProductsPage(c *fiber.Ctx, limit int32, filter service.ProductFilter) error {
+	page, err := h.Service.ListPage(c.UserContext(), service.ListPageInput{
+		Limit:          limit,
+		After:          c.Query("after"),
+		Before:         c.Query("before"),
+		IncludeDeleted: c.QueryBool("include_deleted"),
+		Currency:       c.Query("currency"),
+		Filter:         filter,
+	})
+	if err != nil {
+		return productError(c, err)
+	}
+
+	response := make([]ProductResponse, 0, len(page.Products))
+	for _, p := range page.Products {
+		response = append(response, toProductResponse(p))
+	}
+
+	if limit <= 0 {
+		limit = service.DefaultListLimit
+	}
+
+	return respondList(c, response, ProductsPageMeta{
+		Limit:      limit,
+		Count:      len(response),
+		HasMore:    page.HasMore,
+		NextCursor: page.NextCursor,
+		PrevCursor: page.PrevCursor,
+	})
+}
+
+func (h *ProductHandler) SearchProducts(c *fiber.Ctx) error {
+	limit, err := int32Query(c, "limit")
+	if err != nil {
+		return respondError(c, fiber.StatusBadRequest, err.Error())
+	}
+	offset, err := int32Query(c, "offset")
+	if err != nil {
+		return respondError(c, fiber.StatusBadRequest, err.Error())
+	}
+
+	result, err := h.Service.Search(c.UserContext(), service.SearchInput{
+		Query:  c.Query("q"),
+		Limit:  limit,
+		Offset: offset,
+	})
+	if err != nil {
+		return productError(c, err)
+	}
+
+	response := make([]SearchProductResponse, 0, len(result.Items))
+	for _, r := range result.Items {
+		response = append(response, SearchProductResponse{
+			ProductResponse: ProductResponse{
+				ID:          r.ID,
+				Slug:        r.Slug,
+				Title:       r.Title,
+				Description: r.Description,
+				PriceCents:  r.PriceCents,
+				Currency:    r.Currency,
+				CreatedAt:   r.CreatedAt,
+			},
+			Rank:               r.Rank,
+			TitleSnippet:       r.TitleSnippet,
+			DescriptionSnippet: r.DescriptionSnippet,
+		})
+	}
+
+	if limit <= 0 {
+		limit = service.DefaultListLimit
+	}
+
+	return respondList(c, response, SearchProductsMeta{
+		Query:  c.Query("q"),
+		Limit:  limit,
+		Offset: offset,
+		Total:  result.Total,
+	})
+}
+
+// UpdateProductPrice - цена меняется, только если версия из If-Match не изменилась;
+// без If-Match - 428, чтобы клиент не затёр чужое изменение, не видя его
+func (h *ProductHandler) UpdateProductPrice(c *fiber.Ctx) error {
+	id, err := productIDParam(c)
+	if err != nil {
+		return respondError(c, fiber.StatusBadRequest, err.Error())
+	}
+	version, err := ifMatchVersion(c, true)
+	if err != nil {
+		return preconditionError(c, err)
+	}
+
+	var request UpdateProductPriceRequest
+	if err := c.BodyParser(&request); err != nil {
+		return respondError(c, fiber.StatusBadRequest, "invalid JSON")
+	}
+
+	product, err := h.Service.UpdatePrice(c.UserContext(), id, request.PriceCents, request.Currency, version)
+	if err != nil {
+		return productError(c, err)
+	}
+
+	setProductETag(c, product)
+	return respondData(c, fiber.StatusOK, toProductResponse(product))
+}
+
+func (h *ProductHandler) PriceHistory(c *fiber.Ctx) error {
+	id, err := productIDParam(c)
+	if err != nil {
+		return respondError(c, fiber.StatusBadRequest, err.Error())
+	}
+	limit, err := int32Query(c, "limit")
+	if err != nil {
+		return respondError(c, fiber.StatusBadRequest, err.Error())
+	}
+
+	prices, err := h.Service.PriceHistory(c.UserContext(), id, limit)
+	if err != nil {
+		return productError(c, err)
+	}
+
+	response := make([]ProductPriceResponse, 0, len(prices))
+	for _, p := range prices {
+		response = append(response, toProductPriceResponse(p))
+	}
+
+	return respondData(c, fiber.StatusOK, response)
+}
+
+// PriceAt отдаёт цену на момент ?at=<RFC3339>, без параметра - текущую
+func (h *ProductHandler) PriceAt(c *fiber.Ctx) error {
+	id, err := productIDParam(c)
+	if err != nil {
+		return respondError(c, fiber.StatusBadRequest, err.Error())
+	}
+
+	at := time.Now()
+	if value := c.Query("at"); value != "" {
+		at, err = time.Parse(time.RFC3339, value)
+		if err != nil {
+			return respondError(c, fiber.StatusBadRequest, "invalid query parameter at, expected RFC3339")
+		}
+	}
+
+	price, err := h.Service.PriceAt(c.UserContext(), id, at)
+	if err != nil {
+		return productError(c, err)
+	}
+
+	return respondData(c, fiber.StatusOK, toProductPriceResponse(price))
+}
+
+// DeleteProduct - как UpdateProductPrice, требует If-Match
+func (h *ProductHandler) DeleteProduct(c *fiber.Ctx) error {
+	id, err := productIDParam(c)
+	if err != nil {
+		return respondError(c, fiber.StatusBadRequest, err.Error())
+	}
+
+	version, err := ifMatchVersion(c, true)
+	if err != nil {
+		return preconditionError(c, err)
+	}
+
+	if err := h.Service.Delete(c.UserContext(), id, version); err != nil {
+		return productError(c, err)
+	}
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// ImportProducts принимает файл в поле "file" (multipart) или прямо в теле запроса.
+// Формат - из ?format=csv|jsonl, иначе по расширению файла или Content-Type.
+// Режим upsert включается через ?upsert=true.
+func (h *ProductHandler) ImportProducts(c *fiber.Ctx) error {
+	var (
+		body     io.Reader
+		filename string
+	)
+	if file, err := c.FormFile("file"); err == nil {
+		f, err := file.Open()
+		if err != nil {
+			return respondError(c, fiber.StatusBadRequest, "cannot read uploaded file")
+		}
+		defer f.Close()
+		body = f
+		filename = file.Filename
+	} else {
+		body = bytes.NewReader(c.Body())
+	}
+
+	format, err := service.ParseImportFormat(importFormat(c, filename))
+	if err != nil {
+		return productError(c, err)
+	}
+
+	report, err := h.Service.Import(c.UserContext(), body, service.ImportOptions{
+		Format: format,
+		Upsert: c.QueryBool("upsert"),
+	})
+	if err != nil {
+		return productError(c, err)
+	}
+
+	return respondData(c, fiber.StatusOK, report)
+}
+
+func importFormat(c *fiber.Ctx, filename string) string {
+	if format := c.Query("format"); format != "" {
+		return format
+	}
+	if ext := strings.TrimPrefix(filepath.Ext(filename), "."); ext != "" {
+		return ext
+	}
+
+	contentType := strings.ToLower(string(c.Request().Header.ContentType()))
+	switch {
+	case strings.HasPrefix(contentType, "text/csv"):
+		return "csv"
+	case strings.Contains(contentType, "ndjson"), strings.Contains(contentType, "jsonl"):
+		return "jsonl"
+	}
+	return ""
+}
+
+// RestoreProduct - If-Match необязателен: удалённый продукт никто не меняет
+func (h *ProductHandler) RestoreProduct(c *fiber.Ctx) error {
+	id, err := productIDParam(c)
+	if err != nil {
+		return respondError(c, fiber.StatusBadRequest, err.Error())
+	}
+
+	version, err := ifMatchVersion(c, false)
+	if err != nil {
+		return preconditionError(c, err)
+	}
+
+	if err := h.Service.Restore(c.UserContext(), id, version); err != nil {
+		return productError(c, err)
+	}
+
+	product, err := h.Service.Get(c.UserContext(), id)
+	if err != nil {
+		return productError(c, err)
+	}
+
+	setProductETag(c, product)
+	return respondData(c, fiber.StatusOK, toProductResponse(product))
+}
+
+// productError переводит ошибки сервиса в HTTP-статусы
+func productError(c *fiber.Ctx, err error) error {
+	// Конфликт версий проверяем до ErrConflict: это 412, а не 409
+	var versionErr *service.VersionConflictError
+	if errors.As(err, &versionErr) {
+		c.Set(fiber.HeaderETag, productETag(versionErr.Actual))
+		return respondError(c, fiber.StatusPreconditionFailed, err.Error())
+	}
+
+	switch {
+	case errors.Is(err, service.ErrNotFound):
+		return respondError(c, fiber.StatusNotFound, err.Error())
+	case errors.Is(err, service.ErrInvalidInput):
+		return respondError(c, fiber.StatusBadRequest, err.Error())
+	case errors.Is(err, service.ErrConflict):
+		return respondError(c, fiber.StatusConflict, err.Error())
+	case errors.Is(err, service.ErrForbidden):
+		return respondError(c, fiber.StatusForbidden, err.Error())
+	}
+
+	logrus.WithError(err).Error("product handler")
+	return respondError(c, fiber.StatusInternalServerError, "internal server error")
+}
+
+func productIDParam(c *fiber.Ctx) (int32, error) {
+	id, err := strconv.ParseInt(c.Params("id"), 10, 32)
+	if err != nil || id <= 0 {
+		return 0, errors.New("invalid product id")
+	}
+	return int32(id), nil
+}
+
+func int32Query(c *fiber.Ctx, key string) (int32, error) {
+	value := c.Query(key)
+	if value == "" {
+		return 0, nil
+	}
+	parsed, err := strconv.ParseInt(value, 10, 32)
+	if err != nil || parsed < 0 {
+		return 0, errors.New("invalid query parameter " + key)
+	}
+	return int32(parsed), nil
+}
+
+func productFilterQuery(c *fiber.Ctx) (service.ProductFilter, error) {
+	categoryID, err := int32Query(c, "category")
+	if err != nil {
+		return service.ProductFilter{}, err
+	}
+	minPrice, err := int64Query(c, "min_price_cents")
+	if err != nil {
+		return service.ProductFilter{}, err
+	}
+	maxPrice, err := int64Query(c, "max_price_cents")
+	if err != nil {
+		return service.ProductFilter{}, err
+	}
+	createdFrom, err := timeQuery(c, "created_from")
+	if err != nil {
+		return service.ProductFilter{}, err
+	}
+	createdTo, err := timeQuery(c, "created_to")
+	if err != nil {
+		return service.ProductFilter{}, err
+	}
+
+	return service.ProductFilter{
+		CategoryID:    categoryID,
+		AnyTags:       listQuery(c, "tags_any"),
+		AllTags:       listQuery(c, "tags_all"),
+		MinPriceCents: minPrice,
+		MaxPriceCents: maxPrice,
+		CreatedFrom:   createdFrom,
+		CreatedTo:     createdTo,
+		SlugPrefix:    c.Query("slug_prefix"),
+	}, nil
+}
+
+func int64Query(c *fiber.Ctx, key string) (int64, error) {
+	value := c.Query(key)
+	if value == "" {
+		return 0, nil
+	}
+	parsed, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || parsed < 0 {
+		return 0, errors.New("invalid query parameter " + key)
+	}
+	return parsed, nil
+}
+
+// timeQuery разбирает время в RFC3339, пустой параметр - нулевое время
+func timeQuery(c *fiber.Ctx, key string) (time.Time, error) {
+	value := c.Query(key)
+	if value == "" {
+		return time.Time{}, nil
+	}
+	parsed, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, errors.New("invalid query parameter " + key + ", expected RFC3339")
+	}
+	return parsed, nil
+}
+
+// listQuery разбирает параметр вида a,b,c; пустые элементы пропускаются
+func listQuery(c *fiber.Ctx, key string) []string {
+	var values []string
+	for _, v := range strings.Split(c.Query(key), ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			values = append(values, v)
+		}
+	}
+	return values
+}
+
+func toProductResponse(p productsdb.Product) ProductResponse {
+	response := ProductResponse{
+		ID:          p.ID,
+		Slug:        p.Slug,
+		Title:       p.Title,
+		Description: p.Description,
+		PriceCents:  p.PriceCents,
+		Currency:    p.Currency,
+		Version:     p.Version,
+		CreatedAt:   p.CreatedAt,
+	}
+	if p.DeletedAt.Valid {
+		response.DeletedAt = &p.DeletedAt.Time
+	}
+	return response
+}
+
+func toProductPriceResponse(p productsdb.ProductPrice) ProductPriceResponse {
+	return ProductPriceResponse{
+		ProductID:  p.ProductID,
+		PriceCents: p.PriceCents,
+		Currency:   p.Currency,
+		ValidFrom:  p.ValidFrom,
+	}
+}
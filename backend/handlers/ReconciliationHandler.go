@@ -0,0 +1,127 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/sirupsen/logrus"
+
+	paymentsdb "db200/internal/db/payments"
+	"db200/service"
+)
+
+type ReconciliationReportResponse struct {
+	ID         int32     `json:"id"`
+	Source     string    `json:"source"`
+	PeriodFrom time.Time `json:"period_from"`
+	PeriodTo   time.Time `json:"period_to"`
+	RowsTotal  int32     `json:"rows_total"`
+	Matched    int32     `json:"matched"`
+	Missing    int32     `json:"missing"`
+	Extra      int32     `json:"extra"`
+	Mismatched int32     `json:"mismatched"`
+	Invalid    int32     `json:"invalid"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+type ReconciliationHandler struct {
+	Service *service.ReconciliationService
+}
+
+func NewReconciliationHandler(reconciliationService *service.ReconciliationService) *ReconciliationHandler {
+	return &ReconciliationHandler{
+		Service: reconciliationService,
+	}
+}
+
+// ListReconciliations - последние отчёты сверки без строк
+func (h *ReconciliationHandler) ListReconciliations(c *fiber.Ctx) error {
+	limit, err := int32Query(c, "limit")
+	if err != nil {
+		return respondError(c, fiber.StatusBadRequest, err.Error())
+	}
+
+	reports, err := h.Service.List(c.UserContext(), limit)
+	if err != nil {
+		return reconciliationError(c, err)
+	}
+
+	response := make([]ReconciliationReportResponse, 0, len(reports))
+	for _, report := range reports {
+		response = append(response, toReconciliationReportResponse(report))
+	}
+
+	return respondData(c, fiber.StatusOK, response)
+}
+
+// GetReconciliation - отчёт со всеми строками
+func (h *ReconciliationHandler) GetReconciliation(c *fiber.Ctx) error {
+	id, err := idParam(c, "id")
+	if err != nil {
+		return respondError(c, fiber.StatusBadRequest, err.Error())
+	}
+
+	details, err := h.Service.Get(c.UserContext(), id)
+	if err != nil {
+		return reconciliationError(c, err)
+	}
+
+	return respondData(c, fiber.StatusOK, service.NewReconciliationExport(details))
+}
+
+// ExportReconciliation отдаёт отчёт файлом: ?format=csv или json (по умолчанию)
+func (h *ReconciliationHandler) ExportReconciliation(c *fiber.Ctx) error {
+	id, err := idParam(c, "id")
+	if err != nil {
+		return respondError(c, fiber.StatusBadRequest, err.Error())
+	}
+
+	format, err := service.ParseReconciliationFormat(c.Query("format", string(service.ReconciliationFormatJSON)))
+	if err != nil {
+		return reconciliationError(c, err)
+	}
+
+	details, err := h.Service.Get(c.UserContext(), id)
+	if err != nil {
+		return reconciliationError(c, err)
+	}
+
+	contentType := fiber.MIMEApplicationJSONCharsetUTF8
+	if format == service.ReconciliationFormatCSV {
+		contentType = "text/csv; charset=utf-8"
+	}
+	c.Set(fiber.HeaderContentType, contentType)
+	c.Attachment(fmt.Sprintf("reconciliation-%d.%s", id, format))
+
+	return service.WriteReconciliation(c.Response().BodyWriter(), details, format)
+}
+
+func reconciliationError(c *fiber.Ctx, err error) error {
+	switch {
+	case errors.Is(err, service.ErrNotFound):
+		return respondError(c, fiber.StatusNotFound, err.Error())
+	case errors.Is(err, service.ErrInvalidInput):
+		return respondError(c, fiber.StatusBadRequest, err.Error())
+	}
+
+	logrus.WithError(err).Error("reconciliation handler")
+	return respondError(c, fiber.StatusInternalServerError, "internal server error")
+}
+
+func toReconciliationReportResponse(report paymentsdb.ReconciliationReport) ReconciliationReportResponse {
+	return ReconciliationReportResponse{
+		ID:         report.ID,
+		Source:     report.Source,
+		PeriodFrom: report.PeriodFrom,
+		PeriodTo:   report.PeriodTo,
+		RowsTotal:  report.RowsTotal,
+		Matched:    report.Matched,
+		Missing:    report.Missing,
+		Extra:      report.Extra,
+		Mismatched: report.Mismatched,
+		Invalid:    report.Invalid,
+		CreatedAt:  report.CreatedAt,
+	}
+}
@@ -0,0 +1,41 @@
+package handlers
+
+import (
+	"github.com/gofiber/fiber/v2"
+)
+
+// Единый формат ответа API: либо data, либо error
+type (
+	Response struct {
+		Data  interface{}    `json:"data,omitempty"`
+		Meta  interface{}    `json:"meta,omitempty"`
+		Error *ErrorResponse `json:"error,omitempty"`
+	}
+
+	ErrorResponse struct {
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+	}
+)
+
+func respondData(c *fiber.Ctx, status int, data interface{}) error {
+	return c.Status(status).JSON(Response{
+		Data: data,
+	})
+}
+
+func respondList(c *fiber.Ctx, data interface{}, meta interface{}) error {
+	return c.Status(fiber.StatusOK).JSON(Response{
+		Data: data,
+		Meta: meta,
+	})
+}
+
+func respondError(c *fiber.Ctx, status int, message string) error {
+	return c.Status(status).JSON(Response{
+		Error: &ErrorResponse{
+			Code:    status,
+			Message: message,
+		},
+	})
+}
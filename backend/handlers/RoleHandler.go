@@ -0,0 +1,174 @@
+package handlers
+
+import (
+	"errors"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/sirupsen/logrus"
+
+	"db200/service"
+)
+
+type (
+	RoleResponse struct {
+		ID          int32     `json:"id"`
+		Name        string    `json:"name"`
+		Description string    `json:"description"`
+		Permissions []string  `json:"permissions"`
+		CreatedAt   time.Time `json:"created_at"`
+	}
+
+	UserRolesResponse struct {
+		UserID int32    `json:"user_id"`
+		Roles  []string `json:"roles"`
+	}
+
+	AssignRoleRequest struct {
+		Role string `json:"role"`
+	}
+)
+
+type RoleHandler struct {
+	Service *service.RoleService
+}
+
+func NewRoleHandler(roleService *service.RoleService) *RoleHandler {
+	return &RoleHandler{
+		Service: roleService,
+	}
+}
+
+// RequirePermission - middleware после JWT-middleware: пропускает запрос,
+// если хотя бы у одной роли из токена есть permission, иначе 403
+func (h *RoleHandler) RequirePermission(permission string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		claims, ok := tokenClaims(c)
+		if !ok {
+			return respondError(c, fiber.StatusUnauthorized, service.ErrInvalidToken.Error())
+		}
+
+		allowed, err := h.Service.HasPermission(c.UserContext(), claims.Roles, permission)
+		if err != nil {
+			return roleError(c, err)
+		}
+		if !allowed {
+			return respondError(c, fiber.StatusForbidden, "permission "+permission+" required")
+		}
+		return c.Next()
+	}
+}
+
+// ListRoles - все роли с разрешениями
+func (h *RoleHandler) ListRoles(c *fiber.Ctx) error {
+	roles, err := h.Service.Roles(c.UserContext())
+	if err != nil {
+		return roleError(c, err)
+	}
+
+	response := make([]RoleResponse, 0, len(roles))
+	for _, role := range roles {
+		permissions := role.Permissions
+		if permissions == nil {
+			permissions = []string{}
+		}
+		response = append(response, RoleResponse{
+			ID:          role.Role.ID,
+			Name:        role.Role.Name,
+			Description: role.Role.Description,
+			Permissions: permissions,
+			CreatedAt:   role.Role.CreatedAt,
+		})
+	}
+
+	return respondData(c, fiber.StatusOK, response)
+}
+
+func (h *RoleHandler) UserRoles(c *fiber.Ctx) error {
+	userID, err := idParam(c, "id")
+	if err != nil {
+		return respondError(c, fiber.StatusBadRequest, err.Error())
+	}
+
+	return h.respondUserRoles(c, fiber.StatusOK, userID)
+}
+
+// AssignRole выдаёт роль: 201, если выдана сейчас, 200 - если уже была.
+// Пользователь получит её в токене при следующем обновлении.
+func (h *RoleHandler) AssignRole(c *fiber.Ctx) error {
+	userID, err := idParam(c, "id")
+	if err != nil {
+		return respondError(c, fiber.StatusBadRequest, err.Error())
+	}
+	actorID, ok := tokenUserID(c)
+	if !ok {
+		return respondError(c, fiber.StatusUnauthorized, service.ErrInvalidToken.Error())
+	}
+
+	var request AssignRoleRequest
+	if err := c.BodyParser(&request); err != nil {
+		return respondError(c, fiber.StatusBadRequest, "invalid JSON")
+	}
+
+	assigned, err := h.Service.AssignRole(c.UserContext(), userID, request.Role, actorID)
+	if err != nil {
+		return roleError(c, err)
+	}
+
+	status := fiber.StatusOK
+	if assigned {
+		status = fiber.StatusCreated
+	}
+	return h.respondUserRoles(c, status, userID)
+}
+
+// RevokeRole забирает роль; 404, если её у пользователя не было
+func (h *RoleHandler) RevokeRole(c *fiber.Ctx) error {
+	userID, err := idParam(c, "id")
+	if err != nil {
+		return respondError(c, fiber.StatusBadRequest, err.Error())
+	}
+	actorID, ok := tokenUserID(c)
+	if !ok {
+		return respondError(c, fiber.StatusUnauthorized, service.ErrInvalidToken.Error())
+	}
+
+	revoked, err := h.Service.RevokeRole(c.UserContext(), userID, c.Params("role"), actorID)
+	if err != nil {
+		return roleError(c, err)
+	}
+	if !revoked {
+		return respondError(c, fiber.StatusNotFound, "user does not have role "+c.Params("role"))
+	}
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+func (h *RoleHandler) respondUserRoles(c *fiber.Ctx, status int, userID int32) error {
+	roles, err := h.Service.UserRoles(c.UserContext(), userID)
+	if err != nil {
+		return roleError(c, err)
+	}
+	if roles == nil {
+		roles = []string{}
+	}
+
+	return respondData(c, status, UserRolesResponse{
+		UserID: userID,
+		Roles:  roles,
+	})
+}
+
+func roleError(c *fiber.Ctx, err error) error {
+	switch {
+	case errors.Is(err, service.ErrNotFound):
+		return respondError(c, fiber.StatusNotFound, err.Error())
+	case errors.Is(err, service.ErrInvalidInput):
+		return respondError(c, fiber.StatusBadRequest, err.Error())
+	case errors.Is(err, service.ErrForbidden):
+		return respondError(c, fiber.StatusForbidden, err.Error())
+	}
+
+	logrus.WithError(err).Error("role handler")
+	return respondError(c, fiber.StatusInternalServerError, "internal server error")
+}
@@ -0,0 +1,130 @@
+package handlers
+
+import (
+	"errors"
+	"net/url"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/sirupsen/logrus"
+
+	usersdb "db200/internal/db/users"
+	"db200/service"
+)
+
+type (
+	SecurityEventResponse struct {
+		ID        int64     `json:"id"`
+		Kind      string    `json:"kind"`
+		Scope     string    `json:"scope"`
+		Subject   string    `json:"subject"`
+		IP        string    `json:"ip,omitempty"`
+		ActorID   *int32    `json:"actor_id,omitempty"`
+		CreatedAt time.Time `json:"created_at"`
+	}
+
+	UnlockResponse struct {
+		Unlocked bool `json:"unlocked"`
+	}
+)
+
+type SecurityHandler struct {
+	Service *service.LoginThrottleService
+}
+
+func NewSecurityHandler(throttleService *service.LoginThrottleService) *SecurityHandler {
+	return &SecurityHandler{
+		Service: throttleService,
+	}
+}
+
+// UnlockUser снимает блокировку входа в аккаунт; unlocked false - её не было
+func (h *SecurityHandler) UnlockUser(c *fiber.Ctx) error {
+	userID, err := idParam(c, "id")
+	if err != nil {
+		return respondError(c, fiber.StatusBadRequest, err.Error())
+	}
+	actorID, ok := tokenUserID(c)
+	if !ok {
+		return respondError(c, fiber.StatusUnauthorized, service.ErrInvalidToken.Error())
+	}
+
+	unlocked, err := h.Service.UnlockUser(c.UserContext(), userID, actorID)
+	if err != nil {
+		return securityError(c, err)
+	}
+
+	return respondData(c, fiber.StatusOK, UnlockResponse{
+		Unlocked: unlocked,
+	})
+}
+
+// UnlockIP снимает блокировку входа с адреса (IPv4 или IPv6)
+func (h *SecurityHandler) UnlockIP(c *fiber.Ctx) error {
+	actorID, ok := tokenUserID(c)
+	if !ok {
+		return respondError(c, fiber.StatusUnauthorized, service.ErrInvalidToken.Error())
+	}
+
+	// В IPv6 есть двоеточия, клиенты присылают их как %3A
+	ip, err := url.PathUnescape(c.Params("ip"))
+	if err != nil {
+		return respondError(c, fiber.StatusBadRequest, "invalid ip")
+	}
+
+	unlocked, err := h.Service.UnlockIP(c.UserContext(), ip, actorID)
+	if err != nil {
+		return securityError(c, err)
+	}
+
+	return respondData(c, fiber.StatusOK, UnlockResponse{
+		Unlocked: unlocked,
+	})
+}
+
+// SecurityEvents - последние события безопасности, новые первыми
+func (h *SecurityHandler) SecurityEvents(c *fiber.Ctx) error {
+	limit, err := int32Query(c, "limit")
+	if err != nil {
+		return respondError(c, fiber.StatusBadRequest, err.Error())
+	}
+
+	events, err := h.Service.Events(c.UserContext(), limit)
+	if err != nil {
+		return securityError(c, err)
+	}
+
+	response := make([]SecurityEventResponse, 0, len(events))
+	for _, event := range events {
+		response = append(response, toSecurityEventResponse(event))
+	}
+
+	return respondData(c, fiber.StatusOK, response)
+}
+
+func securityError(c *fiber.Ctx, err error) error {
+	switch {
+	case errors.Is(err, service.ErrNotFound):
+		return respondError(c, fiber.StatusNotFound, err.Error())
+	case errors.Is(err, service.ErrInvalidInput):
+		return respondError(c, fiber.StatusBadRequest, err.Error())
+	}
+
+	logrus.WithError(err).Error("security handler")
+	return respondError(c, fiber.StatusInternalServerError, "internal server error")
+}
+
+func toSecurityEventResponse(event usersdb.SecurityEvent) SecurityEventResponse {
+	response := SecurityEventResponse{
+		ID:        event.ID,
+		Kind:      event.Kind,
+		Scope:     event.Scope,
+		Subject:   event.Subject,
+		IP:        event.IP,
+		CreatedAt: event.CreatedAt,
+	}
+	if event.ActorID.Valid {
+		response.ActorID = &event.ActorID.Int32
+	}
+	return response
+}
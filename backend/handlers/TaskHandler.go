@@ -37,6 +37,8 @@ type (
 	}
 )
 
+var ErrNotFound = errors.New("not found")
+
 type TaskStorageInterface interface {
 	CreateTask(task Task) int64
 	GetTask(id int64) (Task, error)
@@ -63,13 +65,11 @@ func (t *TaskHandler) CreateTask(c *fiber.Ctx) error {
 	}
 
 	task := Task{
-		ID:       t.Storage.TaskIDCounter,
 		Desc:     request.Desc,
 		Deadline: request.Deadline,
 	}
 
-	id := t.storage.CreateTask(task)
-	t.storage.taskIDCounter++
+	id := t.Storage.CreateTask(task)
 
 	return c.Status(201).JSON(CreateTaskResponse{
 		ID: id,
@@ -86,7 +86,7 @@ func (t *TaskHandler) GetTask(c *fiber.Ctx) error {
 	if err != nil {
 		return err
 	}
-	task, err := t.storage.GetTask(idInt64)
+	task, err := t.Storage.GetTask(idInt64)
 	if err != nil {
 		if errors.Is(err, ErrNotFound) {
 			return c.SendStatus(fiber.StatusNotFound)
@@ -124,7 +124,7 @@ func (t *TaskHandler) UpdateTask(c *fiber.Ctx) error {
 		Deadline: request.Deadline,
 	}
 
-	_, err = t.storage.UpdateTask(task)
+	_, err = t.Storage.UpdateTask(task)
 	if err != nil {
 		return c.SendStatus(404)
 	}
@@ -143,7 +143,7 @@ func (t *TaskHandler) DeleteTask(c *fiber.Ctx) error {
 		return c.Status(400).SendString("Error ID")
 	}
 
-	err = t.storage.DeleteTask(idInt64)
+	err = t.Storage.DeleteTask(idInt64)
 	if err != nil {
 		return c.SendStatus(404)
 	}
@@ -153,14 +153,21 @@ func (t *TaskHandler) DeleteTask(c *fiber.Ctx) error {
 }
 
 func (t *TaskStorage) CreateTask(task Task) int64 {
-	t.tasks[task.ID] = task
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.TaskIDCounter++
+	task.ID = t.TaskIDCounter
+	t.Tasks[task.ID] = task
 	return task.ID
 
 }
 
 func (t *TaskStorage) GetTask(id int64) (Task, error) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
 
-	result, ok := t.tasks[id]
+	result, ok := t.Tasks[id]
 	if !ok {
 		return Task{}, ErrNotFound
 	}
@@ -169,8 +176,11 @@ func (t *TaskStorage) GetTask(id int64) (Task, error) {
 }
 
 func (t *TaskStorage) UpdateTask(task Task) (Task, error) {
-	t.tasks[task.ID] = task
-	updated, ok := t.tasks[task.ID]
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.Tasks[task.ID] = task
+	updated, ok := t.Tasks[task.ID]
 	if !ok {
 		return Task{}, fmt.Errorf("Item not found")
 	}
@@ -180,12 +190,14 @@ func (t *TaskStorage) UpdateTask(task Task) (Task, error) {
 }
 
 func (t *TaskStorage) DeleteTask(id int64) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
 
-	_, ok := t.tasks[id]
+	_, ok := t.Tasks[id]
 	if !ok {
 		return fmt.Errorf("Item not found")
 	}
-	delete(t.tasks, id)
+	delete(t.Tasks, id)
 
 	return nil
 
@@ -0,0 +1,91 @@
+package cache
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLRUEvictsLeastRecentlyUsed(t *testing.T) {
+	c := NewLRU[string, int](2)
+	c.Set("a", 1, time.Minute)
+	c.Set("b", 2, time.Minute)
+
+	// Чтение освежает a, поэтому вытесняется b
+	if _, ok := c.Get("a"); !ok {
+		t.Fatal("a missing before eviction")
+	}
+	c.Set("c", 3, time.Minute)
+
+	if _, ok := c.Get("b"); ok {
+		t.Error("b survived, want it evicted as least recently used")
+	}
+	if v, ok := c.Get("a"); !ok || v != 1 {
+		t.Errorf("Get(a) = %d, %v; want 1, true", v, ok)
+	}
+	if v, ok := c.Get("c"); !ok || v != 3 {
+		t.Errorf("Get(c) = %d, %v; want 3, true", v, ok)
+	}
+
+	// Перезапись тоже освежает запись и не увеличивает размер
+	c.Set("a", 10, time.Minute)
+	c.Set("d", 4, time.Minute)
+	if _, ok := c.Get("c"); ok {
+		t.Error("c survived, want it evicted after a was overwritten")
+	}
+	if c.Len() != 2 {
+		t.Errorf("Len = %d, want 2", c.Len())
+	}
+}
+
+func TestLRUExpiry(t *testing.T) {
+	now := time.Date(2026, 2, 1, 12, 0, 0, 0, time.UTC)
+	c := NewLRU[int, string](10)
+	c.now = func() time.Time { return now }
+
+	c.Set(1, "short", time.Second)
+	c.Set(2, "long", time.Hour)
+
+	now = now.Add(time.Second - time.Nanosecond)
+	if _, ok := c.Get(1); !ok {
+		t.Error("entry expired before its ttl")
+	}
+
+	now = now.Add(time.Nanosecond)
+	if _, ok := c.Get(1); ok {
+		t.Error("entry returned at its expiry time")
+	}
+	if c.Len() != 1 {
+		t.Errorf("Len = %d, want expired entry removed on Get", c.Len())
+	}
+	if v, ok := c.Get(2); !ok || v != "long" {
+		t.Errorf("Get(2) = %q, %v; want long, true", v, ok)
+	}
+
+	// Перезапись задаёт новый срок вместо старого
+	c.Set(2, "renewed", time.Minute)
+	now = now.Add(2 * time.Minute)
+	if _, ok := c.Get(2); ok {
+		t.Error("overwritten entry kept its old ttl")
+	}
+}
+
+func TestLRUDeleteAndPurge(t *testing.T) {
+	c := NewLRU[int, int](0)
+	c.Set(1, 1, time.Minute)
+	c.Set(2, 2, time.Minute)
+	if c.Len() != 1 {
+		t.Fatalf("Len = %d, want size <= 0 treated as 1", c.Len())
+	}
+
+	c.Delete(2)
+	c.Delete(3)
+	if _, ok := c.Get(2); ok {
+		t.Error("deleted entry still cached")
+	}
+
+	c.Set(4, 4, time.Minute)
+	c.Purge()
+	if _, ok := c.Get(4); ok || c.Len() != 0 {
+		t.Errorf("after Purge: Get ok = %v, Len = %d; want empty cache", ok, c.Len())
+	}
+}
@@ -7,52 +7,75 @@ package paymentsdb
 
 import (
 	"context"
-	"database/sql"
-	"time"
+
+	"github.com/lib/pq"
 )
 
 const createPayment = `-- name: CreatePayment :one
-INSERT INTO payments (invoice_id, amount_cents,status ) values($1,$2,$3)
-RETURNING invoice_id,amount_cents,status,updated_at
+INSERT INTO payments (invoice_id, amount_cents) VALUES ($1, $2)
+RETURNING id,invoice_id,amount_cents,status,updated_at,created_at,status_changed_at
 `
 
 type CreatePaymentParams struct {
-	InvoiceID   string
-	AmountCents int32
-	Status      sql.NullString
-}
-
-type CreatePaymentRow struct {
-	InvoiceID   string
+	InvoiceID   int32
 	AmountCents int32
-	Status      sql.NullString
-	UpdatedAt   time.Time
 }
 
-func (q *Queries) CreatePayment(ctx context.Context, arg CreatePaymentParams) (CreatePaymentRow, error) {
-	row := q.db.QueryRowContext(ctx, createPayment, arg.InvoiceID, arg.AmountCents, arg.Status)
-	var i CreatePaymentRow
+func (q *Queries) CreatePayment(ctx context.Context, arg CreatePaymentParams) (Payment, error) {
+	row := q.db.QueryRowContext(ctx, createPayment, arg.InvoiceID, arg.AmountCents)
+	var i Payment
 	err := row.Scan(
+		&i.ID,
 		&i.InvoiceID,
 		&i.AmountCents,
 		&i.Status,
 		&i.UpdatedAt,
+		&i.CreatedAt,
+		&i.StatusChangedAt,
+	)
+	return i, err
+}
+
+const insertPaymentStatusTransition = `-- name: InsertPaymentStatusTransition :one
+INSERT INTO payment_status_transitions (payment_id, from_status, to_status)
+VALUES ($1, $2, $3)
+RETURNING id,payment_id,from_status,to_status,created_at
+`
+
+type InsertPaymentStatusTransitionParams struct {
+	PaymentID  int32
+	FromStatus string
+	ToStatus   string
+}
+
+func (q *Queries) InsertPaymentStatusTransition(ctx context.Context, arg InsertPaymentStatusTransitionParams) (PaymentStatusTransition, error) {
+	row := q.db.QueryRowContext(ctx, insertPaymentStatusTransition, arg.PaymentID, arg.FromStatus, arg.ToStatus)
+	var i PaymentStatusTransition
+	err := row.Scan(
+		&i.ID,
+		&i.PaymentID,
+		&i.FromStatus,
+		&i.ToStatus,
+		&i.CreatedAt,
 	)
 	return i, err
 }
 
 const setPaymentStatus = `-- name: SetPaymentStatus :one
-UPDATE payments set status = $1, updated_at = CURRENT_TIMESTAMP where id = $2
-RETURNING id, invoice_id, amount_cents, status, updated_at
+UPDATE payments
+SET status = $1, updated_at = CURRENT_TIMESTAMP, status_changed_at = CURRENT_TIMESTAMP
+WHERE id = $2 AND status = ANY($3::text[])
+RETURNING id,invoice_id,amount_cents,status,updated_at,created_at,status_changed_at
 `
 
 type SetPaymentStatusParams struct {
-	Status sql.NullString
-	ID     int32
+	Status       string
+	ID           int32
+	FromStatuses []string
 }
 
 func (q *Queries) SetPaymentStatus(ctx context.Context, arg SetPaymentStatusParams) (Payment, error) {
-	row := q.db.QueryRowContext(ctx, setPaymentStatus, arg.Status, arg.ID)
+	row := q.db.QueryRowContext(ctx, setPaymentStatus, arg.Status, arg.ID, pq.Array(arg.FromStatuses))
 	var i Payment
 	err := row.Scan(
 		&i.ID,
@@ -60,6 +83,8 @@ func (q *Queries) SetPaymentStatus(ctx context.Context, arg SetPaymentStatusPara
 		&i.AmountCents,
 		&i.Status,
 		&i.UpdatedAt,
+		&i.CreatedAt,
+		&i.StatusChangedAt,
 	)
 	return i, err
 }
@@ -6,13 +6,110 @@ package paymentsdb
 
 import (
 	"database/sql"
+	"encoding/json"
 	"time"
 )
 
+type Customer struct {
+	ID        int32
+	Name      string
+	Email     string
+	CreatedAt time.Time
+}
+
+type Invoice struct {
+	ID            int32
+	Number        sql.NullString
+	CustomerID    int32
+	Status        string
+	Currency      string
+	SubtotalCents int64
+	DiscountCents int64
+	TaxRateBp     int32
+	TaxCents      int64
+	TotalCents    int64
+	CreatedAt     time.Time
+	UpdatedAt     time.Time
+	IssuedAt      sql.NullTime
+	PaidAt        sql.NullTime
+	VoidedAt      sql.NullTime
+}
+
+type InvoiceLine struct {
+	ID          int64
+	InvoiceID   int32
+	ProductID   sql.NullInt32
+	Description string
+	Quantity    int32
+	PriceCents  int64
+	AmountCents int64
+	CreatedAt   time.Time
+}
+
+type InvoiceNumberSequence struct {
+	Year       int32
+	LastNumber int32
+}
+
 type Payment struct {
-	ID          int32
-	InvoiceID   string
-	AmountCents int32
-	Status      sql.NullString
-	UpdatedAt   time.Time
+	ID              int32
+	InvoiceID       int32
+	AmountCents     int32
+	Status          string
+	UpdatedAt       time.Time
+	CreatedAt       time.Time
+	StatusChangedAt time.Time
+}
+
+type PaymentOperation struct {
+	ID          int64
+	PaymentID   int32
+	Kind        string
+	AmountCents int64
+	CreatedAt   time.Time
+}
+
+type PaymentStatusTransition struct {
+	ID         int64
+	PaymentID  int32
+	FromStatus string
+	ToStatus   string
+	CreatedAt  time.Time
+}
+
+type PaymentWebhookEvent struct {
+	EventID    string
+	Type       string
+	PaymentID  int32
+	Payload    json.RawMessage
+	Outcome    string
+	Reason     string
+	ReceivedAt time.Time
+}
+
+type ReconciliationEntry struct {
+	ID            int64
+	ReportID      int32
+	Status        string
+	Line          sql.NullInt32
+	InvoiceRef    string
+	SettlementRef string
+	PaymentID     sql.NullInt32
+	SettledCents  sql.NullInt64
+	ExpectedCents sql.NullInt64
+	Note          string
+}
+
+type ReconciliationReport struct {
+	ID         int32
+	Source     string
+	PeriodFrom time.Time
+	PeriodTo   time.Time
+	RowsTotal  int32
+	Matched    int32
+	Missing    int32
+	Extra      int32
+	Mismatched int32
+	Invalid    int32
+	CreatedAt  time.Time
 }
@@ -9,8 +9,40 @@ import (
 )
 
 type Querier interface {
-	CreatePayment(ctx context.Context, arg CreatePaymentParams) (CreatePaymentRow, error)
+	CreateCustomer(ctx context.Context, arg CreateCustomerParams) (Customer, error)
+	CreateInvoice(ctx context.Context, arg CreateInvoiceParams) (Invoice, error)
+	CreatePayment(ctx context.Context, arg CreatePaymentParams) (Payment, error)
+	CreateReconciliationReport(ctx context.Context, arg CreateReconciliationReportParams) (ReconciliationReport, error)
+	DeleteInvoiceLine(ctx context.Context, arg DeleteInvoiceLineParams) (int64, error)
+	GetCustomer(ctx context.Context, id int32) (Customer, error)
+	GetInvoice(ctx context.Context, id int32) (Invoice, error)
+	GetInvoiceForShare(ctx context.Context, id int32) (Invoice, error)
+	GetInvoiceForUpdate(ctx context.Context, id int32) (Invoice, error)
+	GetPayment(ctx context.Context, id int32) (Payment, error)
+	GetPaymentForUpdate(ctx context.Context, id int32) (Payment, error)
+	GetReconciliationReport(ctx context.Context, id int32) (ReconciliationReport, error)
+	InsertInvoiceLine(ctx context.Context, arg InsertInvoiceLineParams) (InvoiceLine, error)
+	InsertPaymentOperation(ctx context.Context, arg InsertPaymentOperationParams) (PaymentOperation, error)
+	InsertPaymentStatusTransition(ctx context.Context, arg InsertPaymentStatusTransitionParams) (PaymentStatusTransition, error)
+	InsertPaymentWebhookEvent(ctx context.Context, arg InsertPaymentWebhookEventParams) (PaymentWebhookEvent, error)
+	InsertReconciliationEntry(ctx context.Context, arg InsertReconciliationEntryParams) error
+	InvoiceCapturedCents(ctx context.Context, invoiceID int32) (int64, error)
+	IssueInvoice(ctx context.Context, arg IssueInvoiceParams) (Invoice, error)
+	ListInvoiceLines(ctx context.Context, invoiceID int32) ([]InvoiceLine, error)
+	ListInvoicesByCustomer(ctx context.Context, customerID int32) ([]Invoice, error)
+	ListPaymentOperations(ctx context.Context, paymentID int32) ([]PaymentOperation, error)
+	ListPaymentStatusTransitions(ctx context.Context, paymentID int32) ([]PaymentStatusTransition, error)
+	ListPaymentWebhookEvents(ctx context.Context, paymentID int32) ([]PaymentWebhookEvent, error)
+	ListPaymentsByInvoice(ctx context.Context, invoiceID int32) ([]Payment, error)
+	ListReconciliationEntries(ctx context.Context, reportID int32) ([]ReconciliationEntry, error)
+	ListReconciliationReports(ctx context.Context, limit int32) ([]ReconciliationReport, error)
+	ListSettlementCandidates(ctx context.Context, arg ListSettlementCandidatesParams) ([]ListSettlementCandidatesRow, error)
+	MarkInvoicePaid(ctx context.Context, arg MarkInvoicePaidParams) (int64, error)
+	NextInvoiceNumber(ctx context.Context, year int32) (int32, error)
+	PaymentOperationTotals(ctx context.Context, paymentID int32) (PaymentOperationTotalsRow, error)
 	SetPaymentStatus(ctx context.Context, arg SetPaymentStatusParams) (Payment, error)
+	UpdateInvoiceTotals(ctx context.Context, arg UpdateInvoiceTotalsParams) (Invoice, error)
+	VoidInvoice(ctx context.Context, id int32) (Invoice, error)
 }
 
 var _ Querier = (*Queries)(nil)
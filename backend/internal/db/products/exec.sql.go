@@ -7,19 +7,21 @@ package productsdb
 
 import (
 	"context"
+	"time"
 )
 
 const createProduct = `-- name: CreateProduct :one
-INSERT INTO products (slug,title,description,price_cents)
-values($1,$2,$3,$4)
-RETURNING id,slug,title,description,price_cents,created_at
+INSERT INTO products (slug,title,description,price_cents,currency)
+values($1,$2,$3,$4,$5)
+RETURNING id,slug,title,description,price_cents,created_at,deleted_at,currency,version
 `
 
 type CreateProductParams struct {
 	Slug        string
 	Title       string
 	Description string
-	PriceCents  int32
+	PriceCents  int64
+	Currency    string
 }
 
 func (q *Queries) CreateProduct(ctx context.Context, arg CreateProductParams) (Product, error) {
@@ -28,6 +30,7 @@ func (q *Queries) CreateProduct(ctx context.Context, arg CreateProductParams) (P
 		arg.Title,
 		arg.Description,
 		arg.PriceCents,
+		arg.Currency,
 	)
 	var i Product
 	err := row.Scan(
@@ -37,6 +40,47 @@ func (q *Queries) CreateProduct(ctx context.Context, arg CreateProductParams) (P
 		&i.Description,
 		&i.PriceCents,
 		&i.CreatedAt,
+		&i.DeletedAt,
+		&i.Currency,
+		&i.Version,
+	)
+	return i, err
+}
+
+const createProductIfSlugFree = `-- name: CreateProductIfSlugFree :one
+INSERT INTO products (slug,title,description,price_cents,currency)
+values($1,$2,$3,$4,$5)
+ON CONFLICT (slug) DO NOTHING
+RETURNING id,slug,title,description,price_cents,created_at,deleted_at,currency,version
+`
+
+type CreateProductIfSlugFreeParams struct {
+	Slug        string
+	Title       string
+	Description string
+	PriceCents  int64
+	Currency    string
+}
+
+func (q *Queries) CreateProductIfSlugFree(ctx context.Context, arg CreateProductIfSlugFreeParams) (Product, error) {
+	row := q.db.QueryRowContext(ctx, createProductIfSlugFree,
+		arg.Slug,
+		arg.Title,
+		arg.Description,
+		arg.PriceCents,
+		arg.Currency,
+	)
+	var i Product
+	err := row.Scan(
+		&i.ID,
+		&i.Slug,
+		&i.Title,
+		&i.Description,
+		&i.PriceCents,
+		&i.CreatedAt,
+		&i.DeletedAt,
+		&i.Currency,
+		&i.Version,
 	)
 	return i, err
 }
@@ -62,19 +106,89 @@ func (q *Queries) DeleteProduct(ctx context.Context, id int32) (int64, error) {
 	return result.RowsAffected()
 }
 
-const updateProductPrice = `-- name: UpdateProductPrice :execrows
-UPDATE products set price_cents=$1 where id =$2
+const purgeDeletedProducts = `-- name: PurgeDeletedProducts :execrows
+DELETE from products
+where deleted_at IS NOT NULL AND deleted_at < $1::timestamp
 `
 
-type UpdateProductPriceParams struct {
-	PriceCents int32
-	ID         int32
+func (q *Queries) PurgeDeletedProducts(ctx context.Context, deletedBefore time.Time) (int64, error) {
+	result, err := q.db.ExecContext(ctx, purgeDeletedProducts, deletedBefore)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const restoreProduct = `-- name: RestoreProduct :execrows
+UPDATE products set deleted_at = NULL, version = version + 1
+where id = $1 AND deleted_at IS NOT NULL
+AND ($2::int = 0 OR version = $2::int)
+`
+
+type RestoreProductParams struct {
+	ID              int32
+	ExpectedVersion int32
+}
+
+func (q *Queries) RestoreProduct(ctx context.Context, arg RestoreProductParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, restoreProduct, arg.ID, arg.ExpectedVersion)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
 }
 
-func (q *Queries) UpdateProductPrice(ctx context.Context, arg UpdateProductPriceParams) (int64, error) {
-	result, err := q.db.ExecContext(ctx, updateProductPrice, arg.PriceCents, arg.ID)
+const softDeleteProduct = `-- name: SoftDeleteProduct :execrows
+UPDATE products set deleted_at = CURRENT_TIMESTAMP, version = version + 1
+where id = $1 AND deleted_at IS NULL
+AND ($2::int = 0 OR version = $2::int)
+`
+
+type SoftDeleteProductParams struct {
+	ID              int32
+	ExpectedVersion int32
+}
+
+func (q *Queries) SoftDeleteProduct(ctx context.Context, arg SoftDeleteProductParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, softDeleteProduct, arg.ID, arg.ExpectedVersion)
 	if err != nil {
 		return 0, err
 	}
 	return result.RowsAffected()
 }
+
+const updateProductPrice = `-- name: UpdateProductPrice :one
+UPDATE products set price_cents=$1, currency=COALESCE(NULLIF($2, ''), currency), version = version + 1
+where id = $3 AND deleted_at IS NULL
+AND ($4::int = 0 OR version = $4::int)
+RETURNING id,slug,title,description,price_cents,created_at,deleted_at,currency,version
+`
+
+type UpdateProductPriceParams struct {
+	PriceCents      int64
+	Currency        string
+	ID              int32
+	ExpectedVersion int32
+}
+
+func (q *Queries) UpdateProductPrice(ctx context.Context, arg UpdateProductPriceParams) (Product, error) {
+	row := q.db.QueryRowContext(ctx, updateProductPrice,
+		arg.PriceCents,
+		arg.Currency,
+		arg.ID,
+		arg.ExpectedVersion,
+	)
+	var i Product
+	err := row.Scan(
+		&i.ID,
+		&i.Slug,
+		&i.Title,
+		&i.Description,
+		&i.PriceCents,
+		&i.CreatedAt,
+		&i.DeletedAt,
+		&i.Currency,
+		&i.Version,
+	)
+	return i, err
+}
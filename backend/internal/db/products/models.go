@@ -5,14 +5,88 @@
 package productsdb
 
 import (
+	"database/sql"
 	"time"
 )
 
+type Category struct {
+	ID        int32
+	ParentID  sql.NullInt32
+	Slug      string
+	Name      string
+	CreatedAt time.Time
+}
+
+type ExchangeRate struct {
+	Base      string
+	Quote     string
+	Rate      string
+	UpdatedAt time.Time
+}
+
+type Inventory struct {
+	ProductID int32
+	OnHand    int64
+	Reserved  int64
+	UpdatedAt time.Time
+}
+
 type Product struct {
 	ID          int32
 	Slug        string
 	Title       string
 	Description string
-	PriceCents  int32
+	PriceCents  int64
 	CreatedAt   time.Time
+	DeletedAt   sql.NullTime
+	Currency    string
+	Version     int32
+}
+
+type ProductCategory struct {
+	ProductID  int32
+	CategoryID int32
+}
+
+type ProductPrice struct {
+	ID         int64
+	ProductID  int32
+	PriceCents int64
+	ValidFrom  time.Time
+	Currency   string
+}
+
+type ProductSearch struct {
+	ProductID int32
+	Document  interface{}
+}
+
+type ProductTag struct {
+	ProductID int32
+	TagID     int32
+}
+
+type StockMovement struct {
+	ID            int64
+	ProductID     int32
+	Kind          string
+	Quantity      int64
+	ReservationID sql.NullInt64
+	Note          string
+	CreatedAt     time.Time
+}
+
+type StockReservation struct {
+	ID        int64
+	ProductID int32
+	Quantity  int64
+	Status    string
+	ExpiresAt time.Time
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+type Tag struct {
+	ID   int32
+	Name string
 }
@@ -6,15 +6,63 @@ package productsdb
 
 import (
 	"context"
+	"time"
 )
 
 type Querier interface {
+	AddProductCategories(ctx context.Context, arg AddProductCategoriesParams) error
+	AddProductTags(ctx context.Context, arg AddProductTagsParams) error
+	AddStock(ctx context.Context, arg AddStockParams) (Inventory, error)
+	CategorySubtreeIDs(ctx context.Context, rootID int32) ([]int32, error)
+	CommitReservation(ctx context.Context, id int64) (StockReservation, error)
+	CommitReservedStock(ctx context.Context, arg CommitReservedStockParams) (Inventory, error)
+	CountSearchProducts(ctx context.Context, query string) (int64, error)
+	CreateCategory(ctx context.Context, arg CreateCategoryParams) (Category, error)
 	CreateProduct(ctx context.Context, arg CreateProductParams) (Product, error)
+	CreateProductIfSlugFree(ctx context.Context, arg CreateProductIfSlugFreeParams) (Product, error)
+	CreateReservation(ctx context.Context, arg CreateReservationParams) (StockReservation, error)
+	CreateTag(ctx context.Context, name string) (Tag, error)
 	DeleteAllProducts(ctx context.Context) error
+	DeleteCategory(ctx context.Context, id int32) (int64, error)
 	DeleteProduct(ctx context.Context, id int32) (int64, error)
-	GetProductByID(ctx context.Context, id int32) (Product, error)
-	ListProducts(ctx context.Context, arg ListProductsParams) ([]Product, error)
-	UpdateProductPrice(ctx context.Context, arg UpdateProductPriceParams) (int64, error)
+	DeleteProductCategories(ctx context.Context, productID int32) error
+	DeleteProductTags(ctx context.Context, productID int32) error
+	DeleteTag(ctx context.Context, id int32) (int64, error)
+	EnsureTags(ctx context.Context, names []string) ([]Tag, error)
+	ExpireReservations(ctx context.Context) (int64, error)
+	GetCategory(ctx context.Context, id int32) (Category, error)
+	GetExchangeRate(ctx context.Context, arg GetExchangeRateParams) (ExchangeRate, error)
+	GetInventory(ctx context.Context, productID int32) (Inventory, error)
+	GetProductByID(ctx context.Context, arg GetProductByIDParams) (Product, error)
+	GetProductBySlug(ctx context.Context, slug string) (Product, error)
+	GetProductPriceAt(ctx context.Context, arg GetProductPriceAtParams) (ProductPrice, error)
+	GetReservation(ctx context.Context, id int64) (StockReservation, error)
+	GetTag(ctx context.Context, id int32) (Tag, error)
+	InsertProductPrice(ctx context.Context, arg InsertProductPriceParams) (ProductPrice, error)
+	InsertProductsBatch(ctx context.Context, arg InsertProductsBatchParams) ([]InsertProductsBatchRow, error)
+	InsertStockMovement(ctx context.Context, arg InsertStockMovementParams) (StockMovement, error)
+	ListCategories(ctx context.Context) ([]Category, error)
+	ListExchangeRates(ctx context.Context) ([]ExchangeRate, error)
+	ListProductCategories(ctx context.Context, productID int32) ([]Category, error)
+	ListProductPrices(ctx context.Context, arg ListProductPricesParams) ([]ProductPrice, error)
+	ListProductTags(ctx context.Context, productID int32) ([]Tag, error)
+	ListStockMovements(ctx context.Context, arg ListStockMovementsParams) ([]StockMovement, error)
+	ListTags(ctx context.Context) ([]Tag, error)
+	LockCategoryTree(ctx context.Context) error
+	PurgeDeletedProducts(ctx context.Context, deletedBefore time.Time) (int64, error)
+	RecordCurrentPrices(ctx context.Context, ids []int32) (int64, error)
+	ReleaseReservation(ctx context.Context, id int64) (StockReservation, error)
+	ReleaseReservedStock(ctx context.Context, arg ReleaseReservedStockParams) (Inventory, error)
+	RemoveStock(ctx context.Context, arg RemoveStockParams) (Inventory, error)
+	RenameTag(ctx context.Context, arg RenameTagParams) (int64, error)
+	ReserveStock(ctx context.Context, arg ReserveStockParams) (Inventory, error)
+	RestoreProduct(ctx context.Context, arg RestoreProductParams) (int64, error)
+	SearchProducts(ctx context.Context, arg SearchProductsParams) ([]SearchProductsRow, error)
+	SoftDeleteProduct(ctx context.Context, arg SoftDeleteProductParams) (int64, error)
+	UpdateCategory(ctx context.Context, arg UpdateCategoryParams) (int64, error)
+	UpdateProductPrice(ctx context.Context, arg UpdateProductPriceParams) (Product, error)
+	UpsertExchangeRate(ctx context.Context, arg UpsertExchangeRateParams) (ExchangeRate, error)
+	UpsertProductsBatch(ctx context.Context, arg UpsertProductsBatchParams) ([]UpsertProductsBatchRow, error)
 }
 
 var _ Querier = (*Queries)(nil)
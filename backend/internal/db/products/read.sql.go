@@ -10,12 +10,18 @@ import (
 )
 
 const getProductByID = `-- name: GetProductByID :one
-SELECT id,slug,title,description,price_cents,created_at 
+SELECT id,slug,title,description,price_cents,created_at,deleted_at,currency,version
 from products where id = $1
+AND (deleted_at IS NULL OR $2::bool)
 `
 
-func (q *Queries) GetProductByID(ctx context.Context, id int32) (Product, error) {
-	row := q.db.QueryRowContext(ctx, getProductByID, id)
+type GetProductByIDParams struct {
+	ID             int32
+	IncludeDeleted bool
+}
+
+func (q *Queries) GetProductByID(ctx context.Context, arg GetProductByIDParams) (Product, error) {
+	row := q.db.QueryRowContext(ctx, getProductByID, arg.ID, arg.IncludeDeleted)
 	var i Product
 	err := row.Scan(
 		&i.ID,
@@ -24,46 +30,31 @@ func (q *Queries) GetProductByID(ctx context.Context, id int32) (Product, error)
 		&i.Description,
 		&i.PriceCents,
 		&i.CreatedAt,
+		&i.DeletedAt,
+		&i.Currency,
+		&i.Version,
 	)
 	return i, err
 }
 
-const listProducts = `-- name: ListProducts :many
-SELECT id,slug,title,description,price_cents,created_at FROM products
-LIMIT $1 OFFSET $2
+const getProductBySlug = `-- name: GetProductBySlug :one
+SELECT id,slug,title,description,price_cents,created_at,deleted_at,currency,version
+from products where slug = $1 AND deleted_at IS NULL
 `
 
-type ListProductsParams struct {
-	Limit  int32
-	Offset int32
-}
-
-func (q *Queries) ListProducts(ctx context.Context, arg ListProductsParams) ([]Product, error) {
-	rows, err := q.db.QueryContext(ctx, listProducts, arg.Limit, arg.Offset)
-	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
-	var items []Product
-	for rows.Next() {
-		var i Product
-		if err := rows.Scan(
-			&i.ID,
-			&i.Slug,
-			&i.Title,
-			&i.Description,
-			&i.PriceCents,
-			&i.CreatedAt,
-		); err != nil {
-			return nil, err
-		}
-		items = append(items, i)
-	}
-	if err := rows.Close(); err != nil {
-		return nil, err
-	}
-	if err := rows.Err(); err != nil {
-		return nil, err
-	}
-	return items, nil
+func (q *Queries) GetProductBySlug(ctx context.Context, slug string) (Product, error) {
+	row := q.db.QueryRowContext(ctx, getProductBySlug, slug)
+	var i Product
+	err := row.Scan(
+		&i.ID,
+		&i.Slug,
+		&i.Title,
+		&i.Description,
+		&i.PriceCents,
+		&i.CreatedAt,
+		&i.DeletedAt,
+		&i.Currency,
+		&i.Version,
+	)
+	return i, err
 }
@@ -11,31 +11,29 @@ import (
 )
 
 const createUser = `-- name: CreateUser :one
-INSERT INTO users (email,name)
-VALUES ($1, $2)
-RETURNING id,name,email,created_at
+INSERT INTO users (email,name,password_hash,password_changed_at)
+VALUES ($1, $2, $3, CURRENT_TIMESTAMP)
+RETURNING id,name,email,created_at,updated_at,password_hash,password_changed_at,email_verified_at
 `
 
 type CreateUserParams struct {
-	Email string
-	Name  string
+	Email        string
+	Name         string
+	PasswordHash string
 }
 
-type CreateUserRow struct {
-	ID        int32
-	Name      string
-	Email     string
-	CreatedAt sql.NullTime
-}
-
-func (q *Queries) CreateUser(ctx context.Context, arg CreateUserParams) (CreateUserRow, error) {
-	row := q.db.QueryRowContext(ctx, createUser, arg.Email, arg.Name)
-	var i CreateUserRow
+func (q *Queries) CreateUser(ctx context.Context, arg CreateUserParams) (User, error) {
+	row := q.db.QueryRowContext(ctx, createUser, arg.Email, arg.Name, arg.PasswordHash)
+	var i User
 	err := row.Scan(
 		&i.ID,
 		&i.Name,
 		&i.Email,
 		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.PasswordHash,
+		&i.PasswordChangedAt,
+		&i.EmailVerifiedAt,
 	)
 	return i, err
 }
@@ -6,12 +6,83 @@ package usersdb
 
 import (
 	"database/sql"
+	"time"
 )
 
+type LoginThrottle struct {
+	Scope         string
+	Key           string
+	Failures      int32
+	LastFailureAt time.Time
+	BlockedUntil  sql.NullTime
+	Locked        bool
+}
+
+type Permission struct {
+	ID          int32
+	Name        string
+	Description string
+}
+
+type RefreshToken struct {
+	ID            int64
+	UserID        int32
+	FamilyID      string
+	TokenHash     []byte
+	CreatedAt     time.Time
+	ExpiresAt     time.Time
+	UsedAt        sql.NullTime
+	RevokedAt     sql.NullTime
+	RevokedReason string
+}
+
+type Role struct {
+	ID          int32
+	Name        string
+	Description string
+	CreatedAt   time.Time
+}
+
+type RolePermission struct {
+	RoleID       int32
+	PermissionID int32
+}
+
+type SecurityEvent struct {
+	ID        int64
+	Kind      string
+	Scope     string
+	Subject   string
+	IP        string
+	ActorID   sql.NullInt32
+	CreatedAt time.Time
+}
+
 type User struct {
-	ID        int32
-	Name      string
+	ID                int32
+	Name              string
+	Email             string
+	CreatedAt         sql.NullTime
+	UpdatedAt         sql.NullTime
+	PasswordHash      string
+	PasswordChangedAt sql.NullTime
+	EmailVerifiedAt   sql.NullTime
+}
+
+type UserRole struct {
+	UserID    int32
+	RoleID    int32
+	GrantedAt time.Time
+	GrantedBy sql.NullInt32
+}
+
+type UserToken struct {
+	ID        int64
+	UserID    int32
+	Purpose   string
+	TokenHash []byte
 	Email     string
-	CreatedAt sql.NullTime
-	UpdatedAt sql.NullTime
+	CreatedAt time.Time
+	ExpiresAt time.Time
+	UsedAt    sql.NullTime
 }
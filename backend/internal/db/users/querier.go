@@ -6,12 +6,46 @@ package usersdb
 
 import (
 	"context"
+	"time"
 )
 
 type Querier interface {
-	CreateUser(ctx context.Context, arg CreateUserParams) (CreateUserRow, error)
+	AssignUserRole(ctx context.Context, arg AssignUserRoleParams) (int64, error)
+	CountUserTokensSince(ctx context.Context, arg CountUserTokensSinceParams) (int64, error)
+	CreateUser(ctx context.Context, arg CreateUserParams) (User, error)
+	DecrementLoginThrottle(ctx context.Context, arg DecrementLoginThrottleParams) error
+	DeleteExpiredRefreshTokens(ctx context.Context, expiresAt time.Time) (int64, error)
+	DeleteExpiredUserTokens(ctx context.Context, expiresAt time.Time) (int64, error)
+	DeleteLoginThrottle(ctx context.Context, arg DeleteLoginThrottleParams) (int64, error)
+	DeleteStaleLoginThrottles(ctx context.Context, arg DeleteStaleLoginThrottlesParams) (int64, error)
 	DeleteUser(ctx context.Context, id int32) (DeleteUserRow, error)
+	GetLoginThrottleForUpdate(ctx context.Context, arg GetLoginThrottleForUpdateParams) (LoginThrottle, error)
+	GetRefreshTokenForUpdate(ctx context.Context, tokenHash []byte) (RefreshToken, error)
+	GetRoleByName(ctx context.Context, name string) (Role, error)
+	GetUser(ctx context.Context, id int32) (User, error)
+	GetUserByEmail(ctx context.Context, email string) (User, error)
+	GetUserToken(ctx context.Context, arg GetUserTokenParams) (UserToken, error)
+	GetUserTokenForUpdate(ctx context.Context, arg GetUserTokenForUpdateParams) (UserToken, error)
+	InsertLoginThrottle(ctx context.Context, arg InsertLoginThrottleParams) error
+	InsertRefreshToken(ctx context.Context, arg InsertRefreshTokenParams) (RefreshToken, error)
+	InsertSecurityEvent(ctx context.Context, arg InsertSecurityEventParams) (SecurityEvent, error)
+	InsertUserToken(ctx context.Context, arg InsertUserTokenParams) (UserToken, error)
+	InvalidateUserTokens(ctx context.Context, arg InvalidateUserTokensParams) (int64, error)
+	ListRolePermissions(ctx context.Context) ([]ListRolePermissionsRow, error)
+	ListRoles(ctx context.Context) ([]Role, error)
+	ListSecurityEvents(ctx context.Context, limit int32) ([]SecurityEvent, error)
+	ListUserRoleNames(ctx context.Context, userID int32) ([]string, error)
+	MarkRefreshTokenUsed(ctx context.Context, id int64) error
+	MarkUserEmailVerified(ctx context.Context, arg MarkUserEmailVerifiedParams) (int64, error)
+	RehashUserPassword(ctx context.Context, arg RehashUserPasswordParams) (int64, error)
+	RestoreLoginThrottle(ctx context.Context, arg RestoreLoginThrottleParams) (int64, error)
+	RevokeRefreshTokenFamily(ctx context.Context, arg RevokeRefreshTokenFamilyParams) (int64, error)
+	RevokeUserRefreshTokens(ctx context.Context, arg RevokeUserRefreshTokensParams) (int64, error)
+	RevokeUserRole(ctx context.Context, arg RevokeUserRoleParams) (int64, error)
+	SetUserPassword(ctx context.Context, arg SetUserPasswordParams) error
+	UpdateLoginThrottle(ctx context.Context, arg UpdateLoginThrottleParams) (LoginThrottle, error)
 	UpdateUserName(ctx context.Context, arg UpdateUserNameParams) error
+	UseUserToken(ctx context.Context, arg UseUserTokenParams) error
 }
 
 var _ Querier = (*Queries)(nil)
@@ -0,0 +1,64 @@
+package mail
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+// FileMailer ничего не отправляет, а дописывает письма в файл
+// (или в stdout при пути "-") - для разработки и тестов. Тело пишется
+// как есть, без кодирования, чтобы ссылки из письма можно было скопировать.
+type FileMailer struct {
+	path string
+	from string
+
+	mu sync.Mutex
+}
+
+func NewFileMailer(path, from string) *FileMailer {
+	return &FileMailer{
+		path: path,
+		from: from,
+	}
+}
+
+func (m *FileMailer) Send(ctx context.Context, msg Message) error {
+	var b bytes.Buffer
+	if err := writeHeader(&b, m.from, msg, time.Now()); err != nil {
+		return fmt.Errorf("mail: send: %w", err)
+	}
+	fmt.Fprintf(&b, "\r\n%s\n----\n", msg.Body)
+	data := b.Bytes()
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.path == "-" {
+		return write(os.Stdout, data)
+	}
+
+	f, err := os.OpenFile(m.path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o600)
+	if err != nil {
+		return fmt.Errorf("mail: send: %w", err)
+	}
+	if err := write(f, data); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("mail: send: %w", err)
+	}
+	return nil
+}
+
+func write(w io.Writer, data []byte) error {
+	if _, err := w.Write(data); err != nil {
+		return fmt.Errorf("mail: send: %w", err)
+	}
+	return nil
+}
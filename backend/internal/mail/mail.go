@@ -0,0 +1,72 @@
+// Package mail отправляет служебные письма: подтверждение email, сброс пароля.
+//
+// Mailer - единственная точка отправки. SMTPMailer отправляет по-настоящему,
+// FileMailer пишет письма в файл или stdout для разработки и тестов.
+package mail
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"mime"
+	"mime/quotedprintable"
+	netmail "net/mail"
+	"strings"
+	"time"
+)
+
+var ErrInvalidMessage = errors.New("invalid mail message")
+
+// Message - текстовое письмо одному получателю
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+type Mailer interface {
+	Send(ctx context.Context, msg Message) error
+}
+
+// encode собирает письмо в формате RFC 5322: заголовки и тело в quoted-printable
+func encode(from string, msg Message, now time.Time) ([]byte, error) {
+	var b bytes.Buffer
+	if err := writeHeader(&b, from, msg, now); err != nil {
+		return nil, err
+	}
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=UTF-8\r\n")
+	b.WriteString("Content-Transfer-Encoding: quoted-printable\r\n\r\n")
+
+	w := quotedprintable.NewWriter(&b)
+	body := strings.ReplaceAll(strings.ReplaceAll(msg.Body, "\r\n", "\n"), "\n", "\r\n")
+	if _, err := w.Write([]byte(body)); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	b.WriteString("\r\n")
+	return b.Bytes(), nil
+}
+
+// writeHeader проверяет адреса и пишет From, To, Subject и Date
+func writeHeader(b *bytes.Buffer, from string, msg Message, now time.Time) error {
+	if _, err := netmail.ParseAddress(from); err != nil {
+		return fmt.Errorf("%w: sender %q", ErrInvalidMessage, from)
+	}
+	if _, err := netmail.ParseAddress(msg.To); err != nil {
+		return fmt.Errorf("%w: recipient %q", ErrInvalidMessage, msg.To)
+	}
+	// Перевод строки в заголовке позволил бы дописать свои заголовки
+	if strings.ContainsAny(from+msg.To+msg.Subject, "\r\n") {
+		return fmt.Errorf("%w: line break in header", ErrInvalidMessage)
+	}
+
+	fmt.Fprintf(b, "From: %s\r\n", from)
+	fmt.Fprintf(b, "To: %s\r\n", msg.To)
+	fmt.Fprintf(b, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", msg.Subject))
+	fmt.Fprintf(b, "Date: %s\r\n", now.Format(time.RFC1123Z))
+	return nil
+}
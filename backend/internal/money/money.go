@@ -0,0 +1,216 @@
+// Package money - денежные суммы в минорных единицах (копейки, центы)
+// с проверкой переполнения и округлением при конвертации.
+package money
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"regexp"
+	"strings"
+)
+
+type Currency string
+
+const (
+	RUB Currency = "RUB"
+	EUR Currency = "EUR"
+	USD Currency = "USD"
+)
+
+// DefaultCurrency - валюта каталога по умолчанию
+const DefaultCurrency = RUB
+
+// decimalPattern - допустимая запись суммы и курса. Проверяется до big.Rat.SetString,
+// который понимает ещё дроби, экспоненту и шестнадцатеричную запись - "1e1000000"
+// заставил бы его считать огромное число.
+var decimalPattern = regexp.MustCompile(`^-?\d+(\.\d+)?$`)
+
+// minorUnits - количество знаков после запятой для валюты (ISO 4217)
+var minorUnits = map[Currency]int{
+	RUB: 2,
+	EUR: 2,
+	USD: 2,
+}
+
+var (
+	ErrUnknownCurrency  = errors.New("unknown currency")
+	ErrCurrencyMismatch = errors.New("currency mismatch")
+	ErrOverflow         = errors.New("amount overflow")
+	ErrInvalidRate      = errors.New("invalid exchange rate")
+	ErrInvalidAmount    = errors.New("invalid amount")
+)
+
+// ParseCurrency принимает код в любом регистре ("rub", "EUR")
+func ParseCurrency(s string) (Currency, error) {
+	c := Currency(strings.ToUpper(strings.TrimSpace(s)))
+	if _, ok := minorUnits[c]; !ok {
+		return "", fmt.Errorf("%w: %q", ErrUnknownCurrency, s)
+	}
+	return c, nil
+}
+
+// Valid - поддерживается ли валюта
+func (c Currency) Valid() bool {
+	_, ok := minorUnits[c]
+	return ok
+}
+
+// Money - сумма в минорных единицах валюты
+type Money struct {
+	Amount   int64
+	Currency Currency
+}
+
+func New(amount int64, currency Currency) Money {
+	return Money{
+		Amount:   amount,
+		Currency: currency,
+	}
+}
+
+func (m Money) Add(other Money) (Money, error) {
+	if m.Currency != other.Currency {
+		return Money{}, fmt.Errorf("%w: %s + %s", ErrCurrencyMismatch, m.Currency, other.Currency)
+	}
+	sum := m.Amount + other.Amount
+	// Переполнение: слагаемые одного знака, а сумма - другого
+	if (m.Amount > 0 && other.Amount > 0 && sum < 0) || (m.Amount < 0 && other.Amount < 0 && sum >= 0) {
+		return Money{}, ErrOverflow
+	}
+	return New(sum, m.Currency), nil
+}
+
+func (m Money) Sub(other Money) (Money, error) {
+	if other.Amount == math.MinInt64 {
+		return Money{}, ErrOverflow
+	}
+	return m.Add(New(-other.Amount, other.Currency))
+}
+
+func (m Money) Mul(n int64) (Money, error) {
+	if m.Amount == 0 || n == 0 {
+		return New(0, m.Currency), nil
+	}
+	product := m.Amount * n
+	if product/n != m.Amount || (m.Amount == -1 && n == math.MinInt64) || (n == -1 && m.Amount == math.MinInt64) {
+		return Money{}, ErrOverflow
+	}
+	return New(product, m.Currency), nil
+}
+
+// Convert переводит сумму в валюту to по курсу rate (сколько единиц to за одну единицу m.Currency).
+// Результат округляется до минорной единицы, половина - от нуля.
+func (m Money) Convert(to Currency, rate *big.Rat) (Money, error) {
+	if !to.Valid() {
+		return Money{}, fmt.Errorf("%w: %q", ErrUnknownCurrency, to)
+	}
+	if m.Currency == to {
+		return m, nil
+	}
+	if rate == nil || rate.Sign() <= 0 {
+		return Money{}, ErrInvalidRate
+	}
+
+	// Курс задан для основных единиц, поэтому учитываем разницу в знаках после запятой
+	value := new(big.Rat).Mul(new(big.Rat).SetInt64(m.Amount), rate)
+	if diff := minorUnits[to] - minorUnits[m.Currency]; diff != 0 {
+		scale := new(big.Rat).SetInt(new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(abs(diff))), nil))
+		if diff > 0 {
+			value.Mul(value, scale)
+		} else {
+			value.Quo(value, scale)
+		}
+	}
+
+	amount, err := Round(value)
+	if err != nil {
+		return Money{}, err
+	}
+	return New(amount, to), nil
+}
+
+// Round округляет до целого, половина - от нуля (2.5 -> 3, -2.5 -> -3)
+func Round(r *big.Rat) (int64, error) {
+	num := new(big.Int).Abs(r.Num())
+	den := r.Denom()
+
+	quo, rem := new(big.Int).QuoRem(num, den, new(big.Int))
+	if rem.Lsh(rem, 1).Cmp(den) >= 0 {
+		quo.Add(quo, big.NewInt(1))
+	}
+	if r.Sign() < 0 {
+		quo.Neg(quo)
+	}
+
+	if !quo.IsInt64() {
+		return 0, ErrOverflow
+	}
+	return quo.Int64(), nil
+}
+
+// Parse разбирает сумму в основных единицах ("1234.5", "-10.05") в минорные.
+// Знаков после запятой не больше, чем у валюты: "1.005 RUB" - ошибка, а не округление.
+func Parse(s string, currency Currency) (Money, error) {
+	if !currency.Valid() {
+		return Money{}, fmt.Errorf("%w: %q", ErrUnknownCurrency, currency)
+	}
+	s = strings.TrimSpace(s)
+	if !decimalPattern.MatchString(s) {
+		return Money{}, fmt.Errorf("%w: %q", ErrInvalidAmount, s)
+	}
+	value, ok := new(big.Rat).SetString(s)
+	if !ok {
+		return Money{}, fmt.Errorf("%w: %q", ErrInvalidAmount, s)
+	}
+
+	scale := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(minorUnits[currency])), nil)
+	value.Mul(value, new(big.Rat).SetInt(scale))
+	if !value.IsInt() {
+		return Money{}, fmt.Errorf("%w: %q has more than %d decimal places", ErrInvalidAmount, s, minorUnits[currency])
+	}
+	if !value.Num().IsInt64() {
+		return Money{}, ErrOverflow
+	}
+	return New(value.Num().Int64(), currency), nil
+}
+
+// ParseRate разбирает курс из десятичной строки ("97.4512")
+func ParseRate(s string) (*big.Rat, error) {
+	trimmed := strings.TrimSpace(s)
+	if !decimalPattern.MatchString(trimmed) {
+		return nil, fmt.Errorf("%w: %q", ErrInvalidRate, s)
+	}
+	rate, ok := new(big.Rat).SetString(trimmed)
+	if !ok || rate.Sign() <= 0 {
+		return nil, fmt.Errorf("%w: %q", ErrInvalidRate, s)
+	}
+	return rate, nil
+}
+
+// String - "1234.50 RUB"
+func (m Money) String() string {
+	units := minorUnits[m.Currency]
+	if units == 0 {
+		return fmt.Sprintf("%d %s", m.Amount, m.Currency)
+	}
+
+	sign := ""
+	amount := new(big.Int).SetInt64(m.Amount)
+	if amount.Sign() < 0 {
+		sign = "-"
+		amount.Neg(amount)
+	}
+	div := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(units)), nil)
+	major, minor := new(big.Int).QuoRem(amount, div, new(big.Int))
+
+	return fmt.Sprintf("%s%s.%0*s %s", sign, major, units, minor, m.Currency)
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
@@ -0,0 +1,131 @@
+package money
+
+import (
+	"errors"
+	"math"
+	"math/big"
+	"testing"
+)
+
+func rat(t *testing.T, s string) *big.Rat {
+	t.Helper()
+	r, ok := new(big.Rat).SetString(s)
+	if !ok {
+		t.Fatalf("bad rational %q", s)
+	}
+	return r
+}
+
+func TestConvert(t *testing.T) {
+	tests := []struct {
+		amount Money
+		to     Currency
+		rate   string
+		want   int64
+	}{
+		{amount: New(10000, USD), to: RUB, rate: "97.45", want: 974500},
+		{amount: New(1, USD), to: RUB, rate: "0.4", want: 0},
+		{amount: New(1, USD), to: RUB, rate: "0.5", want: 1},
+		{amount: New(-1, USD), to: RUB, rate: "0.5", want: -1},
+		{amount: New(-1, USD), to: RUB, rate: "0.49", want: 0},
+		{amount: New(333, RUB), to: EUR, rate: "0.0105", want: 3},
+		{amount: New(1050, RUB), to: EUR, rate: "0.0105", want: 11},
+		// Та же валюта - курс не нужен
+		{amount: New(123, RUB), to: RUB, rate: "0", want: 123},
+	}
+	for _, tt := range tests {
+		got, err := tt.amount.Convert(tt.to, rat(t, tt.rate))
+		if err != nil {
+			t.Errorf("%v -> %s at %s: %v", tt.amount, tt.to, tt.rate, err)
+			continue
+		}
+		if got != New(tt.want, tt.to) {
+			t.Errorf("%v -> %s at %s = %v, want %d", tt.amount, tt.to, tt.rate, got, tt.want)
+		}
+	}
+}
+
+func TestConvertErrors(t *testing.T) {
+	if _, err := New(math.MaxInt64, USD).Convert(RUB, rat(t, "2")); !errors.Is(err, ErrOverflow) {
+		t.Errorf("overflow: error = %v, want %v", err, ErrOverflow)
+	}
+	if _, err := New(100, USD).Convert(RUB, rat(t, "0")); !errors.Is(err, ErrInvalidRate) {
+		t.Errorf("zero rate: error = %v, want %v", err, ErrInvalidRate)
+	}
+	if _, err := New(100, USD).Convert(RUB, nil); !errors.Is(err, ErrInvalidRate) {
+		t.Errorf("nil rate: error = %v, want %v", err, ErrInvalidRate)
+	}
+	if _, err := New(100, USD).Convert("XXX", rat(t, "1")); !errors.Is(err, ErrUnknownCurrency) {
+		t.Errorf("unknown currency: error = %v, want %v", err, ErrUnknownCurrency)
+	}
+}
+
+// Половина округляется от нуля в обе стороны
+func TestRound(t *testing.T) {
+	for r, want := range map[string]int64{
+		"0": 0, "2.4": 2, "2.5": 3, "-2.4": -2, "-2.5": -3, "1/3": 0, "2/3": 1, "-2/3": -1,
+	} {
+		got, err := Round(rat(t, r))
+		if err != nil || got != want {
+			t.Errorf("Round(%s) = %d, %v; want %d", r, got, err, want)
+		}
+	}
+}
+
+func TestParseRate(t *testing.T) {
+	got, err := ParseRate(" 97.4512 ")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Cmp(rat(t, "97.4512")) != 0 {
+		t.Errorf("ParseRate = %s, want 97.4512", got)
+	}
+
+	for _, s := range []string{"", "0", "-1.5", "abc", "1e2", "1/3", "0x10"} {
+		if _, err := ParseRate(s); !errors.Is(err, ErrInvalidRate) {
+			t.Errorf("ParseRate(%q) error = %v, want %v", s, err, ErrInvalidRate)
+		}
+	}
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		s        string
+		currency Currency
+		want     int64
+		err      error
+	}{
+		{s: "1234", currency: RUB, want: 123400},
+		{s: "1234.5", currency: RUB, want: 123450},
+		{s: "10.05", currency: USD, want: 1005},
+		{s: "-10.05", currency: EUR, want: -1005},
+		{s: " 0.01 ", currency: RUB, want: 1},
+		{s: "92233720368547758.07", currency: RUB, want: math.MaxInt64},
+		// Лишние знаки - ошибка, а не округление
+		{s: "1.005", currency: RUB, err: ErrInvalidAmount},
+		{s: "92233720368547758.08", currency: RUB, err: ErrOverflow},
+		{s: "1e3", currency: RUB, err: ErrInvalidAmount},
+		{s: "1/3", currency: RUB, err: ErrInvalidAmount},
+		{s: "1,5", currency: RUB, err: ErrInvalidAmount},
+		// big.Rat понимает и такие записи, Parse - нет
+		{s: "1e1000000000", currency: RUB, err: ErrInvalidAmount},
+		{s: "0x10", currency: RUB, err: ErrInvalidAmount},
+		{s: "+1", currency: RUB, err: ErrInvalidAmount},
+		{s: ".5", currency: RUB, err: ErrInvalidAmount},
+		{s: "5.", currency: RUB, err: ErrInvalidAmount},
+		{s: "", currency: RUB, err: ErrInvalidAmount},
+		{s: "1", currency: "XXX", err: ErrUnknownCurrency},
+	}
+	for _, tt := range tests {
+		got, err := Parse(tt.s, tt.currency)
+		if tt.err != nil {
+			if !errors.Is(err, tt.err) {
+				t.Errorf("Parse(%q, %s) error = %v, want %v", tt.s, tt.currency, err, tt.err)
+			}
+			continue
+		}
+		if err != nil || got != New(tt.want, tt.currency) {
+			t.Errorf("Parse(%q, %s) = %v, %v; want %d", tt.s, tt.currency, got, err, tt.want)
+		}
+	}
+}
@@ -0,0 +1,152 @@
+// Package password - хэши паролей в формате PHC.
+// Новые хэши - argon2id; алгоритм, параметры и соль хранятся в самой строке,
+// поэтому у каждого пользователя свои параметры, а смена настроек не ломает
+// старые хэши: они проверяются по своим параметрам и пересчитываются при входе.
+// bcrypt-хэши ($2a$, $2b$, $2y$) только проверяются.
+package password
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Params - параметры argon2id
+type Params struct {
+	// Memory - память в KiB
+	Memory      uint32
+	Iterations  uint32
+	Parallelism uint8
+	SaltLength  uint32
+	KeyLength   uint32
+}
+
+// DefaultParams - рекомендация OWASP для argon2id с запасом по памяти
+var DefaultParams = Params{
+	Memory:      64 * 1024,
+	Iterations:  3,
+	Parallelism: 2,
+	SaltLength:  16,
+	KeyLength:   32,
+}
+
+var (
+	ErrInvalidHash     = errors.New("invalid password hash")
+	ErrUnsupportedHash = errors.New("unsupported password hash algorithm")
+	ErrInvalidParams   = errors.New("invalid password hash params")
+)
+
+// Validate отсекает параметры, с которыми хэш либо слабый, либо не посчитается
+func (p Params) Validate() error {
+	switch {
+	case p.Memory < 8*1024:
+		return fmt.Errorf("%w: memory must be at least 8 MiB", ErrInvalidParams)
+	case p.Iterations < 1:
+		return fmt.Errorf("%w: iterations must be positive", ErrInvalidParams)
+	case p.Parallelism < 1:
+		return fmt.Errorf("%w: parallelism must be positive", ErrInvalidParams)
+	case p.SaltLength < 16:
+		return fmt.Errorf("%w: salt must be at least 16 bytes", ErrInvalidParams)
+	case p.KeyLength < 16:
+		return fmt.Errorf("%w: key must be at least 16 bytes", ErrInvalidParams)
+	}
+	return nil
+}
+
+// Hash - argon2id-хэш пароля вида $argon2id$v=19$m=65536,t=3,p=2$<соль>$<ключ>
+func Hash(password string, p Params) (string, error) {
+	if err := p.Validate(); err != nil {
+		return "", err
+	}
+
+	salt := make([]byte, p.SaltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return "", fmt.Errorf("password: generate salt: %w", err)
+	}
+	key := argon2.IDKey([]byte(password), salt, p.Iterations, p.Memory, p.Parallelism, p.KeyLength)
+
+	return fmt.Sprintf("$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s",
+		argon2.Version, p.Memory, p.Iterations, p.Parallelism,
+		base64.RawStdEncoding.EncodeToString(salt),
+		base64.RawStdEncoding.EncodeToString(key),
+	), nil
+}
+
+// Verify сравнивает пароль с хэшем за постоянное время.
+// Неверный пароль - false без ошибки; ошибка - хэш не разобрать.
+func Verify(password, encoded string) (bool, error) {
+	if isBcrypt(encoded) {
+		err := bcrypt.CompareHashAndPassword([]byte(encoded), []byte(password))
+		if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+			return false, nil
+		}
+		if err != nil {
+			return false, fmt.Errorf("%w: %v", ErrInvalidHash, err)
+		}
+		return true, nil
+	}
+
+	p, salt, key, err := decodeArgon2id(encoded)
+	if err != nil {
+		return false, err
+	}
+	candidate := argon2.IDKey([]byte(password), salt, p.Iterations, p.Memory, p.Parallelism, p.KeyLength)
+	return subtle.ConstantTimeCompare(key, candidate) == 1, nil
+}
+
+// NeedsRehash - хэш посчитан не argon2id или не с параметрами p.
+// Вызывается после успешного Verify, когда пароль есть в открытом виде.
+func NeedsRehash(encoded string, p Params) bool {
+	current, salt, _, err := decodeArgon2id(encoded)
+	if err != nil {
+		return true
+	}
+	current.SaltLength = uint32(len(salt))
+	return current != p
+}
+
+func isBcrypt(encoded string) bool {
+	return strings.HasPrefix(encoded, "$2a$") || strings.HasPrefix(encoded, "$2b$") || strings.HasPrefix(encoded, "$2y$")
+}
+
+// decodeArgon2id разбирает PHC-строку; KeyLength - длина ключа в строке
+func decodeArgon2id(encoded string) (Params, []byte, []byte, error) {
+	parts := strings.Split(encoded, "$")
+	if len(parts) != 6 || parts[0] != "" {
+		return Params{}, nil, nil, ErrInvalidHash
+	}
+	if parts[1] != "argon2id" {
+		return Params{}, nil, nil, fmt.Errorf("%w: %q", ErrUnsupportedHash, parts[1])
+	}
+
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil || version != argon2.Version {
+		return Params{}, nil, nil, fmt.Errorf("%w: version %q", ErrUnsupportedHash, parts[2])
+	}
+
+	var p Params
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &p.Memory, &p.Iterations, &p.Parallelism); err != nil {
+		return Params{}, nil, nil, fmt.Errorf("%w: params %q", ErrInvalidHash, parts[3])
+	}
+	if p.Iterations < 1 || p.Parallelism < 1 || p.Memory < 8*uint32(p.Parallelism) {
+		return Params{}, nil, nil, fmt.Errorf("%w: params %q", ErrInvalidHash, parts[3])
+	}
+
+	salt, err := base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil || len(salt) == 0 {
+		return Params{}, nil, nil, fmt.Errorf("%w: salt", ErrInvalidHash)
+	}
+	key, err := base64.RawStdEncoding.DecodeString(parts[5])
+	if err != nil || len(key) == 0 {
+		return Params{}, nil, nil, fmt.Errorf("%w: key", ErrInvalidHash)
+	}
+	p.KeyLength = uint32(len(key))
+
+	return p, salt, key, nil
+}
@@ -0,0 +1,106 @@
+package password
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// testParams - минимальные допустимые параметры, чтобы тесты шли быстро
+var testParams = Params{
+	Memory:      8 * 1024,
+	Iterations:  1,
+	Parallelism: 1,
+	SaltLength:  16,
+	KeyLength:   16,
+}
+
+func TestHashAndVerify(t *testing.T) {
+	hash, err := Hash("correct horse", testParams)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(hash, "$argon2id$v=19$m=8192,t=1,p=1$") {
+		t.Errorf("Hash = %q, want argon2id with params in PHC format", hash)
+	}
+	if again, _ := Hash("correct horse", testParams); again == hash {
+		t.Error("two hashes of the same password are equal, salt is not random")
+	}
+
+	if ok, err := Verify("correct horse", hash); !ok || err != nil {
+		t.Errorf("Verify(correct password) = %v, %v", ok, err)
+	}
+	for _, wrong := range []string{"correct horse!", "Correct horse", ""} {
+		if ok, err := Verify(wrong, hash); ok || err != nil {
+			t.Errorf("Verify(%q) = %v, %v; want false, nil", wrong, ok, err)
+		}
+	}
+
+	weak := testParams
+	weak.Memory--
+	if _, err := Hash("correct horse", weak); !errors.Is(err, ErrInvalidParams) {
+		t.Errorf("Hash with too little memory: error = %v, want %v", err, ErrInvalidParams)
+	}
+}
+
+// Пароли, перенесённые из старой системы, хранятся в bcrypt
+func TestVerifyBcrypt(t *testing.T) {
+	hash, err := bcrypt.GenerateFromPassword([]byte("correct horse"), bcrypt.MinCost)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, h := range []string{string(hash), "$2y$" + string(hash[4:])} {
+		if ok, err := Verify("correct horse", h); !ok || err != nil {
+			t.Errorf("Verify(%q) = %v, %v; want true", h[:4], ok, err)
+		}
+	}
+	if ok, _ := Verify("battery staple", string(hash)); ok {
+		t.Error("bcrypt hash accepted a wrong password")
+	}
+	if !NeedsRehash(string(hash), testParams) {
+		t.Error("bcrypt hash does not need a rehash")
+	}
+}
+
+func TestVerifyMalformed(t *testing.T) {
+	argon, err := Hash("correct horse", testParams)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := map[string]error{
+		"":             ErrInvalidHash,
+		"$2b$04$short": ErrInvalidHash,
+		"$argon2id$v=19$m=8192,t=1,p=1$c2FsdHNhbHRzYWx0c2FsdA": ErrInvalidHash,
+		strings.Replace(argon, "t=1", "t=0", 1):                ErrInvalidHash,
+		strings.Replace(argon, "argon2id", "argon2i", 1):       ErrUnsupportedHash,
+		strings.Replace(argon, "v=19", "v=16", 1):              ErrUnsupportedHash,
+	}
+	for hash, want := range tests {
+		if _, err := Verify("correct horse", hash); !errors.Is(err, want) {
+			t.Errorf("Verify(%q) error = %v, want %v", hash, err, want)
+		}
+	}
+}
+
+func TestNeedsRehash(t *testing.T) {
+	hash, err := Hash("correct horse", testParams)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if NeedsRehash(hash, testParams) {
+		t.Error("hash with current params needs a rehash")
+	}
+
+	stronger := testParams
+	stronger.Iterations++
+	if !NeedsRehash(hash, stronger) {
+		t.Error("hash with fewer iterations than current params does not need a rehash")
+	}
+	longerKey := testParams
+	longerKey.KeyLength = 32
+	if !NeedsRehash(hash, longerKey) {
+		t.Error("hash with a shorter key than current params does not need a rehash")
+	}
+}
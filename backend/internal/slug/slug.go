@@ -0,0 +1,95 @@
+// Package slug строит URL-совместимые идентификаторы из названий,
+// в том числе русскоязычных.
+package slug
+
+import (
+	"strconv"
+	"strings"
+)
+
+// MaxLength - максимальная длина slug в байтах
+const MaxLength = 80
+
+// fallback используется, если в названии не нашлось ни одной буквы или цифры
+const fallback = "product"
+
+// Транслитерация кириллицы (упрощённая схема, как в адресах Яндекса)
+var translit = map[rune]string{
+	'а': "a", 'б': "b", 'в': "v", 'г': "g", 'д': "d",
+	'е': "e", 'ё': "yo", 'ж': "zh", 'з': "z", 'и': "i",
+	'й': "y", 'к': "k", 'л': "l", 'м': "m", 'н': "n",
+	'о': "o", 'п': "p", 'р': "r", 'с': "s", 'т': "t",
+	'у': "u", 'ф': "f", 'х': "h", 'ц': "ts", 'ч': "ch",
+	'ш': "sh", 'щ': "sch", 'ъ': "", 'ы': "y", 'ь': "",
+	'э': "e", 'ю': "yu", 'я': "ya",
+	// украинские и белорусские буквы
+	'і': "i", 'ї': "yi", 'є': "ye", 'ґ': "g", 'ў': "u",
+}
+
+// Make строит slug из произвольного названия:
+// кириллица транслитерируется, всё кроме [a-z0-9] заменяется на дефис.
+func Make(title string) string {
+	var b strings.Builder
+	dash := false
+
+	for _, r := range strings.ToLower(title) {
+		var part string
+		switch {
+		case r >= 'a' && r <= 'z', r >= '0' && r <= '9':
+			part = string(r)
+		case translit[r] != "":
+			part = translit[r]
+		case r == 'ъ' || r == 'ь':
+			continue
+		default:
+			// пробелы, пунктуация и прочие символы становятся разделителем
+			dash = b.Len() > 0
+			continue
+		}
+
+		if dash {
+			b.WriteByte('-')
+			dash = false
+		}
+		b.WriteString(part)
+	}
+
+	s := truncate(b.String(), MaxLength)
+	if s == "" {
+		return fallback
+	}
+	return s
+}
+
+// WithSuffix добавляет числовой суффикс (-2, -3, ...), не выходя за MaxLength
+func WithSuffix(base string, n int) string {
+	if n <= 1 {
+		return base
+	}
+	suffix := "-" + strconv.Itoa(n)
+	return truncate(base, MaxLength-len(suffix)) + suffix
+}
+
+// Valid проверяет, что строка уже является корректным slug
+func Valid(s string) bool {
+	if s == "" || len(s) > MaxLength {
+		return false
+	}
+	if s[0] == '-' || s[len(s)-1] == '-' || strings.Contains(s, "--") {
+		return false
+	}
+	for _, r := range s {
+		if !(r >= 'a' && r <= 'z' || r >= '0' && r <= '9' || r == '-') {
+			return false
+		}
+	}
+	return true
+}
+
+// truncate обрезает slug до max байт и убирает висящие дефисы
+func truncate(s string, max int) string {
+	if len(s) > max {
+		s = s[:max]
+	}
+	return strings.Trim(s, "-")
+}
@@ -0,0 +1,68 @@
+package slug
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestMake(t *testing.T) {
+	tests := map[string]string{
+		"Hello World":          "hello-world",
+		"iPhone 15 Pro":        "iphone-15-pro",
+		"  Привет, мир!  ":     "privet-mir",
+		"Щука и Ёж":            "schuka-i-yozh",
+		"Объявление о съёмке":  "obyavlenie-o-syomke",
+		"Їжак":                 "yizhak",
+		"a -- b__c":            "a-b-c",
+		"café crème":           "caf-cr-me",
+		"":                     fallback,
+		"!!! ???":              fallback,
+		"ъь":                   fallback,
+		strings.Repeat("a", 9): "aaaaaaaaa",
+	}
+	for title, want := range tests {
+		if got := Make(title); got != want {
+			t.Errorf("Make(%q) = %q, want %q", title, got, want)
+		}
+	}
+}
+
+// Длинный заголовок обрезается по границе слова и остаётся допустимым slug
+func TestMakeLong(t *testing.T) {
+	got := Make(strings.Repeat("a", MaxLength-1) + " b")
+	if got != strings.Repeat("a", MaxLength-1) || !Valid(got) {
+		t.Errorf("Make = %q, want %d letters a", got, MaxLength-1)
+	}
+	if got := Make(strings.Repeat("слово ", 50)); len(got) > MaxLength || !Valid(got) {
+		t.Errorf("Make = %q (%d bytes), want a valid slug of at most %d bytes", got, len(got), MaxLength)
+	}
+}
+
+func TestWithSuffix(t *testing.T) {
+	if got := WithSuffix("chay", 1); got != "chay" {
+		t.Errorf("WithSuffix(chay, 1) = %q, want chay", got)
+	}
+	if got := WithSuffix("chay", 10); got != "chay-10" {
+		t.Errorf("WithSuffix(chay, 10) = %q, want chay-10", got)
+	}
+
+	// Суффикс не выталкивает slug за MaxLength и не даёт двойного дефиса
+	base := strings.Repeat("a", MaxLength-3) + "-bb"
+	want := strings.Repeat("a", MaxLength-3) + "-2"
+	if got := WithSuffix(base, 2); got != want {
+		t.Errorf("WithSuffix(%q, 2) = %q, want %q", base, got, want)
+	}
+}
+
+func TestValid(t *testing.T) {
+	for _, s := range []string{"chay", "chay-2", "a1-b2-c3"} {
+		if !Valid(s) {
+			t.Errorf("Valid(%q) = false, want true", s)
+		}
+	}
+	for _, s := range []string{"", "-chay", "chay-", "ch--ay", "Chay", "chay_2", "чай", strings.Repeat("a", MaxLength+1)} {
+		if Valid(s) {
+			t.Errorf("Valid(%q) = true, want false", s)
+		}
+	}
+}
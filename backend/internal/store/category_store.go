@@ -0,0 +1,232 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"slices"
+
+	productsdb "db200/internal/db/products"
+)
+
+var (
+	ErrCategoryCycle       = errors.New("category cannot be moved under itself")
+	ErrCategoryHasChildren = errors.New("category has subcategories")
+	ErrNameTaken           = errors.New("name already taken")
+	// ErrReferenceNotFound - ссылка на несуществующую строку (нарушение внешнего ключа)
+	ErrReferenceNotFound = errors.New("referenced row not found")
+)
+
+// CategoryStore - категории, теги и их привязка к продуктам
+type CategoryStore struct {
+	db      *sql.DB
+	queries *productsdb.Queries
+}
+
+func NewCategoryStore(db *sql.DB) *CategoryStore {
+	return &CategoryStore{
+		db:      db,
+		queries: productsdb.New(db),
+	}
+}
+
+func (s *CategoryStore) withTx(ctx context.Context, fn func(*productsdb.Queries) error) error {
+	return withProductsTx(ctx, s.db, s.queries, fn)
+}
+
+// CreateCategory создаёт категорию, ParentID.Valid == false - корневая категория
+func (s *CategoryStore) CreateCategory(ctx context.Context, params productsdb.CreateCategoryParams) (productsdb.Category, error) {
+	category, err := s.queries.CreateCategory(ctx, params)
+	if err != nil {
+		switch {
+		case isUniqueViolation(err):
+			return category, fmt.Errorf("store: create category: %w: %s", ErrSlugTaken, params.Slug)
+		case isForeignKeyViolation(err):
+			return category, fmt.Errorf("store: create category: %w: parent %d",
+				ErrReferenceNotFound, params.ParentID.Int32)
+		}
+		return category, fmt.Errorf("store: create category: %w", err)
+	}
+	return category, nil
+}
+
+func (s *CategoryStore) GetCategory(ctx context.Context, id int32) (productsdb.Category, error) {
+	return s.queries.GetCategory(ctx, id)
+}
+
+// ListCategories - все категории плоским списком, корневые первыми
+func (s *CategoryStore) ListCategories(ctx context.Context) ([]productsdb.Category, error) {
+	categories, err := s.queries.ListCategories(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("store: list categories: %w", err)
+	}
+	return categories, nil
+}
+
+// UpdateCategory меняет категорию целиком, включая родителя.
+// Перенос под собственного потомка запрещён; переносы сериализуются
+// advisory-блокировкой, чтобы два встречных переноса не создали цикл.
+func (s *CategoryStore) UpdateCategory(ctx context.Context, params productsdb.UpdateCategoryParams) (int64, error) {
+	var rows int64
+	err := s.withTx(ctx, func(q *productsdb.Queries) error {
+		if params.ParentID.Valid {
+			if err := q.LockCategoryTree(ctx); err != nil {
+				return err
+			}
+			subtree, err := q.CategorySubtreeIDs(ctx, params.ID)
+			if err != nil {
+				return err
+			}
+			if slices.Contains(subtree, params.ParentID.Int32) {
+				return fmt.Errorf("%w: %d under %d", ErrCategoryCycle, params.ID, params.ParentID.Int32)
+			}
+		}
+
+		var err error
+		rows, err = q.UpdateCategory(ctx, params)
+		return err
+	})
+	if err != nil {
+		switch {
+		case isUniqueViolation(err):
+			return 0, fmt.Errorf("store: update category %d: %w: %s", params.ID, ErrSlugTaken, params.Slug)
+		case isForeignKeyViolation(err):
+			return 0, fmt.Errorf("store: update category %d: %w: parent %d",
+				params.ID, ErrReferenceNotFound, params.ParentID.Int32)
+		}
+		return 0, fmt.Errorf("store: update category %d: %w", params.ID, err)
+	}
+	return rows, nil
+}
+
+// DeleteCategory удаляет категорию без подкатегорий, привязки к продуктам удаляются каскадом
+func (s *CategoryStore) DeleteCategory(ctx context.Context, id int32) (int64, error) {
+	rows, err := s.queries.DeleteCategory(ctx, id)
+	if err != nil {
+		if isForeignKeyViolation(err) {
+			return 0, fmt.Errorf("store: delete category %d: %w", id, ErrCategoryHasChildren)
+		}
+		return 0, fmt.Errorf("store: delete category %d: %w", id, err)
+	}
+	return rows, nil
+}
+
+// SetProductCategories заменяет набор категорий продукта
+func (s *CategoryStore) SetProductCategories(ctx context.Context, productID int32, categoryIDs []int32) error {
+	err := s.withTx(ctx, func(q *productsdb.Queries) error {
+		if err := q.DeleteProductCategories(ctx, productID); err != nil {
+			return err
+		}
+		if len(categoryIDs) == 0 {
+			return nil
+		}
+		return q.AddProductCategories(ctx, productsdb.AddProductCategoriesParams{
+			ProductID:   productID,
+			CategoryIds: categoryIDs,
+		})
+	})
+	if err != nil {
+		if isForeignKeyViolation(err) {
+			return fmt.Errorf("store: set product %d categories: %w", productID, ErrReferenceNotFound)
+		}
+		return fmt.Errorf("store: set product %d categories: %w", productID, err)
+	}
+	return nil
+}
+
+func (s *CategoryStore) ProductCategories(ctx context.Context, productID int32) ([]productsdb.Category, error) {
+	categories, err := s.queries.ListProductCategories(ctx, productID)
+	if err != nil {
+		return nil, fmt.Errorf("store: list product %d categories: %w", productID, err)
+	}
+	return categories, nil
+}
+
+func (s *CategoryStore) CreateTag(ctx context.Context, name string) (productsdb.Tag, error) {
+	tag, err := s.queries.CreateTag(ctx, name)
+	if err != nil {
+		if isUniqueViolation(err) {
+			return tag, fmt.Errorf("store: create tag: %w: %s", ErrNameTaken, name)
+		}
+		return tag, fmt.Errorf("store: create tag: %w", err)
+	}
+	return tag, nil
+}
+
+func (s *CategoryStore) GetTag(ctx context.Context, id int32) (productsdb.Tag, error) {
+	return s.queries.GetTag(ctx, id)
+}
+
+func (s *CategoryStore) ListTags(ctx context.Context) ([]productsdb.Tag, error) {
+	tags, err := s.queries.ListTags(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("store: list tags: %w", err)
+	}
+	return tags, nil
+}
+
+func (s *CategoryStore) RenameTag(ctx context.Context, id int32, name string) (int64, error) {
+	rows, err := s.queries.RenameTag(ctx, productsdb.RenameTagParams{
+		Name: name,
+		ID:   id,
+	})
+	if err != nil {
+		if isUniqueViolation(err) {
+			return 0, fmt.Errorf("store: rename tag %d: %w: %s", id, ErrNameTaken, name)
+		}
+		return 0, fmt.Errorf("store: rename tag %d: %w", id, err)
+	}
+	return rows, nil
+}
+
+func (s *CategoryStore) DeleteTag(ctx context.Context, id int32) (int64, error) {
+	rows, err := s.queries.DeleteTag(ctx, id)
+	if err != nil {
+		return 0, fmt.Errorf("store: delete tag %d: %w", id, err)
+	}
+	return rows, nil
+}
+
+// SetProductTags заменяет теги продукта, недостающие теги создаются
+func (s *CategoryStore) SetProductTags(ctx context.Context, productID int32, names []string) ([]productsdb.Tag, error) {
+	var tags []productsdb.Tag
+	err := s.withTx(ctx, func(q *productsdb.Queries) error {
+		if err := q.DeleteProductTags(ctx, productID); err != nil {
+			return err
+		}
+		if len(names) == 0 {
+			return nil
+		}
+
+		var err error
+		tags, err = q.EnsureTags(ctx, names)
+		if err != nil {
+			return err
+		}
+
+		ids := make([]int32, len(tags))
+		for i, tag := range tags {
+			ids[i] = tag.ID
+		}
+		return q.AddProductTags(ctx, productsdb.AddProductTagsParams{
+			ProductID: productID,
+			TagIds:    ids,
+		})
+	})
+	if err != nil {
+		if isForeignKeyViolation(err) {
+			return nil, fmt.Errorf("store: set product %d tags: %w", productID, ErrReferenceNotFound)
+		}
+		return nil, fmt.Errorf("store: set product %d tags: %w", productID, err)
+	}
+	return tags, nil
+}
+
+func (s *CategoryStore) ProductTags(ctx context.Context, productID int32) ([]productsdb.Tag, error) {
+	tags, err := s.queries.ListProductTags(ctx, productID)
+	if err != nil {
+		return nil, fmt.Errorf("store: list product %d tags: %w", productID, err)
+	}
+	return tags, nil
+}
@@ -0,0 +1,51 @@
+package store
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// ProductCursor - позиция в выдаче, упорядоченной по (created_at, id)
+type ProductCursor struct {
+	CreatedAt time.Time
+	ID        int32
+}
+
+// EncodeCursor упаковывает позицию в непрозрачную для клиента строку
+func EncodeCursor(c ProductCursor) string {
+	raw := fmt.Sprintf("%d:%d", c.CreatedAt.UnixMicro(), c.ID)
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+// DecodeCursor разбирает строку, полученную от EncodeCursor
+func DecodeCursor(s string) (ProductCursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return ProductCursor{}, ErrInvalidCursor
+	}
+
+	micros, id, ok := strings.Cut(string(raw), ":")
+	if !ok {
+		return ProductCursor{}, ErrInvalidCursor
+	}
+
+	createdAt, err := strconv.ParseInt(micros, 10, 64)
+	if err != nil {
+		return ProductCursor{}, ErrInvalidCursor
+	}
+	productID, err := strconv.ParseInt(id, 10, 32)
+	if err != nil || productID <= 0 {
+		return ProductCursor{}, ErrInvalidCursor
+	}
+
+	return ProductCursor{
+		CreatedAt: time.UnixMicro(createdAt).UTC(),
+		ID:        int32(productID),
+	}, nil
+}
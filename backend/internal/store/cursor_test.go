@@ -0,0 +1,60 @@
+package store
+
+import (
+	"encoding/base64"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestCursorRoundTrip(t *testing.T) {
+	cursor := ProductCursor{
+		CreatedAt: time.Date(2026, 1, 12, 9, 30, 15, 123456000, time.UTC),
+		ID:        42,
+	}
+	got, err := DecodeCursor(EncodeCursor(cursor))
+	if err != nil {
+		t.Fatalf("DecodeCursor: %v", err)
+	}
+	if !got.CreatedAt.Equal(cursor.CreatedAt) || got.ID != cursor.ID {
+		t.Errorf("round trip = %+v, want %+v", got, cursor)
+	}
+
+	// Точность курсора - микросекунды, как у TIMESTAMP в Postgres
+	withNanos := ProductCursor{CreatedAt: cursor.CreatedAt.Add(789), ID: 1}
+	got, err = DecodeCursor(EncodeCursor(withNanos))
+	if err != nil {
+		t.Fatalf("DecodeCursor: %v", err)
+	}
+	if !got.CreatedAt.Equal(cursor.CreatedAt) {
+		t.Errorf("CreatedAt = %v, want truncated to %v", got.CreatedAt, cursor.CreatedAt)
+	}
+}
+
+func TestDecodeCursorInvalid(t *testing.T) {
+	encode := func(raw string) string {
+		return base64.RawURLEncoding.EncodeToString([]byte(raw))
+	}
+	tests := []struct {
+		name   string
+		cursor string
+	}{
+		{name: "empty", cursor: ""},
+		{name: "malformed base64", cursor: "!!!"},
+		{name: "padded base64", cursor: base64.URLEncoding.EncodeToString([]byte("10:2"))},
+		{name: "missing colon", cursor: encode("1700000000000000")},
+		{name: "non-numeric time", cursor: encode("yesterday:1")},
+		{name: "non-numeric id", cursor: encode("1700000000000000:abc")},
+		{name: "empty id", cursor: encode("1700000000000000:")},
+		{name: "zero id", cursor: encode("1700000000000000:0")},
+		{name: "negative id", cursor: encode("1700000000000000:-5")},
+		{name: "id overflows int32", cursor: encode("1700000000000000:2147483648")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := DecodeCursor(tt.cursor); !errors.Is(err, ErrInvalidCursor) {
+				t.Errorf("DecodeCursor(%q) error = %v, want %v", tt.cursor, err, ErrInvalidCursor)
+			}
+		})
+	}
+}
@@ -0,0 +1,80 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"math/big"
+
+	productsdb "db200/internal/db/products"
+	"db200/internal/money"
+)
+
+// ErrRateNotFound - курса для пары валют нет ни в прямом, ни в обратном направлении
+var ErrRateNotFound = errors.New("exchange rate not found")
+
+// SetExchangeRate сохраняет курс base -> quote (сколько quote за одну единицу base)
+func (s *ProductStore) SetExchangeRate(ctx context.Context, base, quote money.Currency, rate *big.Rat) (productsdb.ExchangeRate, error) {
+	if rate == nil || rate.Sign() <= 0 {
+		return productsdb.ExchangeRate{}, fmt.Errorf("store: set exchange rate: %w", money.ErrInvalidRate)
+	}
+
+	row, err := s.queries.UpsertExchangeRate(ctx, productsdb.UpsertExchangeRateParams{
+		Base:  string(base),
+		Quote: string(quote),
+		// NUMERIC(20, 10) - больше десяти знаков база всё равно не сохранит
+		Rate: rate.FloatString(10),
+	})
+	if err != nil {
+		return productsdb.ExchangeRate{}, fmt.Errorf("store: set exchange rate %s/%s: %w", base, quote, err)
+	}
+
+	return row, nil
+}
+
+// ExchangeRate возвращает курс from -> to. Если сохранён только обратный курс,
+// используется 1/rate.
+func (s *ProductStore) ExchangeRate(ctx context.Context, from, to money.Currency) (*big.Rat, error) {
+	if from == to {
+		return big.NewRat(1, 1), nil
+	}
+
+	row, err := s.queries.GetExchangeRate(ctx, productsdb.GetExchangeRateParams{
+		Base:  string(from),
+		Quote: string(to),
+	})
+	if err == nil {
+		return money.ParseRate(row.Rate)
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("store: get exchange rate %s/%s: %w", from, to, err)
+	}
+
+	row, err = s.queries.GetExchangeRate(ctx, productsdb.GetExchangeRateParams{
+		Base:  string(to),
+		Quote: string(from),
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("store: %w: %s/%s", ErrRateNotFound, from, to)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("store: get exchange rate %s/%s: %w", to, from, err)
+	}
+
+	rate, err := money.ParseRate(row.Rate)
+	if err != nil {
+		return nil, err
+	}
+	return rate.Inv(rate), nil
+}
+
+// ListExchangeRates возвращает все сохранённые курсы
+func (s *ProductStore) ListExchangeRates(ctx context.Context) ([]productsdb.ExchangeRate, error) {
+	rates, err := s.queries.ListExchangeRates(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("store: list exchange rates: %w", err)
+	}
+
+	return rates, nil
+}
@@ -0,0 +1,146 @@
+package store
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+
+	idempotencydb "db200/internal/db/idempotency"
+)
+
+var (
+	// ErrIdempotencyKeyMismatch - ключ уже использован с другим телом запроса
+	ErrIdempotencyKeyMismatch = errors.New("idempotency key reused with a different request")
+	// ErrIdempotencyKeyInProgress - запрос с этим ключом ещё выполняется
+	ErrIdempotencyKeyInProgress = errors.New("request with this idempotency key is in progress")
+	// ErrIdempotencyLockLost - ключ забрал другой запрос, пока этот выполнялся
+	ErrIdempotencyLockLost = errors.New("idempotency key lock lost")
+)
+
+// IdempotencyStore - ключи идемпотентности и сохранённые ответы
+type IdempotencyStore struct {
+	queries *idempotencydb.Queries
+}
+
+func NewIdempotencyStore(db *sql.DB) *IdempotencyStore {
+	return &IdempotencyStore{
+		queries: idempotencydb.New(db),
+	}
+}
+
+// Begin занимает ключ под запрос с отпечатком fingerprint.
+// started == true - ключ наш, запрос надо выполнить и вызвать Complete или Release
+// с record.LockedBy. started == false - запрос уже выполнен, в записи сохранённый ответ.
+// Ключ, взятый раньше lockTimeout и не завершённый, считается брошенным и забирается.
+func (s *IdempotencyStore) Begin(ctx context.Context, scope, key, fingerprint string, lockTimeout time.Duration) (idempotencydb.IdempotencyKey, bool, error) {
+	lockedBy, err := newLockToken()
+	if err != nil {
+		return idempotencydb.IdempotencyKey{}, false, fmt.Errorf("store: begin idempotency key: %w", err)
+	}
+
+	record, err := s.queries.InsertIdempotencyKey(ctx, idempotencydb.InsertIdempotencyKeyParams{
+		Scope:       scope,
+		Key:         key,
+		Fingerprint: fingerprint,
+		LockedBy:    lockedBy,
+	})
+	if err == nil {
+		return record, true, nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return record, false, fmt.Errorf("store: begin idempotency key: %w", err)
+	}
+
+	record, err = s.queries.GetIdempotencyKey(ctx, idempotencydb.GetIdempotencyKeyParams{
+		Scope: scope,
+		Key:   key,
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		// Ключ только что освободили после ошибки - клиенту стоит повторить
+		return record, false, fmt.Errorf("store: begin idempotency key: %w", ErrIdempotencyKeyInProgress)
+	}
+	if err != nil {
+		return record, false, fmt.Errorf("store: begin idempotency key: %w", err)
+	}
+	if record.Fingerprint != fingerprint {
+		return record, false, fmt.Errorf("store: begin idempotency key: %w", ErrIdempotencyKeyMismatch)
+	}
+	if record.CompletedAt.Valid {
+		return record, false, nil
+	}
+
+	record, err = s.queries.ReclaimIdempotencyKey(ctx, idempotencydb.ReclaimIdempotencyKeyParams{
+		LockedBy:           lockedBy,
+		Scope:              scope,
+		Key:                key,
+		Fingerprint:        fingerprint,
+		LockTimeoutSeconds: int32(lockTimeout.Seconds()),
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		return record, false, fmt.Errorf("store: begin idempotency key: %w", ErrIdempotencyKeyInProgress)
+	}
+	if err != nil {
+		return record, false, fmt.Errorf("store: begin idempotency key: %w", err)
+	}
+	return record, true, nil
+}
+
+// Complete сохраняет ответ на запрос, повторы будут получать его.
+// lockedBy - токен из Begin; если ключ тем временем забрал другой запрос,
+// ответ не сохраняется и возвращается ErrIdempotencyLockLost.
+func (s *IdempotencyStore) Complete(ctx context.Context, scope, key, lockedBy string, statusCode int, contentType string, body []byte) error {
+	count, err := s.queries.CompleteIdempotencyKey(ctx, idempotencydb.CompleteIdempotencyKeyParams{
+		Scope:        scope,
+		Key:          key,
+		StatusCode:   sql.NullInt32{Int32: int32(statusCode), Valid: true},
+		ContentType:  contentType,
+		ResponseBody: body,
+		LockedBy:     lockedBy,
+	})
+	if err != nil {
+		return fmt.Errorf("store: complete idempotency key: %w", err)
+	}
+	if count == 0 {
+		return fmt.Errorf("store: complete idempotency key: %w", ErrIdempotencyLockLost)
+	}
+	return nil
+}
+
+// Release освобождает незавершённый ключ, чтобы повтор выполнился заново.
+// Чужой ключ (забранный другим запросом) не трогается - ErrIdempotencyLockLost.
+func (s *IdempotencyStore) Release(ctx context.Context, scope, key, lockedBy string) error {
+	count, err := s.queries.ReleaseIdempotencyKey(ctx, idempotencydb.ReleaseIdempotencyKeyParams{
+		Scope:    scope,
+		Key:      key,
+		LockedBy: lockedBy,
+	})
+	if err != nil {
+		return fmt.Errorf("store: release idempotency key: %w", err)
+	}
+	if count == 0 {
+		return fmt.Errorf("store: release idempotency key: %w", ErrIdempotencyLockLost)
+	}
+	return nil
+}
+
+// DeleteExpired удаляет ключи старше ttl
+func (s *IdempotencyStore) DeleteExpired(ctx context.Context, ttl time.Duration) (int64, error) {
+	count, err := s.queries.DeleteExpiredIdempotencyKeys(ctx, int32(ttl.Seconds()))
+	if err != nil {
+		return 0, fmt.Errorf("store: delete expired idempotency keys: %w", err)
+	}
+	return count, nil
+}
+
+// newLockToken - случайный токен владельца блокировки ключа
+func newLockToken() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
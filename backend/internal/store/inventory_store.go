@@ -0,0 +1,238 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	productsdb "db200/internal/db/products"
+)
+
+// Виды движений в журнале остатков
+const (
+	MovementReceipt    = "receipt"
+	MovementSale       = "sale"
+	MovementAdjustment = "adjustment"
+)
+
+// Статусы резерва
+const (
+	ReservationActive    = "active"
+	ReservationCommitted = "committed"
+	ReservationReleased  = "released"
+	ReservationExpired   = "expired"
+)
+
+var (
+	ErrInsufficientStock = errors.New("insufficient stock")
+	// ErrReservationNotActive - резерв уже списан, отпущен или истёк
+	ErrReservationNotActive = errors.New("reservation is not active")
+	// ErrReservationExpired - срок резерва вышел, но фоновая задача его ещё не закрыла
+	ErrReservationExpired = errors.New("reservation expired")
+)
+
+// InventoryStore - остатки, резервы и журнал движений.
+// Все изменения остатков - условные UPDATE одной строкой: блокировка строки
+// и повторная проверка WHERE в Postgres не дают уйти в минус при гонках.
+type InventoryStore struct {
+	db      *sql.DB
+	queries *productsdb.Queries
+}
+
+func NewInventoryStore(db *sql.DB) *InventoryStore {
+	return &InventoryStore{
+		db:      db,
+		queries: productsdb.New(db),
+	}
+}
+
+func (s *InventoryStore) withTx(ctx context.Context, fn func(*productsdb.Queries) error) error {
+	return withProductsTx(ctx, s.db, s.queries, fn)
+}
+
+// Get возвращает остаток продукта; если движений не было - нулевой остаток
+func (s *InventoryStore) Get(ctx context.Context, productID int32) (productsdb.Inventory, error) {
+	inventory, err := s.queries.GetInventory(ctx, productID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return productsdb.Inventory{ProductID: productID}, nil
+	}
+	if err != nil {
+		return inventory, fmt.Errorf("store: get inventory %d: %w", productID, err)
+	}
+	return inventory, nil
+}
+
+// ChangeStock меняет on_hand на delta и пишет движение в журнал.
+// Уменьшение возможно только в пределах доступного (on_hand - reserved).
+func (s *InventoryStore) ChangeStock(ctx context.Context, productID int32, delta int64, kind, note string) (productsdb.Inventory, error) {
+	if delta == 0 {
+		return productsdb.Inventory{}, fmt.Errorf("store: change stock %d: zero delta", productID)
+	}
+
+	var inventory productsdb.Inventory
+	err := s.withTx(ctx, func(q *productsdb.Queries) error {
+		var err error
+		if delta > 0 {
+			inventory, err = q.AddStock(ctx, productsdb.AddStockParams{
+				ProductID: productID,
+				Quantity:  delta,
+			})
+		} else {
+			inventory, err = q.RemoveStock(ctx, productsdb.RemoveStockParams{
+				Quantity:  -delta,
+				ProductID: productID,
+			})
+			if errors.Is(err, sql.ErrNoRows) {
+				return ErrInsufficientStock
+			}
+		}
+		if err != nil {
+			return err
+		}
+
+		_, err = q.InsertStockMovement(ctx, productsdb.InsertStockMovementParams{
+			ProductID: productID,
+			Kind:      kind,
+			Quantity:  delta,
+			Note:      note,
+		})
+		return err
+	})
+	if err != nil {
+		if isForeignKeyViolation(err) {
+			return inventory, fmt.Errorf("store: change stock %d: %w", productID, ErrReferenceNotFound)
+		}
+		return inventory, fmt.Errorf("store: change stock %d: %w", productID, err)
+	}
+	return inventory, nil
+}
+
+// Reserve резервирует quantity единиц на ttlSeconds секунд
+func (s *InventoryStore) Reserve(ctx context.Context, productID int32, quantity int64, ttlSeconds int32) (productsdb.StockReservation, error) {
+	var reservation productsdb.StockReservation
+	err := s.withTx(ctx, func(q *productsdb.Queries) error {
+		_, err := q.ReserveStock(ctx, productsdb.ReserveStockParams{
+			Quantity:  quantity,
+			ProductID: productID,
+		})
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrInsufficientStock
+		}
+		if err != nil {
+			return err
+		}
+
+		reservation, err = q.CreateReservation(ctx, productsdb.CreateReservationParams{
+			ProductID:  productID,
+			Quantity:   quantity,
+			TtlSeconds: ttlSeconds,
+		})
+		return err
+	})
+	if err != nil {
+		return reservation, fmt.Errorf("store: reserve stock %d: %w", productID, err)
+	}
+	return reservation, nil
+}
+
+func (s *InventoryStore) GetReservation(ctx context.Context, id int64) (productsdb.StockReservation, error) {
+	return s.queries.GetReservation(ctx, id)
+}
+
+// Commit списывает резерв: on_hand и reserved уменьшаются, в журнал пишется продажа
+func (s *InventoryStore) Commit(ctx context.Context, id int64) (productsdb.StockReservation, error) {
+	var reservation productsdb.StockReservation
+	err := s.withTx(ctx, func(q *productsdb.Queries) error {
+		var err error
+		reservation, err = q.CommitReservation(ctx, id)
+		if errors.Is(err, sql.ErrNoRows) {
+			return reservationError(ctx, q, id)
+		}
+		if err != nil {
+			return err
+		}
+
+		_, err = q.CommitReservedStock(ctx, productsdb.CommitReservedStockParams{
+			Quantity:  reservation.Quantity,
+			ProductID: reservation.ProductID,
+		})
+		if err != nil {
+			return err
+		}
+
+		_, err = q.InsertStockMovement(ctx, productsdb.InsertStockMovementParams{
+			ProductID:     reservation.ProductID,
+			Kind:          MovementSale,
+			Quantity:      -reservation.Quantity,
+			ReservationID: sql.NullInt64{Int64: reservation.ID, Valid: true},
+		})
+		return err
+	})
+	if err != nil {
+		return reservation, fmt.Errorf("store: commit reservation %d: %w", id, err)
+	}
+	return reservation, nil
+}
+
+// Release отпускает резерв, on_hand не меняется
+func (s *InventoryStore) Release(ctx context.Context, id int64) (productsdb.StockReservation, error) {
+	var reservation productsdb.StockReservation
+	err := s.withTx(ctx, func(q *productsdb.Queries) error {
+		var err error
+		reservation, err = q.ReleaseReservation(ctx, id)
+		if errors.Is(err, sql.ErrNoRows) {
+			return reservationError(ctx, q, id)
+		}
+		if err != nil {
+			return err
+		}
+
+		_, err = q.ReleaseReservedStock(ctx, productsdb.ReleaseReservedStockParams{
+			Quantity:  reservation.Quantity,
+			ProductID: reservation.ProductID,
+		})
+		return err
+	})
+	if err != nil {
+		return reservation, fmt.Errorf("store: release reservation %d: %w", id, err)
+	}
+	return reservation, nil
+}
+
+// ExpireReservations закрывает просроченные резервы и возвращает их количество
+func (s *InventoryStore) ExpireReservations(ctx context.Context) (int64, error) {
+	count, err := s.queries.ExpireReservations(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("store: expire reservations: %w", err)
+	}
+	return count, nil
+}
+
+// Movements - журнал движений продукта, новые первыми
+func (s *InventoryStore) Movements(ctx context.Context, productID, limit int32) ([]productsdb.StockMovement, error) {
+	if limit <= 0 || limit > MaxListLimit {
+		return nil, fmt.Errorf("store: invalid movements limit: %d", limit)
+	}
+
+	movements, err := s.queries.ListStockMovements(ctx, productsdb.ListStockMovementsParams{
+		ProductID: productID,
+		Limit:     limit,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("store: list stock movements %d: %w", productID, err)
+	}
+	return movements, nil
+}
+
+// reservationError объясняет, почему условный UPDATE резерва не нашёл строку
+func reservationError(ctx context.Context, q *productsdb.Queries, id int64) error {
+	reservation, err := q.GetReservation(ctx, id)
+	if err != nil {
+		return err
+	}
+	if reservation.Status == ReservationActive {
+		return ErrReservationExpired
+	}
+	return fmt.Errorf("%w: %s", ErrReservationNotActive, reservation.Status)
+}
@@ -0,0 +1,311 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	paymentsdb "db200/internal/db/payments"
+)
+
+var (
+	// ErrInvoiceStatusMismatch - счёт не в том статусе, который нужен операции
+	ErrInvoiceStatusMismatch = errors.New("invoice status mismatch")
+	// ErrInvoiceEmpty - в счёте нет строк
+	ErrInvoiceEmpty = errors.New("invoice has no lines")
+	// ErrInvoiceHasPayments - по счёту есть списанные и не возвращённые деньги
+	ErrInvoiceHasPayments = errors.New("invoice has captured payments")
+	// ErrInvoiceLineNotFound - строки нет в этом счёте
+	ErrInvoiceLineNotFound = errors.New("invoice line not found")
+	// ErrEmailTaken - покупатель с таким email уже есть
+	ErrEmailTaken = errors.New("email already taken")
+)
+
+// Статусы счёта, которые проверяет хранилище; жизненный цикл описан в service.InvoiceStatus
+const (
+	invoiceDraft  = "draft"
+	invoiceIssued = "issued"
+	invoiceVoid   = "void"
+)
+
+// InvoiceTotals - итоги счёта, которые записываются вместе с изменением черновика
+type InvoiceTotals struct {
+	SubtotalCents int64
+	DiscountCents int64
+	TaxRateBp     int32
+	TaxCents      int64
+	TotalCents    int64
+}
+
+// InvoiceTotalsFunc считает итоги по заблокированному черновику и его строкам
+// после изменения; ошибка отменяет изменение
+type InvoiceTotalsFunc func(invoice paymentsdb.Invoice, lines []paymentsdb.InvoiceLine) (InvoiceTotals, error)
+
+// InvoiceStore - покупатели, счета и их строки
+type InvoiceStore struct {
+	db      *sql.DB
+	queries *paymentsdb.Queries
+}
+
+func NewInvoiceStore(db *sql.DB) *InvoiceStore {
+	return &InvoiceStore{
+		db:      db,
+		queries: paymentsdb.New(db),
+	}
+}
+
+func (s *InvoiceStore) withTx(ctx context.Context, fn func(*paymentsdb.Queries) error) error {
+	tx, err := s.db.BeginTx(ctx, &sql.TxOptions{
+		Isolation: sql.LevelReadCommitted,
+	})
+	if err != nil {
+		return err
+	}
+
+	defer tx.Rollback()
+	if err = fn(s.queries.WithTx(tx)); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (s *InvoiceStore) CreateCustomer(ctx context.Context, name, email string) (paymentsdb.Customer, error) {
+	customer, err := s.queries.CreateCustomer(ctx, paymentsdb.CreateCustomerParams{
+		Name:  name,
+		Email: email,
+	})
+	if err != nil {
+		if isUniqueViolation(err) {
+			return customer, fmt.Errorf("store: create customer: %w: %s", ErrEmailTaken, email)
+		}
+		return customer, fmt.Errorf("store: create customer: %w", err)
+	}
+	return customer, nil
+}
+
+func (s *InvoiceStore) GetCustomer(ctx context.Context, id int32) (paymentsdb.Customer, error) {
+	return s.queries.GetCustomer(ctx, id)
+}
+
+// Create создаёт пустой черновик счёта
+func (s *InvoiceStore) Create(ctx context.Context, customerID int32, currency string, taxRateBp int32) (paymentsdb.Invoice, error) {
+	invoice, err := s.queries.CreateInvoice(ctx, paymentsdb.CreateInvoiceParams{
+		CustomerID: customerID,
+		Currency:   currency,
+		TaxRateBp:  taxRateBp,
+	})
+	if err != nil {
+		return invoice, fmt.Errorf("store: create invoice: %w", err)
+	}
+	return invoice, nil
+}
+
+func (s *InvoiceStore) Get(ctx context.Context, id int32) (paymentsdb.Invoice, error) {
+	return s.queries.GetInvoice(ctx, id)
+}
+
+func (s *InvoiceStore) Lines(ctx context.Context, id int32) ([]paymentsdb.InvoiceLine, error) {
+	lines, err := s.queries.ListInvoiceLines(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("store: list invoice %d lines: %w", id, err)
+	}
+	return lines, nil
+}
+
+// ListByCustomer - счета покупателя в порядке создания
+func (s *InvoiceStore) ListByCustomer(ctx context.Context, customerID int32) ([]paymentsdb.Invoice, error) {
+	invoices, err := s.queries.ListInvoicesByCustomer(ctx, customerID)
+	if err != nil {
+		return nil, fmt.Errorf("store: list customer %d invoices: %w", customerID, err)
+	}
+	return invoices, nil
+}
+
+// CapturedCents - списано по всем платежам счёта за вычетом возвратов
+func (s *InvoiceStore) CapturedCents(ctx context.Context, id int32) (int64, error) {
+	captured, err := s.queries.InvoiceCapturedCents(ctx, id)
+	if err != nil {
+		return 0, fmt.Errorf("store: invoice %d captured amount: %w", id, err)
+	}
+	return captured, nil
+}
+
+// AddLine добавляет строку в черновик и пересчитывает итоги
+func (s *InvoiceStore) AddLine(ctx context.Context, line paymentsdb.InsertInvoiceLineParams, totals InvoiceTotalsFunc) (paymentsdb.Invoice, paymentsdb.InvoiceLine, error) {
+	var inserted paymentsdb.InvoiceLine
+	invoice, err := s.editDraft(ctx, line.InvoiceID, func(q *paymentsdb.Queries) error {
+		var err error
+		inserted, err = q.InsertInvoiceLine(ctx, line)
+		return err
+	}, totals)
+	if err != nil {
+		return invoice, inserted, fmt.Errorf("store: add invoice %d line: %w", line.InvoiceID, err)
+	}
+	return invoice, inserted, nil
+}
+
+// RemoveLine удаляет строку из черновика и пересчитывает итоги
+func (s *InvoiceStore) RemoveLine(ctx context.Context, invoiceID int32, lineID int64, totals InvoiceTotalsFunc) (paymentsdb.Invoice, error) {
+	invoice, err := s.editDraft(ctx, invoiceID, func(q *paymentsdb.Queries) error {
+		count, err := q.DeleteInvoiceLine(ctx, paymentsdb.DeleteInvoiceLineParams{
+			ID:        lineID,
+			InvoiceID: invoiceID,
+		})
+		if err != nil {
+			return err
+		}
+		if count == 0 {
+			return ErrInvoiceLineNotFound
+		}
+		return nil
+	}, totals)
+	if err != nil {
+		return invoice, fmt.Errorf("store: remove invoice %d line %d: %w", invoiceID, lineID, err)
+	}
+	return invoice, nil
+}
+
+// Recalculate пересчитывает итоги черновика, например после смены скидки или ставки налога
+func (s *InvoiceStore) Recalculate(ctx context.Context, id int32, totals InvoiceTotalsFunc) (paymentsdb.Invoice, error) {
+	invoice, err := s.editDraft(ctx, id, nil, totals)
+	if err != nil {
+		return invoice, fmt.Errorf("store: recalculate invoice %d: %w", id, err)
+	}
+	return invoice, nil
+}
+
+// Issue выставляет черновик: присваивает очередной номер года year.
+// Номер берётся в той же транзакции, поэтому откат не оставляет дыр в нумерации.
+func (s *InvoiceStore) Issue(ctx context.Context, id int32, year int32) (paymentsdb.Invoice, error) {
+	var invoice paymentsdb.Invoice
+	err := s.withTx(ctx, func(q *paymentsdb.Queries) error {
+		current, err := q.GetInvoiceForUpdate(ctx, id)
+		if err != nil {
+			return err
+		}
+		invoice = current
+		if current.Status != invoiceDraft {
+			return ErrInvoiceStatusMismatch
+		}
+
+		lines, err := q.ListInvoiceLines(ctx, id)
+		if err != nil {
+			return err
+		}
+		if len(lines) == 0 {
+			return ErrInvoiceEmpty
+		}
+
+		seq, err := q.NextInvoiceNumber(ctx, year)
+		if err != nil {
+			return err
+		}
+		invoice, err = q.IssueInvoice(ctx, paymentsdb.IssueInvoiceParams{
+			ID:     id,
+			Number: sql.NullString{String: invoiceNumber(year, seq), Valid: true},
+		})
+		if errors.Is(err, sql.ErrNoRows) {
+			invoice = current
+			return ErrInvoiceStatusMismatch
+		}
+		return err
+	})
+	if err != nil {
+		return invoice, fmt.Errorf("store: issue invoice %d: %w", id, err)
+	}
+	return invoice, nil
+}
+
+// Void аннулирует черновик или выставленный счёт без списанных денег.
+// Платежи по счёту блокируют его строку первой, так что списание не проскочит между проверкой и UPDATE.
+func (s *InvoiceStore) Void(ctx context.Context, id int32) (paymentsdb.Invoice, error) {
+	var invoice paymentsdb.Invoice
+	err := s.withTx(ctx, func(q *paymentsdb.Queries) error {
+		current, err := q.GetInvoiceForUpdate(ctx, id)
+		if err != nil {
+			return err
+		}
+		invoice = current
+
+		captured, err := q.InvoiceCapturedCents(ctx, id)
+		if err != nil {
+			return err
+		}
+		if captured > 0 {
+			return ErrInvoiceHasPayments
+		}
+
+		invoice, err = q.VoidInvoice(ctx, id)
+		if errors.Is(err, sql.ErrNoRows) {
+			invoice = current
+			return ErrInvoiceStatusMismatch
+		}
+		return err
+	})
+	if err != nil {
+		return invoice, fmt.Errorf("store: void invoice %d: %w", id, err)
+	}
+	return invoice, nil
+}
+
+// editDraft блокирует счёт, проверяет, что это черновик, применяет change
+// и записывает итоги, которые вернул totals
+func (s *InvoiceStore) editDraft(ctx context.Context, id int32, change func(*paymentsdb.Queries) error, totals InvoiceTotalsFunc) (paymentsdb.Invoice, error) {
+	var invoice paymentsdb.Invoice
+	err := s.withTx(ctx, func(q *paymentsdb.Queries) error {
+		current, err := q.GetInvoiceForUpdate(ctx, id)
+		if err != nil {
+			return err
+		}
+		invoice = current
+		if current.Status != invoiceDraft {
+			return ErrInvoiceStatusMismatch
+		}
+
+		if change != nil {
+			if err := change(q); err != nil {
+				return err
+			}
+		}
+
+		lines, err := q.ListInvoiceLines(ctx, id)
+		if err != nil {
+			return err
+		}
+		t, err := totals(current, lines)
+		if err != nil {
+			return err
+		}
+
+		invoice, err = q.UpdateInvoiceTotals(ctx, paymentsdb.UpdateInvoiceTotalsParams{
+			SubtotalCents: t.SubtotalCents,
+			DiscountCents: t.DiscountCents,
+			TaxRateBp:     t.TaxRateBp,
+			TaxCents:      t.TaxCents,
+			TotalCents:    t.TotalCents,
+			ID:            id,
+		})
+		return err
+	})
+	return invoice, err
+}
+
+// invoiceNumber - номер вида 2026-000042
+func invoiceNumber(year, seq int32) string {
+	return fmt.Sprintf("%d-%06d", year, seq)
+}
+
+// markInvoicePaid переводит выставленный счёт в paid, если списания по нему
+// покрывают итог. Вызывается в транзакции, где строка счёта уже заблокирована.
+func markInvoicePaid(ctx context.Context, q *paymentsdb.Queries, invoiceID int32) error {
+	captured, err := q.InvoiceCapturedCents(ctx, invoiceID)
+	if err != nil {
+		return err
+	}
+	_, err = q.MarkInvoicePaid(ctx, paymentsdb.MarkInvoicePaidParams{
+		ID:         invoiceID,
+		TotalCents: captured,
+	})
+	return err
+}
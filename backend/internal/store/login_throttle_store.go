@@ -0,0 +1,179 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	usersdb "db200/internal/db/users"
+)
+
+// Счётчики неудачных входов ведутся отдельно по аккаунту и по IP
+const (
+	ThrottleAccount = "account"
+	ThrottleIP      = "ip"
+)
+
+// LoginThrottleStore - счётчики неудачных входов и журнал событий безопасности
+type LoginThrottleStore struct {
+	db      *sql.DB
+	queries *usersdb.Queries
+}
+
+func NewLoginThrottleStore(db *sql.DB) *LoginThrottleStore {
+	return &LoginThrottleStore{
+		db:      db,
+		queries: usersdb.New(db),
+	}
+}
+
+func (s *LoginThrottleStore) withTx(ctx context.Context, fn func(*usersdb.Queries) error) error {
+	tx, err := s.db.BeginTx(ctx, &sql.TxOptions{
+		Isolation: sql.LevelReadCommitted,
+	})
+	if err != nil {
+		return err
+	}
+
+	defer tx.Rollback()
+	if err = fn(s.queries.WithTx(tx)); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// ThrottleClaim - попытка входа, заранее учтённая в счётчике как неудача
+type ThrottleClaim struct {
+	Scope string
+	Key   string
+	// Previous - счётчик до попытки, Current - после неё
+	Previous usersdb.LoginThrottle
+	Current  usersdb.LoginThrottle
+	// Blocked - вход уже закрыт; попытка не учтена, Current == Previous
+	Blocked bool
+}
+
+// Claim учитывает попытку входа до проверки пароля. Строка счётчика
+// блокируется на время решения, поэтому параллельные попытки по одному ключу
+// видят друг друга. Если вход закрыт до момента после now, попытка не учитывается
+// (Blocked). Иначе счётчик увеличивается (после последней неудачи раньше
+// windowStart счёт начинается заново), а block по новому значению решает,
+// до какого момента закрыть вход и блокировка ли это.
+func (s *LoginThrottleStore) Claim(ctx context.Context, scope, key string, now, windowStart time.Time, block func(failures int32) (time.Time, bool)) (ThrottleClaim, error) {
+	claim := ThrottleClaim{Scope: scope, Key: key}
+	err := s.withTx(ctx, func(q *usersdb.Queries) error {
+		err := q.InsertLoginThrottle(ctx, usersdb.InsertLoginThrottleParams{
+			Scope:         scope,
+			Key:           key,
+			LastFailureAt: now,
+		})
+		if err != nil {
+			return err
+		}
+		claim.Previous, err = q.GetLoginThrottleForUpdate(ctx, usersdb.GetLoginThrottleForUpdateParams{
+			Scope: scope,
+			Key:   key,
+		})
+		if err != nil {
+			return err
+		}
+
+		if claim.Previous.BlockedUntil.Valid && now.Before(claim.Previous.BlockedUntil.Time) {
+			claim.Current, claim.Blocked = claim.Previous, true
+			return nil
+		}
+
+		failures := claim.Previous.Failures + 1
+		if claim.Previous.LastFailureAt.Before(windowStart) {
+			failures = 1
+		}
+		until, locked := block(failures)
+		claim.Current, err = q.UpdateLoginThrottle(ctx, usersdb.UpdateLoginThrottleParams{
+			Scope:         scope,
+			Key:           key,
+			Failures:      failures,
+			LastFailureAt: now,
+			BlockedUntil:  sql.NullTime{Time: until, Valid: !until.IsZero()},
+			Locked:        locked,
+		})
+		return err
+	})
+	if err != nil {
+		return claim, fmt.Errorf("store: claim %s login attempt: %w", scope, err)
+	}
+	return claim, nil
+}
+
+// Release отменяет учтённую попытку. Если счётчик с тех пор не менялся,
+// он возвращается к состоянию до попытки вместе с паузой; если менялся
+// (другие попытки), из него только вычитается эта попытка.
+func (s *LoginThrottleStore) Release(ctx context.Context, claim ThrottleClaim) error {
+	if claim.Blocked {
+		return nil
+	}
+	err := s.withTx(ctx, func(q *usersdb.Queries) error {
+		restored, err := q.RestoreLoginThrottle(ctx, usersdb.RestoreLoginThrottleParams{
+			Failures:        claim.Previous.Failures,
+			LastFailureAt:   claim.Previous.LastFailureAt,
+			BlockedUntil:    claim.Previous.BlockedUntil,
+			Locked:          claim.Previous.Locked,
+			Scope:           claim.Scope,
+			Key:             claim.Key,
+			ClaimedFailures: claim.Current.Failures,
+			ClaimedAt:       claim.Current.LastFailureAt,
+		})
+		if err != nil || restored > 0 {
+			return err
+		}
+		return q.DecrementLoginThrottle(ctx, usersdb.DecrementLoginThrottleParams{
+			Scope: claim.Scope,
+			Key:   claim.Key,
+		})
+	})
+	if err != nil {
+		return fmt.Errorf("store: release %s login attempt: %w", claim.Scope, err)
+	}
+	return nil
+}
+
+// Reset удаляет счётчик вместе с блокировкой; false - его не было
+func (s *LoginThrottleStore) Reset(ctx context.Context, scope, key string) (bool, error) {
+	count, err := s.queries.DeleteLoginThrottle(ctx, usersdb.DeleteLoginThrottleParams{
+		Scope: scope,
+		Key:   key,
+	})
+	if err != nil {
+		return false, fmt.Errorf("store: reset %s login throttle: %w", scope, err)
+	}
+	return count > 0, nil
+}
+
+// DeleteStale удаляет счётчики без неудач после windowStart и без действующей блокировки
+func (s *LoginThrottleStore) DeleteStale(ctx context.Context, windowStart, now time.Time) (int64, error) {
+	count, err := s.queries.DeleteStaleLoginThrottles(ctx, usersdb.DeleteStaleLoginThrottlesParams{
+		WindowStart: windowStart,
+		Now:         sql.NullTime{Time: now, Valid: true},
+	})
+	if err != nil {
+		return 0, fmt.Errorf("store: delete stale login throttles: %w", err)
+	}
+	return count, nil
+}
+
+func (s *LoginThrottleStore) AddEvent(ctx context.Context, event usersdb.InsertSecurityEventParams) (usersdb.SecurityEvent, error) {
+	created, err := s.queries.InsertSecurityEvent(ctx, event)
+	if err != nil {
+		return created, fmt.Errorf("store: add security event %s: %w", event.Kind, err)
+	}
+	return created, nil
+}
+
+// Events - последние события, новые первыми
+func (s *LoginThrottleStore) Events(ctx context.Context, limit int32) ([]usersdb.SecurityEvent, error) {
+	events, err := s.queries.ListSecurityEvents(ctx, limit)
+	if err != nil {
+		return nil, fmt.Errorf("store: list security events: %w", err)
+	}
+	return events, nil
+}
@@ -0,0 +1,307 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"slices"
+
+	paymentsdb "db200/internal/db/payments"
+)
+
+// ErrPaymentStatusMismatch - текущий статус платежа не из списка допустимых для перехода
+var ErrPaymentStatusMismatch = errors.New("payment status mismatch")
+
+// PaymentStore - платежи и журнал смены их статусов
+type PaymentStore struct {
+	db      *sql.DB
+	queries *paymentsdb.Queries
+}
+
+func NewPaymentStore(db *sql.DB) *PaymentStore {
+	return &PaymentStore{
+		db:      db,
+		queries: paymentsdb.New(db),
+	}
+}
+
+func (s *PaymentStore) withTx(ctx context.Context, fn func(*paymentsdb.Queries) error) error {
+	tx, err := s.db.BeginTx(ctx, &sql.TxOptions{
+		Isolation: sql.LevelReadCommitted,
+	})
+	if err != nil {
+		return err
+	}
+
+	defer tx.Rollback()
+	if err = fn(s.queries.WithTx(tx)); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// Create создаёт платёж в статусе created по выставленному счёту.
+// Счёт блокируется на чтение, чтобы его не аннулировали до вставки платежа.
+// Если счёта нет - sql.ErrNoRows, если он не выставлен - ErrInvoiceStatusMismatch.
+func (s *PaymentStore) Create(ctx context.Context, invoiceID int32, amountCents int32) (paymentsdb.Payment, error) {
+	var payment paymentsdb.Payment
+	err := s.withTx(ctx, func(q *paymentsdb.Queries) error {
+		invoice, err := q.GetInvoiceForShare(ctx, invoiceID)
+		if err != nil {
+			return err
+		}
+		if invoice.Status != invoiceIssued {
+			return ErrInvoiceStatusMismatch
+		}
+
+		payment, err = q.CreatePayment(ctx, paymentsdb.CreatePaymentParams{
+			InvoiceID:   invoiceID,
+			AmountCents: amountCents,
+		})
+		return err
+	})
+	if err != nil {
+		return payment, fmt.Errorf("store: create payment: %w", err)
+	}
+	return payment, nil
+}
+
+func (s *PaymentStore) Get(ctx context.Context, id int32) (paymentsdb.Payment, error) {
+	return s.queries.GetPayment(ctx, id)
+}
+
+// ListByInvoice - платежи по счёту в порядке создания
+func (s *PaymentStore) ListByInvoice(ctx context.Context, invoiceID int32) ([]paymentsdb.Payment, error) {
+	payments, err := s.queries.ListPaymentsByInvoice(ctx, invoiceID)
+	if err != nil {
+		return nil, fmt.Errorf("store: list payments by invoice %d: %w", invoiceID, err)
+	}
+	return payments, nil
+}
+
+// SetStatus переводит платёж в статус to, если текущий статус входит в from,
+// и пишет переход в журнал. Переход проверяет сам UPDATE; строка блокируется
+// заранее, чтобы знать исходный статус для журнала.
+// Если статус не подошёл - текущий платёж и ErrPaymentStatusMismatch,
+// если платежа нет - sql.ErrNoRows.
+func (s *PaymentStore) SetStatus(ctx context.Context, id int32, to string, from []string) (paymentsdb.Payment, error) {
+	var payment paymentsdb.Payment
+	err := s.withTx(ctx, func(q *paymentsdb.Queries) error {
+		current, err := q.GetPaymentForUpdate(ctx, id)
+		if err != nil {
+			return err
+		}
+		payment = current
+		if !slices.Contains(from, current.Status) {
+			return ErrPaymentStatusMismatch
+		}
+
+		payment, err = setPaymentStatus(ctx, q, current, to, from)
+		return err
+	})
+	if err != nil {
+		return payment, fmt.Errorf("store: set payment %d status %s: %w", id, to, err)
+	}
+	return payment, nil
+}
+
+// PaymentOperation - операция, которую надо записать, и статус платежа после неё.
+// Пустой Kind - только смена статуса, без записи в журнал операций.
+type PaymentOperation struct {
+	Kind        string
+	AmountCents int64
+	Status      string
+}
+
+// Виды операций, которые хранилище связывает со счётом; полный список - service.OperationAuthorize и соседи
+const (
+	operationAuthorize = "authorize"
+	operationCapture   = "capture"
+)
+
+// PaymentOperationFunc решает по заблокированному платежу и суммам его операций,
+// что записать; операции применяются по порядку, ошибка отменяет запись
+type PaymentOperationFunc func(payment paymentsdb.Payment, totals paymentsdb.PaymentOperationTotalsRow) ([]PaymentOperation, error)
+
+// ErrWebhookEventDuplicate - событие с таким event_id уже записано
+var ErrWebhookEventDuplicate = errors.New("webhook event already processed")
+
+// RecordOperation записывает операции по платежу и переводит его в новый статус.
+// Строка платежа блокируется на всю транзакцию, поэтому decide видит актуальные
+// суммы: два конкурентных возврата не превысят списанное.
+// Если платежа нет - sql.ErrNoRows.
+func (s *PaymentStore) RecordOperation(ctx context.Context, id int32, decide PaymentOperationFunc) (paymentsdb.Payment, error) {
+	var payment paymentsdb.Payment
+	err := s.withTx(ctx, func(q *paymentsdb.Queries) error {
+		var err error
+		payment, err = recordOperations(ctx, q, id, decide)
+		return err
+	})
+	if err != nil {
+		return payment, fmt.Errorf("store: record payment %d operation: %w", id, err)
+	}
+	return payment, nil
+}
+
+// ApplyWebhookEvent записывает событие провайдера с outcome applied и операции
+// по нему в одной транзакции: событие либо применено и записано, либо ни то, ни другое.
+// Конкурентная доставка того же события ждёт на уникальном ключе и получает
+// ErrWebhookEventDuplicate.
+func (s *PaymentStore) ApplyWebhookEvent(ctx context.Context, event paymentsdb.InsertPaymentWebhookEventParams, decide PaymentOperationFunc) (paymentsdb.Payment, error) {
+	var payment paymentsdb.Payment
+	err := s.withTx(ctx, func(q *paymentsdb.Queries) error {
+		if err := insertWebhookEvent(ctx, q, event); err != nil {
+			return err
+		}
+
+		var err error
+		payment, err = recordOperations(ctx, q, event.PaymentID, decide)
+		return err
+	})
+	if err != nil {
+		return payment, fmt.Errorf("store: apply webhook event %q: %w", event.EventID, err)
+	}
+	return payment, nil
+}
+
+// RecordWebhookEvent записывает событие без изменения платежа - например, отклонённое.
+// Уже записанное событие - ErrWebhookEventDuplicate.
+func (s *PaymentStore) RecordWebhookEvent(ctx context.Context, event paymentsdb.InsertPaymentWebhookEventParams) error {
+	if err := insertWebhookEvent(ctx, s.queries, event); err != nil {
+		return fmt.Errorf("store: record webhook event %q: %w", event.EventID, err)
+	}
+	return nil
+}
+
+// WebhookEvents - события провайдера по платежу в порядке получения
+func (s *PaymentStore) WebhookEvents(ctx context.Context, paymentID int32) ([]paymentsdb.PaymentWebhookEvent, error) {
+	events, err := s.queries.ListPaymentWebhookEvents(ctx, paymentID)
+	if err != nil {
+		return nil, fmt.Errorf("store: list payment %d webhook events: %w", paymentID, err)
+	}
+	return events, nil
+}
+
+// Totals - суммы операций по платежу
+func (s *PaymentStore) Totals(ctx context.Context, id int32) (paymentsdb.PaymentOperationTotalsRow, error) {
+	totals, err := s.queries.PaymentOperationTotals(ctx, id)
+	if err != nil {
+		return totals, fmt.Errorf("store: payment %d totals: %w", id, err)
+	}
+	return totals, nil
+}
+
+// Operations - операции по платежу по порядку
+func (s *PaymentStore) Operations(ctx context.Context, id int32) ([]paymentsdb.PaymentOperation, error) {
+	operations, err := s.queries.ListPaymentOperations(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("store: list payment %d operations: %w", id, err)
+	}
+	return operations, nil
+}
+
+// StatusHistory - переходы статусов платежа по порядку
+func (s *PaymentStore) StatusHistory(ctx context.Context, id int32) ([]paymentsdb.PaymentStatusTransition, error) {
+	transitions, err := s.queries.ListPaymentStatusTransitions(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("store: list payment %d status transitions: %w", id, err)
+	}
+	return transitions, nil
+}
+
+// setPaymentStatus - условный UPDATE статуса и запись в журнал переходов,
+// вызывается в транзакции с уже заблокированной строкой current
+func setPaymentStatus(ctx context.Context, q *paymentsdb.Queries, current paymentsdb.Payment, to string, from []string) (paymentsdb.Payment, error) {
+	payment, err := q.SetPaymentStatus(ctx, paymentsdb.SetPaymentStatusParams{
+		Status:       to,
+		ID:           current.ID,
+		FromStatuses: from,
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		return current, ErrPaymentStatusMismatch
+	}
+	if err != nil {
+		return current, err
+	}
+
+	_, err = q.InsertPaymentStatusTransition(ctx, paymentsdb.InsertPaymentStatusTransitionParams{
+		PaymentID:  current.ID,
+		FromStatus: current.Status,
+		ToStatus:   to,
+	})
+	return payment, err
+}
+
+// recordOperations блокирует счёт и платёж и применяет операции, которые вернул decide;
+// каждая смена статуса проходит через setPaymentStatus и попадает в журнал.
+// Счёт блокируется первым (тот же порядок, что в InvoiceStore.Void), и после
+// списания он становится paid, если списания по всем его платежам покрывают итог.
+// Авторизация и списание по аннулированному счёту - ErrInvoiceStatusMismatch.
+func recordOperations(ctx context.Context, q *paymentsdb.Queries, id int32, decide PaymentOperationFunc) (paymentsdb.Payment, error) {
+	// invoice_id платежа не меняется, его можно прочитать до блокировок
+	payment, err := q.GetPayment(ctx, id)
+	if err != nil {
+		return payment, err
+	}
+	invoice, err := q.GetInvoiceForUpdate(ctx, payment.InvoiceID)
+	if err != nil {
+		return payment, err
+	}
+
+	payment, err = q.GetPaymentForUpdate(ctx, id)
+	if err != nil {
+		return payment, err
+	}
+	totals, err := q.PaymentOperationTotals(ctx, id)
+	if err != nil {
+		return payment, err
+	}
+	planned, err := decide(payment, totals)
+	if err != nil {
+		return payment, err
+	}
+
+	captured := false
+	for _, operation := range planned {
+		switch operation.Kind {
+		case operationAuthorize, operationCapture:
+			if invoice.Status == invoiceVoid {
+				return payment, ErrInvoiceStatusMismatch
+			}
+			captured = captured || operation.Kind == operationCapture
+		}
+
+		if operation.Kind != "" {
+			_, err = q.InsertPaymentOperation(ctx, paymentsdb.InsertPaymentOperationParams{
+				PaymentID:   id,
+				Kind:        operation.Kind,
+				AmountCents: operation.AmountCents,
+			})
+			if err != nil {
+				return payment, err
+			}
+		}
+
+		if operation.Status == payment.Status {
+			continue
+		}
+		payment, err = setPaymentStatus(ctx, q, payment, operation.Status, []string{payment.Status})
+		if err != nil {
+			return payment, err
+		}
+	}
+
+	if captured {
+		err = markInvoicePaid(ctx, q, invoice.ID)
+	}
+	return payment, err
+}
+
+func insertWebhookEvent(ctx context.Context, q *paymentsdb.Queries, event paymentsdb.InsertPaymentWebhookEventParams) error {
+	_, err := q.InsertPaymentWebhookEvent(ctx, event)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrWebhookEventDuplicate
+	}
+	return err
+}
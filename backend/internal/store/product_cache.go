@@ -0,0 +1,240 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"golang.org/x/sync/singleflight"
+
+	"db200/internal/cache"
+	productsdb "db200/internal/db/products"
+	"db200/internal/money"
+)
+
+// ProductCache - хранилище закэшированных продуктов по id.
+// Методы принимают контекст и возвращают ошибку, чтобы за интерфейсом
+// мог стоять и сетевой кэш (Redis); ошибки кэша не ломают чтение из базы.
+type ProductCache interface {
+	Get(ctx context.Context, id int32) (CachedProduct, bool, error)
+	Set(ctx context.Context, id int32, entry CachedProduct, ttl time.Duration) error
+	Delete(ctx context.Context, id int32) error
+	Purge(ctx context.Context) error
+}
+
+// CachedProduct - запись кэша; NotFound - отрицательная запись (продукта нет или он удалён)
+type CachedProduct struct {
+	Product  productsdb.Product
+	NotFound bool
+}
+
+// LRUProductCache - ProductCache в памяти процесса
+type LRUProductCache struct {
+	lru *cache.LRU[int32, CachedProduct]
+}
+
+func NewLRUProductCache(size int) *LRUProductCache {
+	return &LRUProductCache{
+		lru: cache.NewLRU[int32, CachedProduct](size),
+	}
+}
+
+func (c *LRUProductCache) Get(_ context.Context, id int32) (CachedProduct, bool, error) {
+	entry, ok := c.lru.Get(id)
+	return entry, ok, nil
+}
+
+func (c *LRUProductCache) Set(_ context.Context, id int32, entry CachedProduct, ttl time.Duration) error {
+	c.lru.Set(id, entry, ttl)
+	return nil
+}
+
+func (c *LRUProductCache) Delete(_ context.Context, id int32) error {
+	c.lru.Delete(id)
+	return nil
+}
+
+func (c *LRUProductCache) Purge(_ context.Context) error {
+	c.lru.Purge()
+	return nil
+}
+
+// CacheStats - счётчики кэша с момента запуска
+type CacheStats struct {
+	Hits         int64
+	NegativeHits int64
+	Misses       int64
+	Errors       int64
+}
+
+// CachedProductStore - ProductStore с read-through кэшем для Get.
+// Одновременные промахи по одному id схлопываются в один запрос к базе.
+// Изменения продукта через эту обёртку сбрасывают его запись в кэше.
+type CachedProductStore struct {
+	*ProductStore
+	cache       ProductCache
+	ttl         time.Duration
+	negativeTTL time.Duration
+	group       singleflight.Group
+
+	// generation растёт при каждой инвалидации: результат загрузки,
+	// начатой до неё, в кэш не кладётся. Проверка generation и Set идут
+	// под mu.RLock, инвалидация - под mu.Lock, иначе инвалидация могла бы
+	// пройти между проверкой и Set и устаревшая запись осталась бы в кэше.
+	mu         sync.RWMutex
+	generation atomic.Uint64
+
+	hits         atomic.Int64
+	negativeHits atomic.Int64
+	misses       atomic.Int64
+	cacheErrors  atomic.Int64
+}
+
+// NewCachedProductStore оборачивает next; negativeTTL <= 0 отключает отрицательный кэш
+func NewCachedProductStore(next *ProductStore, productCache ProductCache, ttl, negativeTTL time.Duration) *CachedProductStore {
+	return &CachedProductStore{
+		ProductStore: next,
+		cache:        productCache,
+		ttl:          ttl,
+		negativeTTL:  negativeTTL,
+	}
+}
+
+// Get возвращает продукт из кэша, при промахе читает базу и кэширует результат,
+// включая sql.ErrNoRows
+func (s *CachedProductStore) Get(ctx context.Context, id int32) (productsdb.Product, error) {
+	entry, ok, err := s.cache.Get(ctx, id)
+	if err != nil {
+		s.cacheErrors.Add(1)
+	}
+	if ok {
+		if entry.NotFound {
+			s.negativeHits.Add(1)
+			return productsdb.Product{}, sql.ErrNoRows
+		}
+		s.hits.Add(1)
+		return entry.Product, nil
+	}
+	s.misses.Add(1)
+
+	// Загрузку делят несколько запросов, поэтому отмена одного из них её не прерывает
+	loadCtx := context.WithoutCancel(ctx)
+	v, err, _ := s.group.Do(strconv.Itoa(int(id)), func() (any, error) {
+		return s.load(loadCtx, id)
+	})
+	if err != nil {
+		return productsdb.Product{}, err
+	}
+	return v.(productsdb.Product), nil
+}
+
+func (s *CachedProductStore) load(ctx context.Context, id int32) (productsdb.Product, error) {
+	generation := s.generation.Load()
+	product, err := s.ProductStore.Get(ctx, id)
+
+	var entry CachedProduct
+	var ttl time.Duration
+	switch {
+	case err == nil:
+		entry, ttl = CachedProduct{Product: product}, s.ttl
+	case errors.Is(err, sql.ErrNoRows) && s.negativeTTL > 0:
+		entry, ttl = CachedProduct{NotFound: true}, s.negativeTTL
+	default:
+		return product, err
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if s.generation.Load() == generation {
+		if cErr := s.cache.Set(ctx, id, entry, ttl); cErr != nil {
+			s.cacheErrors.Add(1)
+		}
+	}
+	return product, err
+}
+
+// Stats возвращает текущие счётчики кэша
+func (s *CachedProductStore) Stats() CacheStats {
+	return CacheStats{
+		Hits:         s.hits.Load(),
+		NegativeHits: s.negativeHits.Load(),
+		Misses:       s.misses.Load(),
+		Errors:       s.cacheErrors.Load(),
+	}
+}
+
+func (s *CachedProductStore) Create(ctx context.Context, params productsdb.CreateProductParams) (productsdb.Product, error) {
+	product, err := s.ProductStore.Create(ctx, params)
+	if err == nil {
+		// По этому id мог лежать отрицательный ответ
+		s.invalidate(ctx, product.ID)
+	}
+	return product, err
+}
+
+func (s *CachedProductStore) CreateWithUniqueSlug(ctx context.Context, params productsdb.CreateProductParams) (productsdb.Product, error) {
+	product, err := s.ProductStore.CreateWithUniqueSlug(ctx, params)
+	if err == nil {
+		s.invalidate(ctx, product.ID)
+	}
+	return product, err
+}
+
+func (s *CachedProductStore) UpdatePrice(ctx context.Context, id int32, price money.Money, expectedVersion int32) (productsdb.Product, error) {
+	product, err := s.ProductStore.UpdatePrice(ctx, id, price, expectedVersion)
+	s.invalidate(ctx, id)
+	return product, err
+}
+
+func (s *CachedProductStore) Delete(ctx context.Context, id, expectedVersion int32) (int64, error) {
+	rows, err := s.ProductStore.Delete(ctx, id, expectedVersion)
+	s.invalidate(ctx, id)
+	return rows, err
+}
+
+func (s *CachedProductStore) Restore(ctx context.Context, id, expectedVersion int32) (int64, error) {
+	rows, err := s.ProductStore.Restore(ctx, id, expectedVersion)
+	s.invalidate(ctx, id)
+	return rows, err
+}
+
+// Import в режиме upsert меняет и восстанавливает существующие продукты
+func (s *CachedProductStore) Import(ctx context.Context, products []ImportProduct, upsert bool) ([]ImportedProduct, error) {
+	result, err := s.ProductStore.Import(ctx, products, upsert)
+	if err == nil {
+		for _, p := range result {
+			if p.ID != 0 {
+				s.invalidate(ctx, p.ID)
+			}
+		}
+	}
+	return result, err
+}
+
+func (s *CachedProductStore) DeleteAll(ctx context.Context) error {
+	err := s.ProductStore.DeleteAll(ctx)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.generation.Add(1)
+	if cErr := s.cache.Purge(ctx); cErr != nil {
+		s.cacheErrors.Add(1)
+	}
+	return err
+}
+
+// invalidate сбрасывает запись продукта. Вызывается и после неудачной записи:
+// лишний промах дешевле, чем устаревшая цена.
+func (s *CachedProductStore) invalidate(ctx context.Context, id int32) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.generation.Add(1)
+	s.group.Forget(strconv.Itoa(int(id)))
+	if err := s.cache.Delete(ctx, id); err != nil {
+		s.cacheErrors.Add(1)
+	}
+}
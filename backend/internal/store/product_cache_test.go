@@ -0,0 +1,152 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	productsdb "db200/internal/db/products"
+)
+
+// fakeConnector - соединение без базы: каждый запрос отдаётся в query,
+// которая возвращает строки результата. Транзакции ничего не делают.
+type fakeConnector struct {
+	query func(query string) ([][]driver.Value, error)
+}
+
+func (c fakeConnector) Connect(context.Context) (driver.Conn, error) { return fakeConn(c), nil }
+func (c fakeConnector) Driver() driver.Driver                        { return c }
+func (c fakeConnector) Open(string) (driver.Conn, error)             { return fakeConn(c), nil }
+
+type fakeConn fakeConnector
+
+func (c fakeConn) Prepare(string) (driver.Stmt, error) {
+	return nil, errors.New("prepare not supported")
+}
+func (c fakeConn) Close() error              { return nil }
+func (c fakeConn) Begin() (driver.Tx, error) { return fakeTx{}, nil }
+
+func (c fakeConn) BeginTx(context.Context, driver.TxOptions) (driver.Tx, error) {
+	return fakeTx{}, nil
+}
+
+func (c fakeConn) QueryContext(_ context.Context, query string, _ []driver.NamedValue) (driver.Rows, error) {
+	rows, err := c.query(query)
+	if err != nil {
+		return nil, err
+	}
+	return &fakeRows{rows: rows}, nil
+}
+
+type fakeTx struct{}
+
+func (fakeTx) Commit() error   { return nil }
+func (fakeTx) Rollback() error { return nil }
+
+type fakeRows struct {
+	rows [][]driver.Value
+}
+
+func (r *fakeRows) Columns() []string {
+	if len(r.rows) == 0 {
+		return nil
+	}
+	return make([]string, len(r.rows[0]))
+}
+
+func (r *fakeRows) Close() error { return nil }
+
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if len(r.rows) == 0 {
+		return io.EOF
+	}
+	copy(dest, r.rows[0])
+	r.rows = r.rows[1:]
+	return nil
+}
+
+// productRow - строка products в порядке RETURNING/SELECT из запросов sqlc
+func productRow(id int64, title string) []driver.Value {
+	return []driver.Value{id, "slug", title, "", int64(1000), time.Now(), nil, "RUB", int64(1)}
+}
+
+func newTestCachedStore(query func(string) ([][]driver.Value, error)) (*CachedProductStore, *LRUProductCache) {
+	db := sql.OpenDB(fakeConnector{query: query})
+	productCache := NewLRUProductCache(10)
+	return NewCachedProductStore(NewProductStore(db), productCache, time.Minute, time.Minute), productCache
+}
+
+func TestCachedProductStoreNegativeEntryDroppedOnCreate(t *testing.T) {
+	ctx := context.Background()
+	created := false
+	s, productCache := newTestCachedStore(func(query string) ([][]driver.Value, error) {
+		switch {
+		case strings.Contains(query, "INSERT INTO product_prices"):
+			return [][]driver.Value{{int64(1), int64(7), int64(1000), time.Now(), "RUB"}}, nil
+		case strings.Contains(query, "INSERT INTO products"):
+			created = true
+			return [][]driver.Value{productRow(7, "Чай")}, nil
+		case created:
+			return [][]driver.Value{productRow(7, "Чай")}, nil
+		}
+		return nil, nil
+	})
+
+	if _, err := s.Get(ctx, 7); !errors.Is(err, sql.ErrNoRows) {
+		t.Fatalf("Get before create: error = %v, want %v", err, sql.ErrNoRows)
+	}
+	if entry, ok, _ := productCache.Get(ctx, 7); !ok || !entry.NotFound {
+		t.Fatalf("cache entry = %+v, %v; want negative entry", entry, ok)
+	}
+
+	if _, err := s.Create(ctx, productsdb.CreateProductParams{Slug: "chay", Title: "Чай", PriceCents: 1000, Currency: "RUB"}); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	if _, ok, _ := productCache.Get(ctx, 7); ok {
+		t.Fatal("negative entry survived Create")
+	}
+
+	product, err := s.Get(ctx, 7)
+	if err != nil || product.Title != "Чай" {
+		t.Errorf("Get after create = %+v, %v; want the created product", product, err)
+	}
+}
+
+// Загрузка, во время которой продукт изменился, не должна положить в кэш старую версию
+func TestCachedProductStoreDiscardsStaleFill(t *testing.T) {
+	ctx := context.Background()
+	var s *CachedProductStore
+	loads := 0
+	s, productCache := newTestCachedStore(func(string) ([][]driver.Value, error) {
+		loads++
+		if loads == 1 {
+			// Инвалидация между чтением generation и записью в кэш
+			s.invalidate(ctx, 7)
+			return [][]driver.Value{productRow(7, "старая цена")}, nil
+		}
+		return [][]driver.Value{productRow(7, "новая цена")}, nil
+	})
+
+	if _, err := s.Get(ctx, 7); err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if entry, ok, _ := productCache.Get(ctx, 7); ok {
+		t.Fatalf("stale fill cached: %+v", entry)
+	}
+
+	product, err := s.Get(ctx, 7)
+	if err != nil || product.Title != "новая цена" {
+		t.Fatalf("second Get = %+v, %v; want a fresh load", product, err)
+	}
+	if _, ok, _ := productCache.Get(ctx, 7); !ok {
+		t.Error("fill without concurrent invalidation was not cached")
+	}
+	if stats := s.Stats(); stats.Misses != 2 || stats.Hits != 0 {
+		t.Errorf("Stats = %+v, want 2 misses", stats)
+	}
+}
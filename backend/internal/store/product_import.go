@@ -0,0 +1,112 @@
+package store
+
+import (
+	"context"
+	"fmt"
+
+	productsdb "db200/internal/db/products"
+	"db200/internal/money"
+)
+
+// importBatchSize - сколько строк уходит в один INSERT
+const importBatchSize = 1000
+
+// ImportProduct - строка импорта, уже прошедшая валидацию
+type ImportProduct struct {
+	Slug        string
+	Title       string
+	Description string
+	PriceCents  int64
+	Currency    money.Currency
+}
+
+// ImportedProduct - результат по одной строке: если ID == 0, строка не записана
+// (slug уже занят, а режим upsert выключен)
+type ImportedProduct struct {
+	ID       int32
+	Slug     string
+	Inserted bool
+}
+
+// Import пишет продукты пачками в одной транзакции: либо всё, либо ничего.
+// В режиме upsert существующие по slug продукты обновляются (и восстанавливаются,
+// если были удалены). Изменившиеся цены попадают в историю цен.
+// Slug внутри products должны быть уникальны - это проверяет вызывающий код.
+func (s *ProductStore) Import(ctx context.Context, products []ImportProduct, upsert bool) ([]ImportedProduct, error) {
+	result := make([]ImportedProduct, len(products))
+	index := make(map[string]int, len(products))
+	for i, p := range products {
+		index[p.Slug] = i
+		result[i].Slug = p.Slug
+	}
+
+	err := s.withTx(ctx, func(q *productsdb.Queries) error {
+		ids := make([]int32, 0, len(products))
+
+		for start := 0; start < len(products); start += importBatchSize {
+			end := min(start+importBatchSize, len(products))
+			batch := products[start:end]
+
+			slugs := make([]string, len(batch))
+			titles := make([]string, len(batch))
+			descriptions := make([]string, len(batch))
+			prices := make([]int64, len(batch))
+			currencies := make([]string, len(batch))
+			for i, p := range batch {
+				slugs[i] = p.Slug
+				titles[i] = p.Title
+				descriptions[i] = p.Description
+				prices[i] = p.PriceCents
+				currencies[i] = string(p.Currency)
+			}
+
+			if upsert {
+				rows, err := q.UpsertProductsBatch(ctx, productsdb.UpsertProductsBatchParams{
+					Slugs:        slugs,
+					Titles:       titles,
+					Descriptions: descriptions,
+					Prices:       prices,
+					Currencies:   currencies,
+				})
+				if err != nil {
+					return fmt.Errorf("store: upsert products batch at %d: %w", start, err)
+				}
+				for _, row := range rows {
+					result[index[row.Slug]].ID = row.ID
+					result[index[row.Slug]].Inserted = row.Inserted
+					ids = append(ids, row.ID)
+				}
+				continue
+			}
+
+			rows, err := q.InsertProductsBatch(ctx, productsdb.InsertProductsBatchParams{
+				Slugs:        slugs,
+				Titles:       titles,
+				Descriptions: descriptions,
+				Prices:       prices,
+				Currencies:   currencies,
+			})
+			if err != nil {
+				return fmt.Errorf("store: insert products batch at %d: %w", start, err)
+			}
+			for _, row := range rows {
+				result[index[row.Slug]].ID = row.ID
+				result[index[row.Slug]].Inserted = true
+				ids = append(ids, row.ID)
+			}
+		}
+
+		if len(ids) == 0 {
+			return nil
+		}
+		if _, err := q.RecordCurrentPrices(ctx, ids); err != nil {
+			return fmt.Errorf("store: record imported prices: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
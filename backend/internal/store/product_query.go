@@ -0,0 +1,186 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+
+	productsdb "db200/internal/db/products"
+)
+
+// DefaultProductSort - порядок списка по умолчанию, совпадает с порядком курсоров
+const DefaultProductSort = "created_asc"
+
+var ErrUnknownSort = errors.New("unknown sort order")
+
+// productSorts - допустимые сортировки; в SQL попадает только значение из этой карты.
+// id в конце делает порядок однозначным при равных значениях.
+var productSorts = map[string]string{
+	"created_asc":  "created_at ASC, id ASC",
+	"created_desc": "created_at DESC, id DESC",
+	"price_asc":    "price_cents ASC, id ASC",
+	"price_desc":   "price_cents DESC, id DESC",
+	"title_asc":    "title ASC, id ASC",
+	"title_desc":   "title DESC, id DESC",
+}
+
+// productColumns - те же колонки и в том же порядке, что в запросах sqlc
+const productColumns = "id,slug,title,description,price_cents,created_at,deleted_at,currency,version"
+
+// ProductFilter - фильтры списка продуктов, нулевые значения не фильтруют.
+// CategoryID выбирает категорию вместе со всеми подкатегориями.
+// AnyTags - хотя бы один из тегов, AllTags - все теги сразу; теги без повторов.
+// Цены сравниваются с хранимой суммой в минорных единицах, без конвертации валют.
+// Окно создания полуоткрытое: CreatedFrom <= created_at < CreatedTo.
+type ProductFilter struct {
+	CategoryID    int32
+	AnyTags       []string
+	AllTags       []string
+	MinPriceCents int64
+	MaxPriceCents int64
+	CreatedFrom   time.Time
+	CreatedTo     time.Time
+	SlugPrefix    string
+}
+
+// ValidSort сообщает, есть ли сортировка в списке допустимых
+func ValidSort(sort string) bool {
+	_, ok := productSorts[sort]
+	return ok
+}
+
+// categoryIDs раскрывает CategoryID в список id поддерева.
+// Несуществующая категория - sql.ErrNoRows, а не пустой фильтр.
+func (s *ProductStore) categoryIDs(ctx context.Context, filter ProductFilter) ([]int32, error) {
+	if filter.CategoryID == 0 {
+		return nil, nil
+	}
+
+	ids, err := s.queries.CategorySubtreeIDs(ctx, filter.CategoryID)
+	if err != nil {
+		return nil, fmt.Errorf("store: category %d subtree: %w", filter.CategoryID, err)
+	}
+	if len(ids) == 0 {
+		return nil, fmt.Errorf("store: category %d: %w", filter.CategoryID, sql.ErrNoRows)
+	}
+	return ids, nil
+}
+
+// productQuery собирает SELECT по products из условий фильтра.
+// Значения передаются только параметрами $N, в текст запроса попадают
+// лишь фиксированные фрагменты и сортировка из productSorts.
+type productQuery struct {
+	where []string
+	args  []any
+}
+
+// arg добавляет значение в параметры и возвращает его плейсхолдер
+func (q *productQuery) arg(v any) string {
+	q.args = append(q.args, v)
+	return fmt.Sprintf("$%d", len(q.args))
+}
+
+func (q *productQuery) and(cond string) {
+	q.where = append(q.where, cond)
+}
+
+// filter добавляет условия фильтра; categoryIDs - уже раскрытое поддерево
+func (q *productQuery) filter(includeDeleted bool, filter ProductFilter, categoryIDs []int32) {
+	if !includeDeleted {
+		q.and("deleted_at IS NULL")
+	}
+	if len(categoryIDs) > 0 {
+		q.and(fmt.Sprintf(`EXISTS (
+    SELECT 1 FROM product_categories pc
+    WHERE pc.product_id = products.id AND pc.category_id = ANY(%s::int[])
+)`, q.arg(pq.Array(categoryIDs))))
+	}
+	if len(filter.AnyTags) > 0 {
+		q.and(fmt.Sprintf(`EXISTS (
+    SELECT 1 FROM product_tags pt JOIN tags t ON t.id = pt.tag_id
+    WHERE pt.product_id = products.id AND t.name = ANY(%s::text[])
+)`, q.arg(pq.Array(filter.AnyTags))))
+	}
+	if len(filter.AllTags) > 0 {
+		q.and(fmt.Sprintf(`(
+    SELECT count(*) FROM product_tags pt JOIN tags t ON t.id = pt.tag_id
+    WHERE pt.product_id = products.id AND t.name = ANY(%s::text[])
+) = %s`, q.arg(pq.Array(filter.AllTags)), q.arg(len(filter.AllTags))))
+	}
+	if filter.MinPriceCents > 0 {
+		q.and("price_cents >= " + q.arg(filter.MinPriceCents))
+	}
+	if filter.MaxPriceCents > 0 {
+		q.and("price_cents <= " + q.arg(filter.MaxPriceCents))
+	}
+	if !filter.CreatedFrom.IsZero() {
+		q.and("created_at >= " + q.arg(filter.CreatedFrom))
+	}
+	if !filter.CreatedTo.IsZero() {
+		q.and("created_at < " + q.arg(filter.CreatedTo))
+	}
+	if filter.SlugPrefix != "" {
+		q.and(fmt.Sprintf(`slug LIKE %s ESCAPE '\'`, q.arg(likePrefix(filter.SlugPrefix))))
+	}
+}
+
+// sql возвращает готовый запрос; orderBy должен прийти из productSorts
+func (q *productQuery) sql(orderBy string, limit, offset int32) string {
+	var b strings.Builder
+	b.WriteString("SELECT " + productColumns + " FROM products")
+	if len(q.where) > 0 {
+		b.WriteString("\nWHERE " + strings.Join(q.where, "\nAND "))
+	}
+	b.WriteString("\nORDER BY " + orderBy)
+	b.WriteString("\nLIMIT " + q.arg(limit))
+	if offset > 0 {
+		b.WriteString(" OFFSET " + q.arg(offset))
+	}
+	return b.String()
+}
+
+// queryProducts выполняет собранный запрос и сканирует строки в productsdb.Product
+func (s *ProductStore) queryProducts(ctx context.Context, query string, args []any) ([]productsdb.Product, error) {
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var products []productsdb.Product
+	for rows.Next() {
+		var p productsdb.Product
+		if err := rows.Scan(
+			&p.ID,
+			&p.Slug,
+			&p.Title,
+			&p.Description,
+			&p.PriceCents,
+			&p.CreatedAt,
+			&p.DeletedAt,
+			&p.Currency,
+			&p.Version,
+		); err != nil {
+			return nil, err
+		}
+		products = append(products, p)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return products, nil
+}
+
+// likePrefix экранирует спецсимволы LIKE и добавляет % в конец
+func likePrefix(prefix string) string {
+	r := strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+	return r.Replace(prefix) + "%"
+}
@@ -4,57 +4,202 @@ package store
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
+	"time"
+
+	"github.com/lib/pq"
 
 	productsdb "db200/internal/db/products"
+	"db200/internal/money"
+	"db200/internal/slug"
 )
 
+// MaxListLimit - максимальный размер страницы для списка продуктов
+const MaxListLimit = 50
+
+// AnyVersion - изменять продукт без проверки версии
+const AnyVersion int32 = 0
+
+// maxSlugAttempts - сколько суффиксов пробуем, прежде чем сдаться
+const maxSlugAttempts = 50
+
+var ErrSlugTaken = errors.New("slug already taken")
+
 // ProductStore - хранилище ТОЛЬКО для продуктов
 type ProductStore struct {
+	db      *sql.DB
 	queries *productsdb.Queries
 }
 
 // NewProductStore создает новый ProductStore
 func NewProductStore(db *sql.DB) *ProductStore {
 	return &ProductStore{
+		db:      db,
 		queries: productsdb.New(db),
 	}
 }
 
-// Create создает новый продукт
+// withTx выполняет fn в транзакции, queries внутри fn привязаны к ней
+func (s *ProductStore) withTx(ctx context.Context, fn func(*productsdb.Queries) error) error {
+	return withProductsTx(ctx, s.db, s.queries, fn)
+}
+
+// withProductsTx - общая обёртка транзакции для хранилищ поверх productsdb
+func withProductsTx(ctx context.Context, db *sql.DB, queries *productsdb.Queries, fn func(*productsdb.Queries) error) error {
+	tx, err := db.BeginTx(ctx, &sql.TxOptions{
+		Isolation: sql.LevelReadCommitted,
+	})
+	if err != nil {
+		return err
+	}
+
+	defer tx.Rollback()
+	if err = fn(queries.WithTx(tx)); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// Create создает новый продукт и первую запись в истории цен
 func (s *ProductStore) Create(ctx context.Context, params productsdb.CreateProductParams) (productsdb.Product, error) {
-	product, err := s.queries.CreateProduct(ctx, params)
+	var product productsdb.Product
+	err := s.withTx(ctx, func(q *productsdb.Queries) error {
+		var err error
+		product, err = q.CreateProduct(ctx, params)
+		if err != nil {
+			return err
+		}
+		_, err = q.InsertProductPrice(ctx, productsdb.InsertProductPriceParams{
+			ProductID:  product.ID,
+			PriceCents: product.PriceCents,
+			Currency:   product.Currency,
+		})
+		return err
+	})
 	if err != nil {
+		if isUniqueViolation(err) {
+			return product, fmt.Errorf("store: create product: %w: %s", ErrSlugTaken, params.Slug)
+		}
 		return product, err
 		//return product, fmt.Errorf("create product: %w", err)
 	}
 	return product, nil
 }
 
-// Create создает новый продукт
+// CreateWithUniqueSlug создает продукт, подбирая свободный slug:
+// base, base-2, base-3, ... Конфликт разрешается в самом INSERT
+// (ON CONFLICT DO NOTHING), поэтому параллельные вставки не падают.
+func (s *ProductStore) CreateWithUniqueSlug(ctx context.Context, params productsdb.CreateProductParams) (productsdb.Product, error) {
+	var product productsdb.Product
+	err := s.withTx(ctx, func(q *productsdb.Queries) error {
+		base := params.Slug
+		for n := 1; n <= maxSlugAttempts; n++ {
+			params.Slug = slug.WithSuffix(base, n)
+
+			var err error
+			product, err = q.CreateProductIfSlugFree(ctx, productsdb.CreateProductIfSlugFreeParams{
+				Slug:        params.Slug,
+				Title:       params.Title,
+				Description: params.Description,
+				PriceCents:  params.PriceCents,
+				Currency:    params.Currency,
+			})
+			if errors.Is(err, sql.ErrNoRows) {
+				continue
+			}
+			if err != nil {
+				return fmt.Errorf("store: create product %q: %w", params.Slug, err)
+			}
+
+			_, err = q.InsertProductPrice(ctx, productsdb.InsertProductPriceParams{
+				ProductID:  product.ID,
+				PriceCents: product.PriceCents,
+				Currency:   product.Currency,
+			})
+			return err
+		}
+
+		return fmt.Errorf("store: create product: %w: no free suffix for %q", ErrSlugTaken, base)
+	})
+	if err != nil {
+		return productsdb.Product{}, err
+	}
+
+	return product, nil
+}
+
+// Get возвращает продукт, удалённые продукты не видны
 func (s *ProductStore) Get(ctx context.Context, id int32) (productsdb.Product, error) {
-	product, err := s.queries.GetProductByID(ctx, id)
+	product, err := s.queries.GetProductByID(ctx, productsdb.GetProductByIDParams{
+		ID: id,
+	})
+	if err != nil {
+
+		return product, err
+	}
+	return product, nil
+}
+
+// GetIncludingDeleted возвращает продукт, даже если он мягко удалён
+func (s *ProductStore) GetIncludingDeleted(ctx context.Context, id int32) (productsdb.Product, error) {
+	product, err := s.queries.GetProductByID(ctx, productsdb.GetProductByIDParams{
+		ID:             id,
+		IncludeDeleted: true,
+	})
 	if err != nil {
+		return product, err
+	}
+	return product, nil
+}
 
+// GetBySlug возвращает продукт по slug
+func (s *ProductStore) GetBySlug(ctx context.Context, productSlug string) (productsdb.Product, error) {
+	product, err := s.queries.GetProductBySlug(ctx, productSlug)
+	if err != nil {
 		return product, err
 	}
 	return product, nil
 }
 
+// ListParams - параметры списка со смещением.
+// Sort - ключ из списка допустимых сортировок, пустой - DefaultProductSort.
+type ListParams struct {
+	Limit          int32
+	Offset         int32
+	IncludeDeleted bool
+	Filter         ProductFilter
+	Sort           string
+}
+
 // List возвращает список продуктов с пагинацией
-func (s *ProductStore) List(ctx context.Context, limit, offset int32) ([]productsdb.Product, error) {
+func (s *ProductStore) List(ctx context.Context, params ListParams) ([]productsdb.Product, error) {
 	// Валидация пагинации
-	if limit < 0 || offset < 0 {
-		return nil, fmt.Errorf("store: invalid pagination: limit=%d, offset=%d", limit, offset)
+	if params.Limit < 0 || params.Offset < 0 {
+		return nil, fmt.Errorf("store: invalid pagination: limit=%d, offset=%d", params.Limit, params.Offset)
 	}
-	if limit > 100 { // защита от слишком больших лимитов
-		limit = 100
+	if params.Limit > MaxListLimit { // защита от слишком больших лимитов
+		return nil, fmt.Errorf("store: limit too large: %d", params.Limit)
 	}
 
-	products, err := s.queries.ListProducts(ctx, productsdb.ListProductsParams{
-		Limit:  limit,
-		Offset: offset,
-	})
+	sort := params.Sort
+	if sort == "" {
+		sort = DefaultProductSort
+	}
+	orderBy, ok := productSorts[sort]
+	if !ok {
+		return nil, fmt.Errorf("store: list products: %w: %q", ErrUnknownSort, sort)
+	}
+
+	categoryIDs, err := s.categoryIDs(ctx, params.Filter)
+	if err != nil {
+		return nil, err
+	}
+
+	var q productQuery
+	q.filter(params.IncludeDeleted, params.Filter, categoryIDs)
+	products, err := s.queryProducts(ctx, q.sql(orderBy, params.Limit, params.Offset), q.args)
 	if err != nil {
 		return nil, fmt.Errorf("store: list products: %w", err)
 	}
@@ -62,38 +207,266 @@ func (s *ProductStore) List(ctx context.Context, limit, offset int32) ([]product
 	return products, nil
 }
 
-// UpdatePrice обновляет цену продукта
-func (s *ProductStore) UpdatePrice(ctx context.Context, id, priceCents int32) (int64, error) {
+// PageParams - параметры курсорной пагинации.
+// After и Before взаимоисключающие, пустые значения означают первую страницу.
+type PageParams struct {
+	Limit          int32
+	After          string
+	Before         string
+	IncludeDeleted bool
+	Filter         ProductFilter
+}
+
+// ProductPage - страница продуктов с курсорами на соседние страницы
+type ProductPage struct {
+	Products   []productsdb.Product
+	NextCursor string
+	PrevCursor string
+	HasMore    bool
+}
+
+// ListPage возвращает страницу продуктов в порядке (created_at, id)
+func (s *ProductStore) ListPage(ctx context.Context, params PageParams) (ProductPage, error) {
+	if params.Limit <= 0 || params.Limit > MaxListLimit {
+		return ProductPage{}, fmt.Errorf("store: invalid page limit: %d", params.Limit)
+	}
+	if params.After != "" && params.Before != "" {
+		return ProductPage{}, fmt.Errorf("store: list page: %w: after and before are mutually exclusive",
+			ErrInvalidCursor)
+	}
+
+	categoryIDs, err := s.categoryIDs(ctx, params.Filter)
+	if err != nil {
+		return ProductPage{}, err
+	}
+
+	var q productQuery
+	q.filter(params.IncludeDeleted, params.Filter, categoryIDs)
+
+	// Назад идём по убыванию от курсора, потом разворачиваем страницу
+	orderBy := productSorts[DefaultProductSort]
+	switch {
+	case params.Before != "":
+		cursor, cErr := DecodeCursor(params.Before)
+		if cErr != nil {
+			return ProductPage{}, fmt.Errorf("store: list page: %w", cErr)
+		}
+		q.and(fmt.Sprintf("(created_at, id) < (%s, %s)", q.arg(cursor.CreatedAt), q.arg(cursor.ID)))
+		orderBy = productSorts["created_desc"]
+	case params.After != "":
+		cursor, cErr := DecodeCursor(params.After)
+		if cErr != nil {
+			return ProductPage{}, fmt.Errorf("store: list page: %w", cErr)
+		}
+		q.and(fmt.Sprintf("(created_at, id) > (%s, %s)", q.arg(cursor.CreatedAt), q.arg(cursor.ID)))
+	}
+
+	// Запрашиваем на одну запись больше, чтобы понять, есть ли ещё страница
+	products, err := s.queryProducts(ctx, q.sql(orderBy, params.Limit+1, 0), q.args)
+	if err != nil {
+		return ProductPage{}, fmt.Errorf("store: list page: %w", err)
+	}
+
+	page := ProductPage{
+		HasMore: len(products) > int(params.Limit),
+	}
+	if page.HasMore {
+		products = products[:params.Limit]
+	}
+
+	// Назад читаем в обратном порядке, возвращаем всегда по возрастанию
+	if params.Before != "" {
+		for i, j := 0, len(products)-1; i < j; i, j = i+1, j-1 {
+			products[i], products[j] = products[j], products[i]
+		}
+	}
+	page.Products = products
+
+	if len(products) == 0 {
+		return page, nil
+	}
+
+	first := products[0]
+	last := products[len(products)-1]
+	if params.Before != "" {
+		page.NextCursor = EncodeCursor(ProductCursor{CreatedAt: last.CreatedAt, ID: last.ID})
+		if page.HasMore {
+			page.PrevCursor = EncodeCursor(ProductCursor{CreatedAt: first.CreatedAt, ID: first.ID})
+		}
+		return page, nil
+	}
+
+	if page.HasMore {
+		page.NextCursor = EncodeCursor(ProductCursor{CreatedAt: last.CreatedAt, ID: last.ID})
+	}
+	if params.After != "" {
+		page.PrevCursor = EncodeCursor(ProductCursor{CreatedAt: first.CreatedAt, ID: first.ID})
+	}
+
+	return page, nil
+}
+
+// Search ищет продукты по title и description, результаты отсортированы по релевантности
+func (s *ProductStore) Search(ctx context.Context, query string, limit, offset int32) ([]productsdb.SearchProductsRow, int64, error) {
+	if limit <= 0 || limit > MaxListLimit || offset < 0 {
+		return nil, 0, fmt.Errorf("store: invalid pagination: limit=%d, offset=%d", limit, offset)
+	}
+
+	total, err := s.queries.CountSearchProducts(ctx, query)
+	if err != nil {
+		return nil, 0, fmt.Errorf("store: count search products: %w", err)
+	}
+	if total == 0 || int64(offset) >= total {
+		return nil, total, nil
+	}
+
+	rows, err := s.queries.SearchProducts(ctx, productsdb.SearchProductsParams{
+		Query:     query,
+		RowLimit:  limit,
+		RowOffset: offset,
+	})
+	if err != nil {
+		return nil, 0, fmt.Errorf("store: search products: %w", err)
+	}
+
+	return rows, total, nil
+}
+
+// UpdatePrice обновляет цену и валюту продукта, если его версия равна expectedVersion
+// (AnyVersion - без проверки). Пустая price.Currency - валюта остаётся прежней,
+// это решается в том же UPDATE. Если продукт не найден или версия не совпала - sql.ErrNoRows.
+func (s *ProductStore) UpdatePrice(ctx context.Context, id int32, price money.Money, expectedVersion int32) (productsdb.Product, error) {
 	// Валидация
 	if id <= 0 {
-		return 0, fmt.Errorf("store: invalid product id: %d", id)
+		return productsdb.Product{}, fmt.Errorf("store: invalid product id: %d", id)
 	}
-	if priceCents < 0 {
-		return 0, fmt.Errorf("store: price cannot be negative: %d", priceCents)
+	if price.Amount < 0 {
+		return productsdb.Product{}, fmt.Errorf("store: price cannot be negative: %d", price.Amount)
 	}
+	if price.Currency != "" && !price.Currency.Valid() {
+		return productsdb.Product{}, fmt.Errorf("store: %w: %q", money.ErrUnknownCurrency, price.Currency)
+	}
+
+	// Цена продукта и запись в истории меняются атомарно
+	var product productsdb.Product
+	err := s.withTx(ctx, func(q *productsdb.Queries) error {
+		var err error
+		product, err = q.UpdateProductPrice(ctx, productsdb.UpdateProductPriceParams{
+			PriceCents:      price.Amount,
+			Currency:        string(price.Currency),
+			ID:              id,
+			ExpectedVersion: expectedVersion,
+		})
+		if err != nil {
+			return err
+		}
 
-	rows, err := s.queries.UpdateProductPrice(ctx, productsdb.UpdateProductPriceParams{
-		ID:         id,
-		PriceCents: priceCents,
+		_, err = q.InsertProductPrice(ctx, productsdb.InsertProductPriceParams{
+			ProductID:  id,
+			PriceCents: product.PriceCents,
+			Currency:   product.Currency,
+		})
+		return err
 	})
 	if err != nil {
-		return 0, fmt.Errorf("store: update product price %d: %w", id, err)
+		return productsdb.Product{}, fmt.Errorf("store: update product price %d: %w", id, err)
 	}
 
-	return rows, nil
+	return product, nil
 }
 
-// Delete удаляет продукт
-func (s *ProductStore) Delete(ctx context.Context, id int32) (int64, error) {
+// PriceHistory возвращает историю цен продукта, новые записи первыми
+func (s *ProductStore) PriceHistory(ctx context.Context, id, limit int32) ([]productsdb.ProductPrice, error) {
+	if limit <= 0 || limit > MaxListLimit {
+		return nil, fmt.Errorf("store: invalid limit: %d", limit)
+	}
+
+	prices, err := s.queries.ListProductPrices(ctx, productsdb.ListProductPricesParams{
+		ProductID: id,
+		Limit:     limit,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("store: list product prices %d: %w", id, err)
+	}
+
+	return prices, nil
+}
+
+// PriceAt возвращает цену, действовавшую в момент at
+func (s *ProductStore) PriceAt(ctx context.Context, id int32, at time.Time) (productsdb.ProductPrice, error) {
+	price, err := s.queries.GetProductPriceAt(ctx, productsdb.GetProductPriceAtParams{
+		ProductID: id,
+		At:        at.UTC(),
+	})
+	if err != nil {
+		return price, err
+	}
+	return price, nil
+}
+
+// Delete мягко удаляет продукт (проставляет deleted_at)
+func (s *ProductStore) Delete(ctx context.Context, id, expectedVersion int32) (int64, error) {
 	// Валидация
 	if id <= 0 {
 		return 0, fmt.Errorf("store: invalid product id: %d", id)
 	}
 
-	rows, err := s.queries.DeleteProduct(ctx, id)
+	rows, err := s.queries.SoftDeleteProduct(ctx, productsdb.SoftDeleteProductParams{
+		ID:              id,
+		ExpectedVersion: expectedVersion,
+	})
 	if err != nil {
 		return 0, fmt.Errorf("store: delete product %d: %w", id, err)
 	}
 
 	return rows, nil
 }
+
+// Restore возвращает мягко удалённый продукт
+func (s *ProductStore) Restore(ctx context.Context, id, expectedVersion int32) (int64, error) {
+	if id <= 0 {
+		return 0, fmt.Errorf("store: invalid product id: %d", id)
+	}
+
+	rows, err := s.queries.RestoreProduct(ctx, productsdb.RestoreProductParams{
+		ID:              id,
+		ExpectedVersion: expectedVersion,
+	})
+	if err != nil {
+		return 0, fmt.Errorf("store: restore product %d: %w", id, err)
+	}
+
+	return rows, nil
+}
+
+// PurgeDeleted окончательно удаляет продукты, удалённые раньше before
+func (s *ProductStore) PurgeDeleted(ctx context.Context, before time.Time) (int64, error) {
+	rows, err := s.queries.PurgeDeletedProducts(ctx, before.UTC())
+	if err != nil {
+		return 0, fmt.Errorf("store: purge deleted products: %w", err)
+	}
+
+	return rows, nil
+}
+
+// DeleteAll безвозвратно удаляет все продукты.
+// Проверка прав - на стороне сервиса.
+func (s *ProductStore) DeleteAll(ctx context.Context) error {
+	if err := s.queries.DeleteAllProducts(ctx); err != nil {
+		return fmt.Errorf("store: delete all products: %w", err)
+	}
+
+	return nil
+}
+
+// isUniqueViolation - нарушение UNIQUE-ограничения (SQLSTATE 23505)
+func isUniqueViolation(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == "23505"
+}
+
+// isForeignKeyViolation - нарушение внешнего ключа (SQLSTATE 23503)
+func isForeignKeyViolation(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == "23503"
+}
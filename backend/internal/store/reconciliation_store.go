@@ -0,0 +1,96 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	paymentsdb "db200/internal/db/payments"
+)
+
+// ReconciliationStore - отчёты сверки с расчётами провайдера
+type ReconciliationStore struct {
+	db      *sql.DB
+	queries *paymentsdb.Queries
+}
+
+func NewReconciliationStore(db *sql.DB) *ReconciliationStore {
+	return &ReconciliationStore{
+		db:      db,
+		queries: paymentsdb.New(db),
+	}
+}
+
+func (s *ReconciliationStore) withTx(ctx context.Context, fn func(*paymentsdb.Queries) error) error {
+	tx, err := s.db.BeginTx(ctx, &sql.TxOptions{
+		Isolation: sql.LevelReadCommitted,
+	})
+	if err != nil {
+		return err
+	}
+
+	defer tx.Rollback()
+	if err = fn(s.queries.WithTx(tx)); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// SettlementCandidates - платежи со списаниями в [from, to) и сумма этих списаний
+func (s *ReconciliationStore) SettlementCandidates(ctx context.Context, from, to time.Time) ([]paymentsdb.ListSettlementCandidatesRow, error) {
+	candidates, err := s.queries.ListSettlementCandidates(ctx, paymentsdb.ListSettlementCandidatesParams{
+		PeriodFrom: from,
+		PeriodTo:   to,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("store: list settlement candidates: %w", err)
+	}
+	return candidates, nil
+}
+
+// Save записывает отчёт и все его строки одной транзакцией;
+// ReportID в entries заполняется здесь
+func (s *ReconciliationStore) Save(ctx context.Context, report paymentsdb.CreateReconciliationReportParams, entries []paymentsdb.InsertReconciliationEntryParams) (paymentsdb.ReconciliationReport, error) {
+	var saved paymentsdb.ReconciliationReport
+	err := s.withTx(ctx, func(q *paymentsdb.Queries) error {
+		var err error
+		saved, err = q.CreateReconciliationReport(ctx, report)
+		if err != nil {
+			return err
+		}
+
+		for _, entry := range entries {
+			entry.ReportID = saved.ID
+			if err := q.InsertReconciliationEntry(ctx, entry); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return saved, fmt.Errorf("store: save reconciliation report: %w", err)
+	}
+	return saved, nil
+}
+
+func (s *ReconciliationStore) Get(ctx context.Context, id int32) (paymentsdb.ReconciliationReport, error) {
+	return s.queries.GetReconciliationReport(ctx, id)
+}
+
+func (s *ReconciliationStore) Entries(ctx context.Context, id int32) ([]paymentsdb.ReconciliationEntry, error) {
+	entries, err := s.queries.ListReconciliationEntries(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("store: list reconciliation %d entries: %w", id, err)
+	}
+	return entries, nil
+}
+
+// List - последние limit отчётов, новые первыми
+func (s *ReconciliationStore) List(ctx context.Context, limit int32) ([]paymentsdb.ReconciliationReport, error) {
+	reports, err := s.queries.ListReconciliationReports(ctx, limit)
+	if err != nil {
+		return nil, fmt.Errorf("store: list reconciliation reports: %w", err)
+	}
+	return reports, nil
+}
@@ -0,0 +1,106 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	usersdb "db200/internal/db/users"
+)
+
+var ErrRoleNotFound = errors.New("role not found")
+
+// RoleStore - роли, их разрешения и назначения пользователям
+type RoleStore struct {
+	db      *sql.DB
+	queries *usersdb.Queries
+}
+
+func NewRoleStore(db *sql.DB) *RoleStore {
+	return &RoleStore{
+		db:      db,
+		queries: usersdb.New(db),
+	}
+}
+
+func (s *RoleStore) Roles(ctx context.Context) ([]usersdb.Role, error) {
+	roles, err := s.queries.ListRoles(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("store: list roles: %w", err)
+	}
+	return roles, nil
+}
+
+// Permissions - разрешения каждой роли; роль без разрешений в карту не попадает
+func (s *RoleStore) Permissions(ctx context.Context) (map[string][]string, error) {
+	rows, err := s.queries.ListRolePermissions(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("store: list role permissions: %w", err)
+	}
+
+	permissions := make(map[string][]string)
+	for _, row := range rows {
+		permissions[row.Role] = append(permissions[row.Role], row.Permission)
+	}
+	return permissions, nil
+}
+
+// UserRoles - имена ролей пользователя по алфавиту
+func (s *RoleStore) UserRoles(ctx context.Context, userID int32) ([]string, error) {
+	roles, err := s.queries.ListUserRoleNames(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("store: list user %d roles: %w", userID, err)
+	}
+	return roles, nil
+}
+
+// Assign выдаёт роль пользователю; grantedBy 0 - выдано не через API.
+// false - роль у пользователя уже была.
+func (s *RoleStore) Assign(ctx context.Context, userID int32, role string, grantedBy int32) (bool, error) {
+	found, err := s.role(ctx, role)
+	if err != nil {
+		return false, fmt.Errorf("store: assign role: %w", err)
+	}
+
+	count, err := s.queries.AssignUserRole(ctx, usersdb.AssignUserRoleParams{
+		UserID:    userID,
+		RoleID:    found.ID,
+		GrantedBy: sql.NullInt32{Int32: grantedBy, Valid: grantedBy > 0},
+	})
+	if err != nil {
+		if isForeignKeyViolation(err) {
+			return false, fmt.Errorf("store: assign role %q: %w: user %d", role, ErrReferenceNotFound, userID)
+		}
+		return false, fmt.Errorf("store: assign role %q to user %d: %w", role, userID, err)
+	}
+	return count > 0, nil
+}
+
+// Revoke забирает роль у пользователя. false - роли у него не было.
+func (s *RoleStore) Revoke(ctx context.Context, userID int32, role string) (bool, error) {
+	found, err := s.role(ctx, role)
+	if err != nil {
+		return false, fmt.Errorf("store: revoke role: %w", err)
+	}
+
+	count, err := s.queries.RevokeUserRole(ctx, usersdb.RevokeUserRoleParams{
+		UserID: userID,
+		RoleID: found.ID,
+	})
+	if err != nil {
+		return false, fmt.Errorf("store: revoke role %q from user %d: %w", role, userID, err)
+	}
+	return count > 0, nil
+}
+
+func (s *RoleStore) role(ctx context.Context, name string) (usersdb.Role, error) {
+	role, err := s.queries.GetRoleByName(ctx, name)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return role, fmt.Errorf("%w: %q", ErrRoleNotFound, name)
+		}
+		return role, err
+	}
+	return role, nil
+}
@@ -0,0 +1,168 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	usersdb "db200/internal/db/users"
+)
+
+var (
+	// ErrRefreshTokenNotFound - такого токена нет (или его уже удалили как истёкший)
+	ErrRefreshTokenNotFound = errors.New("refresh token not found")
+	// ErrRefreshTokenRevoked - токен отозван выходом или из-за повторного использования
+	ErrRefreshTokenRevoked = errors.New("refresh token revoked")
+	ErrRefreshTokenExpired = errors.New("refresh token expired")
+	// ErrRefreshTokenReused - токен уже обменяли; всё семейство отозвано
+	ErrRefreshTokenReused = errors.New("refresh token reused")
+)
+
+// Причины отзыва refresh-токенов
+const (
+	RevokedLogout    = "logout"
+	RevokedLogoutAll = "logout_all"
+	RevokedReuse     = "reuse"
+	// RevokedPasswordReset - пароль сброшен по письму, старые сессии закрываются
+	RevokedPasswordReset = "password_reset"
+)
+
+// TokenStore - refresh-токены; хранятся только их хэши
+type TokenStore struct {
+	db      *sql.DB
+	queries *usersdb.Queries
+}
+
+func NewTokenStore(db *sql.DB) *TokenStore {
+	return &TokenStore{
+		db:      db,
+		queries: usersdb.New(db),
+	}
+}
+
+func (s *TokenStore) withTx(ctx context.Context, fn func(*usersdb.Queries) error) error {
+	tx, err := s.db.BeginTx(ctx, &sql.TxOptions{
+		Isolation: sql.LevelReadCommitted,
+	})
+	if err != nil {
+		return err
+	}
+
+	defer tx.Rollback()
+	if err = fn(s.queries.WithTx(tx)); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// Create сохраняет первый токен нового семейства (вход)
+func (s *TokenStore) Create(ctx context.Context, token usersdb.InsertRefreshTokenParams) (usersdb.RefreshToken, error) {
+	created, err := s.queries.InsertRefreshToken(ctx, token)
+	if err != nil {
+		return created, fmt.Errorf("store: create refresh token: %w", err)
+	}
+	return created, nil
+}
+
+// Rotate обменивает токен с хэшем hash на next того же семейства.
+// next получает текущий токен и заполняет новый; UserID и FamilyID
+// берутся из текущего. Токен, который уже обменивали, означает утечку:
+// семейство отзывается целиком и возвращается ErrRefreshTokenReused.
+func (s *TokenStore) Rotate(ctx context.Context, hash []byte, now time.Time, next func(usersdb.RefreshToken) (usersdb.InsertRefreshTokenParams, error)) (usersdb.RefreshToken, error) {
+	var (
+		rotated usersdb.RefreshToken
+		reused  bool
+	)
+	err := s.withTx(ctx, func(q *usersdb.Queries) error {
+		current, err := q.GetRefreshTokenForUpdate(ctx, hash)
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return ErrRefreshTokenNotFound
+			}
+			return err
+		}
+		rotated = current
+
+		switch {
+		case current.RevokedAt.Valid:
+			return ErrRefreshTokenRevoked
+		case current.UsedAt.Valid:
+			// Отзыв должен закоммититься, поэтому это не ошибка транзакции
+			reused = true
+			_, err := q.RevokeRefreshTokenFamily(ctx, usersdb.RevokeRefreshTokenFamilyParams{
+				FamilyID:      current.FamilyID,
+				RevokedReason: RevokedReuse,
+			})
+			return err
+		case !now.Before(current.ExpiresAt):
+			return ErrRefreshTokenExpired
+		}
+
+		params, err := next(current)
+		if err != nil {
+			return err
+		}
+		params.UserID = current.UserID
+		params.FamilyID = current.FamilyID
+
+		if err := q.MarkRefreshTokenUsed(ctx, current.ID); err != nil {
+			return err
+		}
+		rotated, err = q.InsertRefreshToken(ctx, params)
+		return err
+	})
+	if err == nil && reused {
+		err = ErrRefreshTokenReused
+	}
+	if err != nil {
+		return rotated, fmt.Errorf("store: rotate refresh token: %w", err)
+	}
+	return rotated, nil
+}
+
+// RevokeFamily отзывает семейство токена с хэшем hash (выход с устройства)
+func (s *TokenStore) RevokeFamily(ctx context.Context, hash []byte, reason string) (usersdb.RefreshToken, error) {
+	var token usersdb.RefreshToken
+	err := s.withTx(ctx, func(q *usersdb.Queries) error {
+		var err error
+		token, err = q.GetRefreshTokenForUpdate(ctx, hash)
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return ErrRefreshTokenNotFound
+			}
+			return err
+		}
+		_, err = q.RevokeRefreshTokenFamily(ctx, usersdb.RevokeRefreshTokenFamilyParams{
+			FamilyID:      token.FamilyID,
+			RevokedReason: reason,
+		})
+		return err
+	})
+	if err != nil {
+		return token, fmt.Errorf("store: revoke refresh token family: %w", err)
+	}
+	return token, nil
+}
+
+// RevokeUser отзывает все действующие токены пользователя, возвращает их количество
+func (s *TokenStore) RevokeUser(ctx context.Context, userID int32, reason string) (int64, error) {
+	count, err := s.queries.RevokeUserRefreshTokens(ctx, usersdb.RevokeUserRefreshTokensParams{
+		UserID:        userID,
+		RevokedReason: reason,
+	})
+	if err != nil {
+		return 0, fmt.Errorf("store: revoke user %d refresh tokens: %w", userID, err)
+	}
+	return count, nil
+}
+
+// DeleteExpired удаляет токены, истёкшие до before
+func (s *TokenStore) DeleteExpired(ctx context.Context, before time.Time) (int64, error) {
+	count, err := s.queries.DeleteExpiredRefreshTokens(ctx, before)
+	if err != nil {
+		return 0, fmt.Errorf("store: delete expired refresh tokens: %w", err)
+	}
+	return count, nil
+}
@@ -0,0 +1,62 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	usersdb "db200/internal/db/users"
+)
+
+// UserStore - учётные записи пользователей
+type UserStore struct {
+	db      *sql.DB
+	queries *usersdb.Queries
+}
+
+func NewUserStore(db *sql.DB) *UserStore {
+	return &UserStore{
+		db:      db,
+		queries: usersdb.New(db),
+	}
+}
+
+// Create сохраняет пользователя с уже посчитанным хэшем пароля
+func (s *UserStore) Create(ctx context.Context, email, name, passwordHash string) (usersdb.User, error) {
+	user, err := s.queries.CreateUser(ctx, usersdb.CreateUserParams{
+		Email:        email,
+		Name:         name,
+		PasswordHash: passwordHash,
+	})
+	if err != nil {
+		if isUniqueViolation(err) {
+			return user, fmt.Errorf("store: create user: %w: %s", ErrEmailTaken, email)
+		}
+		return user, fmt.Errorf("store: create user: %w", err)
+	}
+	return user, nil
+}
+
+func (s *UserStore) Get(ctx context.Context, id int32) (usersdb.User, error) {
+	return s.queries.GetUser(ctx, id)
+}
+
+// GetByEmail ищет без учёта регистра
+func (s *UserStore) GetByEmail(ctx context.Context, email string) (usersdb.User, error) {
+	return s.queries.GetUserByEmail(ctx, email)
+}
+
+// Rehash заменяет хэш пароля, только если он всё ещё oldHash:
+// смена пароля, прошедшая между проверкой и пересчётом, не откатится.
+// false - хэш уже другой.
+func (s *UserStore) Rehash(ctx context.Context, id int32, oldHash, newHash string) (bool, error) {
+	count, err := s.queries.RehashUserPassword(ctx, usersdb.RehashUserPasswordParams{
+		NewHash: newHash,
+		ID:      id,
+		OldHash: oldHash,
+	})
+	if err != nil {
+		return false, fmt.Errorf("store: rehash user %d password: %w", id, err)
+	}
+	return count > 0, nil
+}
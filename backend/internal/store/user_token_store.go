@@ -0,0 +1,227 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	usersdb "db200/internal/db/users"
+)
+
+// Назначения одноразовых токенов из писем
+const (
+	UserTokenEmailVerification = "email_verification"
+	UserTokenPasswordReset     = "password_reset"
+)
+
+var (
+	// ErrUserTokenNotFound - токена нет, он другого назначения или уже удалён как истёкший
+	ErrUserTokenNotFound = errors.New("user token not found")
+	ErrUserTokenUsed     = errors.New("user token already used")
+	ErrUserTokenExpired  = errors.New("user token expired")
+	// ErrUserEmailChanged - email пользователя сменился после отправки письма
+	ErrUserEmailChanged = errors.New("user email changed")
+)
+
+// UserTokenStore - одноразовые токены подтверждения email и сброса пароля.
+// Хранятся только хэши; время created_at, expires_at и used_at - время приложения в UTC.
+type UserTokenStore struct {
+	db      *sql.DB
+	queries *usersdb.Queries
+}
+
+func NewUserTokenStore(db *sql.DB) *UserTokenStore {
+	return &UserTokenStore{
+		db:      db,
+		queries: usersdb.New(db),
+	}
+}
+
+func (s *UserTokenStore) withTx(ctx context.Context, fn func(*usersdb.Queries) error) error {
+	tx, err := s.db.BeginTx(ctx, &sql.TxOptions{
+		Isolation: sql.LevelReadCommitted,
+	})
+	if err != nil {
+		return err
+	}
+
+	defer tx.Rollback()
+	if err = fn(s.queries.WithTx(tx)); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// Create сохраняет новый токен; прежние неиспользованные токены того же
+// назначения гасятся, действует только ссылка из последнего письма
+func (s *UserTokenStore) Create(ctx context.Context, token usersdb.InsertUserTokenParams) (usersdb.UserToken, error) {
+	var created usersdb.UserToken
+	err := s.withTx(ctx, func(q *usersdb.Queries) error {
+		_, err := q.InvalidateUserTokens(ctx, usersdb.InvalidateUserTokensParams{
+			UsedAt:  sql.NullTime{Time: token.CreatedAt, Valid: true},
+			UserID:  token.UserID,
+			Purpose: token.Purpose,
+		})
+		if err != nil {
+			return err
+		}
+		created, err = q.InsertUserToken(ctx, token)
+		return err
+	})
+	if err != nil {
+		return created, fmt.Errorf("store: create %s token for user %d: %w", token.Purpose, token.UserID, err)
+	}
+	return created, nil
+}
+
+// CountSince - сколько токенов назначения purpose выдано пользователю с момента since
+func (s *UserTokenStore) CountSince(ctx context.Context, userID int32, purpose string, since time.Time) (int64, error) {
+	count, err := s.queries.CountUserTokensSince(ctx, usersdb.CountUserTokensSinceParams{
+		UserID:    userID,
+		Purpose:   purpose,
+		CreatedAt: since,
+	})
+	if err != nil {
+		return 0, fmt.Errorf("store: count %s tokens for user %d: %w", purpose, userID, err)
+	}
+	return count, nil
+}
+
+// VerifyEmail гасит токен подтверждения и отмечает email подтверждённым,
+// если пользователь не сменил его после отправки письма
+func (s *UserTokenStore) VerifyEmail(ctx context.Context, hash []byte, now time.Time) (usersdb.UserToken, error) {
+	var token usersdb.UserToken
+	err := s.withTx(ctx, func(q *usersdb.Queries) error {
+		var err error
+		token, err = consumeUserToken(ctx, q, hash, UserTokenEmailVerification, now)
+		if err != nil {
+			return err
+		}
+
+		count, err := q.MarkUserEmailVerified(ctx, usersdb.MarkUserEmailVerifiedParams{
+			ID:    token.UserID,
+			Email: token.Email,
+		})
+		if err != nil {
+			return err
+		}
+		if count == 0 {
+			return ErrUserEmailChanged
+		}
+		return nil
+	})
+	if err != nil {
+		return token, fmt.Errorf("store: verify email: %w", err)
+	}
+	return token, nil
+}
+
+// CheckPasswordReset проверяет токен сброса, не гася его: ошибки те же, что
+// у ResetPassword. Нужна, чтобы не считать дорогой хэш пароля по чужому токену.
+func (s *UserTokenStore) CheckPasswordReset(ctx context.Context, hash []byte, now time.Time) (usersdb.UserToken, error) {
+	token, err := s.queries.GetUserToken(ctx, usersdb.GetUserTokenParams{
+		TokenHash: hash,
+		Purpose:   UserTokenPasswordReset,
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			err = ErrUserTokenNotFound
+		}
+		return token, fmt.Errorf("store: check password reset: %w", err)
+	}
+	if err := checkUserToken(token, now); err != nil {
+		return token, fmt.Errorf("store: check password reset: %w", err)
+	}
+	return token, nil
+}
+
+// ResetPassword гасит токен сброса, ставит новый хэш пароля и отзывает все
+// refresh-токены пользователя. Письмо дошло, значит email тоже подтверждён.
+func (s *UserTokenStore) ResetPassword(ctx context.Context, hash []byte, now time.Time, passwordHash string) (usersdb.UserToken, error) {
+	var token usersdb.UserToken
+	err := s.withTx(ctx, func(q *usersdb.Queries) error {
+		var err error
+		token, err = consumeUserToken(ctx, q, hash, UserTokenPasswordReset, now)
+		if err != nil {
+			return err
+		}
+
+		err = q.SetUserPassword(ctx, usersdb.SetUserPasswordParams{
+			ID:           token.UserID,
+			PasswordHash: passwordHash,
+		})
+		if err != nil {
+			return err
+		}
+		_, err = q.InvalidateUserTokens(ctx, usersdb.InvalidateUserTokensParams{
+			UsedAt:  sql.NullTime{Time: now, Valid: true},
+			UserID:  token.UserID,
+			Purpose: UserTokenPasswordReset,
+		})
+		if err != nil {
+			return err
+		}
+		_, err = q.RevokeUserRefreshTokens(ctx, usersdb.RevokeUserRefreshTokensParams{
+			UserID:        token.UserID,
+			RevokedReason: RevokedPasswordReset,
+		})
+		if err != nil {
+			return err
+		}
+		_, err = q.MarkUserEmailVerified(ctx, usersdb.MarkUserEmailVerifiedParams{
+			ID:    token.UserID,
+			Email: token.Email,
+		})
+		return err
+	})
+	if err != nil {
+		return token, fmt.Errorf("store: reset password: %w", err)
+	}
+	return token, nil
+}
+
+// DeleteExpired удаляет токены, истёкшие до before
+func (s *UserTokenStore) DeleteExpired(ctx context.Context, before time.Time) (int64, error) {
+	count, err := s.queries.DeleteExpiredUserTokens(ctx, before)
+	if err != nil {
+		return 0, fmt.Errorf("store: delete expired user tokens: %w", err)
+	}
+	return count, nil
+}
+
+// consumeUserToken блокирует токен и отмечает использованным, если он ещё действует
+func consumeUserToken(ctx context.Context, q *usersdb.Queries, hash []byte, purpose string, now time.Time) (usersdb.UserToken, error) {
+	token, err := q.GetUserTokenForUpdate(ctx, usersdb.GetUserTokenForUpdateParams{
+		TokenHash: hash,
+		Purpose:   purpose,
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return token, ErrUserTokenNotFound
+		}
+		return token, err
+	}
+
+	if err := checkUserToken(token, now); err != nil {
+		return token, err
+	}
+
+	err = q.UseUserToken(ctx, usersdb.UseUserTokenParams{
+		UsedAt: sql.NullTime{Time: now, Valid: true},
+		ID:     token.ID,
+	})
+	return token, err
+}
+
+// checkUserToken - токен ещё не использован и не истёк
+func checkUserToken(token usersdb.UserToken, now time.Time) error {
+	switch {
+	case token.UsedAt.Valid:
+		return ErrUserTokenUsed
+	case !now.Before(token.ExpiresAt):
+		return ErrUserTokenExpired
+	}
+	return nil
+}
@@ -0,0 +1,131 @@
+// Package token подписывает и проверяет JWT набором HMAC-ключей.
+//
+// Каждый ключ имеет id, он пишется в заголовок токена (kid). Подписывает
+// активный ключ, проверяет тот, чей id указан в токене, поэтому для смены ключа
+// новый делают активным, а старый оставляют в наборе, пока не истекут
+// выданные им токены.
+package token
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// MinKeyLength - минимальная длина секрета в байтах (256 бит для HS256)
+const MinKeyLength = 32
+
+var (
+	ErrUnknownKey = errors.New("unknown signing key")
+	ErrInvalidKey = errors.New("invalid signing key")
+)
+
+// Keyring - ключи подписи по id и id активного ключа
+type Keyring struct {
+	activeID string
+	keys     map[string][]byte
+}
+
+// NewKeyring проверяет ключи; activeID должен быть среди них
+func NewKeyring(activeID string, keys map[string][]byte) (*Keyring, error) {
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("%w: no keys", ErrInvalidKey)
+	}
+	for id, secret := range keys {
+		if id == "" {
+			return nil, fmt.Errorf("%w: empty key id", ErrInvalidKey)
+		}
+		if len(secret) < MinKeyLength {
+			return nil, fmt.Errorf("%w: key %q is shorter than %d bytes", ErrInvalidKey, id, MinKeyLength)
+		}
+	}
+	if _, ok := keys[activeID]; !ok {
+		return nil, fmt.Errorf("%w: active key %q", ErrUnknownKey, activeID)
+	}
+
+	return &Keyring{
+		activeID: activeID,
+		keys:     keys,
+	}, nil
+}
+
+// ParseKeyring разбирает набор вида "2026-02:<base64>,2026-01:<base64>".
+// Пустой activeID - активен первый ключ в списке.
+func ParseKeyring(spec, activeID string) (*Keyring, error) {
+	keys := make(map[string][]byte)
+	first := ""
+	for _, item := range strings.Split(spec, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		id, encoded, ok := strings.Cut(item, ":")
+		id = strings.TrimSpace(id)
+		if !ok || id == "" {
+			return nil, fmt.Errorf("%w: expected <kid>:<base64 secret>", ErrInvalidKey)
+		}
+		if _, dup := keys[id]; dup {
+			return nil, fmt.Errorf("%w: duplicate key id %q", ErrInvalidKey, id)
+		}
+		secret, err := base64.StdEncoding.DecodeString(strings.TrimSpace(encoded))
+		if err != nil {
+			return nil, fmt.Errorf("%w: key %q is not valid base64", ErrInvalidKey, id)
+		}
+		keys[id] = secret
+		if first == "" {
+			first = id
+		}
+	}
+
+	if activeID == "" {
+		activeID = first
+	}
+	return NewKeyring(activeID, keys)
+}
+
+// NewEphemeralKeyring - один случайный ключ; токены не переживут перезапуск
+func NewEphemeralKeyring() (*Keyring, error) {
+	secret := make([]byte, MinKeyLength)
+	if _, err := rand.Read(secret); err != nil {
+		return nil, err
+	}
+	return NewKeyring("ephemeral", map[string][]byte{"ephemeral": secret})
+}
+
+// ActiveID - id ключа, которым подписываются новые токены
+func (k *Keyring) ActiveID() string {
+	return k.activeID
+}
+
+// Sign подписывает claims активным ключом (HS256) и пишет его id в kid
+func (k *Keyring) Sign(claims jwt.Claims) (string, error) {
+	t := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	t.Header["kid"] = k.activeID
+	return t.SignedString(k.keys[k.activeID])
+}
+
+// Keyfunc для jwt.Parse: ключ по kid из заголовка, только HS256
+func (k *Keyring) Keyfunc(t *jwt.Token) (interface{}, error) {
+	if t.Method != jwt.SigningMethodHS256 {
+		return nil, fmt.Errorf("unexpected signing method %v", t.Header["alg"])
+	}
+	id, _ := t.Header["kid"].(string)
+	secret, ok := k.keys[id]
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", ErrUnknownKey, id)
+	}
+	return secret, nil
+}
+
+// AccessClaims - содержимое токена доступа; sub - id пользователя
+type AccessClaims struct {
+	jwt.RegisteredClaims
+	// SessionID - семейство refresh-токенов, при входе или обновлении в котором выдан токен
+	SessionID string `json:"sid,omitempty"`
+	// Roles - роли пользователя на момент выдачи токена
+	Roles []string `json:"roles,omitempty"`
+}
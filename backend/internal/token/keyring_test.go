@@ -0,0 +1,158 @@
+package token
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+var (
+	oldSecret = bytes.Repeat([]byte{1}, MinKeyLength)
+	newSecret = bytes.Repeat([]byte{2}, MinKeyLength)
+)
+
+func encode(secret []byte) string {
+	return base64.StdEncoding.EncodeToString(secret)
+}
+
+func TestParseKeyring(t *testing.T) {
+	spec := " 2026-02 : " + encode(newSecret) + " ,, 2026-01:" + encode(oldSecret)
+	keys, err := ParseKeyring(spec, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if keys.ActiveID() != "2026-02" {
+		t.Errorf("ActiveID = %q, want the first key", keys.ActiveID())
+	}
+	if keys, err := ParseKeyring(spec, "2026-01"); err != nil || keys.ActiveID() != "2026-01" {
+		t.Errorf("ParseKeyring with active 2026-01: %v", err)
+	}
+	if _, err := ParseKeyring(spec, "2025-12"); !errors.Is(err, ErrUnknownKey) {
+		t.Errorf("unknown active key: error = %v, want %v", err, ErrUnknownKey)
+	}
+
+	for _, bad := range []string{
+		"",
+		encode(newSecret),
+		":" + encode(newSecret),
+		"a:" + encode(newSecret) + ",a:" + encode(oldSecret),
+		"a:not base64!",
+		"a:" + encode(newSecret[:MinKeyLength-1]),
+	} {
+		if _, err := ParseKeyring(bad, ""); !errors.Is(err, ErrInvalidKey) {
+			t.Errorf("ParseKeyring(%q) error = %v, want %v", bad, err, ErrInvalidKey)
+		}
+	}
+}
+
+// Смена ключа: токен старого ключа проверяется, пока ключ в наборе,
+// новые токены подписываются новым ключом
+func TestKeyringRotation(t *testing.T) {
+	before, err := NewKeyring("old", map[string][]byte{"old": oldSecret})
+	if err != nil {
+		t.Fatal(err)
+	}
+	rotated, err := NewKeyring("new", map[string][]byte{"new": newSecret, "old": oldSecret})
+	if err != nil {
+		t.Fatal(err)
+	}
+	retired, err := NewKeyring("new", map[string][]byte{"new": newSecret})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	oldToken := sign(t, before)
+	newToken := sign(t, rotated)
+	if kid := header(t, newToken, "kid"); kid != "new" {
+		t.Fatalf("token signed after rotation has kid %v, want new", kid)
+	}
+
+	tests := []struct {
+		name  string
+		keys  *Keyring
+		token string
+		err   error
+	}{
+		{name: "old token before rotation", keys: before, token: oldToken},
+		{name: "old token after rotation", keys: rotated, token: oldToken},
+		{name: "new token after rotation", keys: rotated, token: newToken},
+		{name: "new token on old keyring", keys: before, token: newToken, err: ErrUnknownKey},
+		{name: "old token after old key removed", keys: retired, token: oldToken, err: ErrUnknownKey},
+		{name: "new token after old key removed", keys: retired, token: newToken},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := jwt.Parse(tt.token, tt.keys.Keyfunc)
+			if tt.err == nil && err != nil {
+				t.Fatalf("Parse: %v", err)
+			}
+			if tt.err != nil && !errors.Is(err, tt.err) {
+				t.Fatalf("Parse error = %v, want %v", err, tt.err)
+			}
+		})
+	}
+}
+
+func TestKeyfuncRejectsForgedTokens(t *testing.T) {
+	keys, err := NewKeyring("k1", map[string][]byte{"k1": newSecret})
+	if err != nil {
+		t.Fatal(err)
+	}
+	claims := jwt.RegisteredClaims{ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Minute))}
+
+	// Ключ известен, но подпись чужим секретом
+	forged := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	forged.Header["kid"] = "k1"
+	forgedToken, err := forged.SignedString(oldSecret)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := jwt.Parse(forgedToken, keys.Keyfunc); !errors.Is(err, jwt.ErrSignatureInvalid) {
+		t.Errorf("token signed with other secret: error = %v, want %v", err, jwt.ErrSignatureInvalid)
+	}
+
+	// Без kid ключ не выбирается, даже если он в наборе один
+	noKid, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(newSecret)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := jwt.Parse(noKid, keys.Keyfunc); !errors.Is(err, ErrUnknownKey) {
+		t.Errorf("token without kid: error = %v, want %v", err, ErrUnknownKey)
+	}
+
+	// Другой алгоритм с тем же секретом
+	hs512 := jwt.NewWithClaims(jwt.SigningMethodHS512, claims)
+	hs512.Header["kid"] = "k1"
+	hs512Token, err := hs512.SignedString(newSecret)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := jwt.Parse(hs512Token, keys.Keyfunc); err == nil {
+		t.Error("HS512 token accepted, want only HS256")
+	}
+}
+
+func sign(t *testing.T, keys *Keyring) string {
+	t.Helper()
+	signed, err := keys.Sign(jwt.RegisteredClaims{
+		Subject:   "1",
+		ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Minute)),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return signed
+}
+
+func header(t *testing.T, signed, name string) any {
+	t.Helper()
+	parsed, _, err := jwt.NewParser().ParseUnverified(signed, &jwt.RegisteredClaims{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return parsed.Header[name]
+}
@@ -0,0 +1,83 @@
+// Package webhook подписывает и проверяет тела вебхуков провайдера платежей.
+//
+// Заголовок подписи: "Webhook-Signature: t=<unix-время>,v1=<hex HMAC-SHA256>",
+// HMAC считается от строки "<t>.<тело>". Подписей v1 может быть несколько
+// (на время смены секрета) - достаточно совпадения одной.
+package webhook
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// SignatureHeader - заголовок с подписью запроса
+const SignatureHeader = "Webhook-Signature"
+
+// DefaultTolerance - насколько время подписи может расходиться с нашим
+const DefaultTolerance = 5 * time.Minute
+
+const signatureScheme = "v1"
+
+var (
+	ErrMissingSignature = errors.New("webhook signature missing or malformed")
+	ErrInvalidSignature = errors.New("webhook signature mismatch")
+	// ErrTimestampOutOfTolerance - подпись слишком старая или из будущего; защищает от повтора перехваченного запроса
+	ErrTimestampOutOfTolerance = errors.New("webhook timestamp outside tolerance")
+)
+
+// Sign возвращает значение заголовка SignatureHeader для тела body
+func Sign(secret []byte, timestamp time.Time, body []byte) string {
+	t := strconv.FormatInt(timestamp.Unix(), 10)
+	return "t=" + t + "," + signatureScheme + "=" + signature(secret, t, body)
+}
+
+// Verify проверяет заголовок header для тела body: подпись секретом secret
+// и расхождение времени подписи с now не больше tolerance
+func Verify(secret []byte, header string, body []byte, now time.Time, tolerance time.Duration) error {
+	var t string
+	var signatures []string
+	for _, part := range strings.Split(header, ",") {
+		key, value, ok := strings.Cut(strings.TrimSpace(part), "=")
+		if !ok {
+			continue
+		}
+		switch key {
+		case "t":
+			t = value
+		case signatureScheme:
+			signatures = append(signatures, value)
+		}
+	}
+	if t == "" || len(signatures) == 0 {
+		return ErrMissingSignature
+	}
+
+	unix, err := strconv.ParseInt(t, 10, 64)
+	if err != nil {
+		return ErrMissingSignature
+	}
+	if skew := now.Sub(time.Unix(unix, 0)); skew > tolerance || skew < -tolerance {
+		return ErrTimestampOutOfTolerance
+	}
+
+	expected := []byte(signature(secret, t, body))
+	for _, s := range signatures {
+		if hmac.Equal(expected, []byte(s)) {
+			return nil
+		}
+	}
+	return ErrInvalidSignature
+}
+
+func signature(secret []byte, t string, body []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(t))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,56 @@
+package webhook
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+var (
+	testSecret = []byte("whsec_test")
+	testBody   = []byte(`{"id":"evt_1"}`)
+	signedAt   = time.Unix(1700000000, 0)
+)
+
+// Подпись посчитана независимо: HMAC-SHA256("whsec_test", `1700000000.{"id":"evt_1"}`)
+const knownSignature = "c89214b5b5da833daed6f0b8c5bb6bd58cea9022bd80ccc78230f3942d632925"
+
+func TestSign(t *testing.T) {
+	want := "t=1700000000,v1=" + knownSignature
+	if got := Sign(testSecret, signedAt, testBody); got != want {
+		t.Errorf("Sign = %q, want %q", got, want)
+	}
+}
+
+func TestVerify(t *testing.T) {
+	valid := Sign(testSecret, signedAt, testBody)
+	rotated := Sign([]byte("whsec_old"), signedAt, testBody) + ",v1=" + knownSignature
+
+	tests := []struct {
+		name   string
+		header string
+		body   string
+		now    time.Time
+		err    error
+	}{
+		{name: "valid", header: valid, now: signedAt},
+		{name: "one of several signatures", header: rotated, now: signedAt},
+		{name: "late within tolerance", header: valid, now: signedAt.Add(DefaultTolerance)},
+		{name: "too old", header: valid, now: signedAt.Add(DefaultTolerance + time.Second), err: ErrTimestampOutOfTolerance},
+		{name: "from the future", header: valid, now: signedAt.Add(-DefaultTolerance - time.Second), err: ErrTimestampOutOfTolerance},
+		{name: "tampered body", header: valid, body: `{"id":"evt_2"}`, now: signedAt, err: ErrInvalidSignature},
+		{name: "tampered timestamp", header: "t=1700000001,v1=" + knownSignature, now: signedAt.Add(time.Second), err: ErrInvalidSignature},
+		{name: "empty header", header: "", now: signedAt, err: ErrMissingSignature},
+		{name: "no signature", header: "t=1700000000", now: signedAt, err: ErrMissingSignature},
+		{name: "bad timestamp", header: "t=yesterday,v1=" + knownSignature, now: signedAt, err: ErrMissingSignature},
+	}
+	for _, tt := range tests {
+		body := testBody
+		if tt.body != "" {
+			body = []byte(tt.body)
+		}
+		if err := Verify(testSecret, tt.header, body, tt.now, DefaultTolerance); !errors.Is(err, tt.err) {
+			t.Errorf("%s: Verify error = %v, want %v", tt.name, err, tt.err)
+		}
+	}
+}
@@ -1,11 +1,14 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"os"
+	"strconv"
 	"time"
 
+	jwtware "github.com/gofiber/contrib/jwt"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
 	"gorm.io/gorm/logger"
@@ -13,6 +16,14 @@ import (
 	_ "github.com/lib/pq" // драйвер PostgreSQL
 	"github.com/sirupsen/logrus"
 
+	"db200/handlers"
+	"db200/internal/mail"
+	"db200/internal/password"
+	"db200/internal/store"
+	"db200/internal/token"
+	"db200/internal/webhook"
+	"db200/service"
+
 	_ "github.com/golang-migrate/migrate/v4/source/file"
 )
 
@@ -73,51 +84,6 @@ type (
 
 )
 
-// ****************************
-// Users
-
-	type User struct {
-		Email    string
-		Name     string
-		password string
-	}
-
-type (
-
-	UserCreateRequest struct {
-		Email    string `json:"email" validate:"required,email"`
-		Name     string `json:"name" validate:"required,min=3,max=50"`
-		Password string `json:"password" validate:"required,min=8,max=16"`
-	}
-
-	UserCreateReqsponse struct {
-		Email string `json:"email" `
-		Name  string `json:"name" `
-	}
-
-	LoginRequest struct {
-		Email    string `json:"email" vaidate:"required,email"`
-		Password string `json:"password" validate:"required,min=8,max=16"`
-	}
-
-	LoginResponse struct {
-		AccessToken string `json:"access_token"`
-	}
-
-)
-
-var users = map[string]User{}
-
-var (
-
-	errBadCredentials = errors.New("email or password is incorrect")
-
-)
-
-var jwtSignature = []byte("supet-secret-signature-2400")
-
-var contextKeyUser = "user"
-
 // Структура с информацией о фильме
 
 	type Film struct {
@@ -163,13 +129,6 @@ var (
 )
 */
 
-type User struct {
-	Email    string
-	Name     string
-	Password string
-	Types    string
-}
-
 func main() {
 	/*
 		file, err := os.OpenFile(".log", os.O_RDWR|os.O_CREATE|os.O_APPEND, 0666)
@@ -236,10 +195,247 @@ func main() {
 	// Если err == nil, соединение успешно установлено
 	logrus.Println("Соединение с базой установлено")
 
-	if err := db.AutoMigrate(&User{}); err != nil {
-		log.Fatalf("ошибка миграции схемы: %v", err)
+	// sqlc-слой работает через *sql.DB, берём его из GORM
+	sqlDB, err := db.DB()
+	if err != nil {
+		log.Fatalf("ошибка получения *sql.DB: %v", err)
 	}
 
+	// Карточка продукта читается на каждый показ, Get идёт через кэш
+	productStore := store.NewCachedProductStore(
+		store.NewProductStore(sqlDB),
+		store.NewLRUProductCache(envInt("PRODUCTS_CACHE_SIZE", 10000)),
+		envDuration("PRODUCTS_CACHE_TTL", 5*time.Minute),
+		envDuration("PRODUCTS_CACHE_NEGATIVE_TTL", 30*time.Second),
+	)
+	productService := service.NewProductService(productStore)
+	productHandler := handlers.NewProductHandler(productService)
+
+	categoryStore := store.NewCategoryStore(sqlDB)
+	categoryService := service.NewCategoryService(categoryStore)
+	categoryHandler := handlers.NewCategoryHandler(categoryService)
+
+	inventoryStore := store.NewInventoryStore(sqlDB)
+	inventoryService := service.NewInventoryService(inventoryStore)
+	inventoryHandler := handlers.NewInventoryHandler(inventoryService)
+
+	paymentStore := store.NewPaymentStore(sqlDB)
+	paymentService := service.NewPaymentService(paymentStore)
+	paymentHandler := handlers.NewPaymentHandler(paymentService)
+
+	invoiceService := service.NewInvoiceService(store.NewInvoiceStore(sqlDB), productService)
+	invoiceHandler := handlers.NewInvoiceHandler(invoiceService)
+
+	reconciliationService := service.NewReconciliationService(store.NewReconciliationStore(sqlDB))
+	reconciliationHandler := handlers.NewReconciliationHandler(reconciliationService)
+
+	// События провайдера подписываются общим секретом; без секрета приём выключен
+	webhookSecret := os.Getenv("PAYMENT_WEBHOOK_SECRET")
+	paymentWebhookHandler := handlers.NewPaymentWebhookHandler(
+		paymentService,
+		[]byte(webhookSecret),
+		envDuration("PAYMENT_WEBHOOK_TOLERANCE", webhook.DefaultTolerance),
+	)
+
+	// Параметры argon2id для новых хэшей; старые пересчитываются при входе
+	passwordParams := password.DefaultParams
+	passwordParams.Memory = uint32(envInt("PASSWORD_ARGON2_MEMORY_KIB", int(passwordParams.Memory)))
+	passwordParams.Iterations = uint32(envInt("PASSWORD_ARGON2_ITERATIONS", int(passwordParams.Iterations)))
+	passwordParams.Parallelism = uint8(envInt("PASSWORD_ARGON2_PARALLELISM", int(passwordParams.Parallelism)))
+	if err := passwordParams.Validate(); err != nil {
+		log.Fatalf("параметры хэширования паролей: %v", err)
+	}
+	userStore := store.NewUserStore(sqlDB)
+	authService := service.NewAuthService(userStore, passwordParams)
+	roleStore := store.NewRoleStore(sqlDB)
+	roleService := service.NewRoleService(roleStore, userStore, envDuration("RBAC_CACHE_TTL", time.Minute))
+
+	// Подкоманды CLI: go run . import-products -file products.csv.
+	// Разбираются до ключей JWT и почты - командам они не нужны
+	if len(os.Args) > 1 {
+		if err := runCommand(context.Background(), os.Args[1], os.Args[2:], commandDeps{
+			products:        productService,
+			reconciliations: reconciliationService,
+			users:           authService,
+			roles:           roleService,
+		}); err != nil {
+			log.Fatalf("%s: %v", os.Args[1], err)
+		}
+		return
+	}
+
+	// Ключи подписи JWT: JWT_SIGNING_KEYS="2026-02:<base64>,2026-01:<base64>".
+	// Для смены ключа новый добавляют и делают активным (JWT_SIGNING_KEY_ID),
+	// старый убирают, когда истекут подписанные им токены доступа.
+	var signingKeys *token.Keyring
+	if spec := os.Getenv("JWT_SIGNING_KEYS"); spec != "" {
+		signingKeys, err = token.ParseKeyring(spec, os.Getenv("JWT_SIGNING_KEY_ID"))
+	} else {
+		logrus.Warn("JWT_SIGNING_KEYS не задан, токены подписываются случайным ключом и не переживут перезапуск")
+		signingKeys, err = token.NewEphemeralKeyring()
+	}
+	if err != nil {
+		log.Fatalf("ключи подписи JWT: %v", err)
+	}
+	tokenService := service.NewTokenService(
+		store.NewTokenStore(sqlDB),
+		userStore,
+		roleStore,
+		signingKeys,
+		envDuration("JWT_ACCESS_TTL", service.DefaultAccessTokenTTL),
+		envDuration("JWT_REFRESH_TTL", service.DefaultRefreshTokenTTL),
+	)
+
+	// Письма: MAILER=smtp (SMTP_ADDR, SMTP_USERNAME, SMTP_PASSWORD) или file
+	// (MAIL_FILE, по умолчанию "-" - stdout). file пишет ссылки для входа
+	// и сброса пароля открытым текстом, поэтому включается только явно - для разработки
+	mailFrom := envString("MAIL_FROM", "no-reply@localhost")
+	var mailer mail.Mailer
+	switch mailerKind := os.Getenv("MAILER"); mailerKind {
+	case "smtp":
+		mailer, err = mail.NewSMTPMailer(os.Getenv("SMTP_ADDR"), mailFrom, os.Getenv("SMTP_USERNAME"), os.Getenv("SMTP_PASSWORD"))
+		if err != nil {
+			log.Fatalf("почта: %v", err)
+		}
+	case "file":
+		mailFile := envString("MAIL_FILE", "-")
+		logrus.WithField("path", mailFile).Warn("MAILER=file: письма со ссылками пишутся открытым текстом, только для разработки")
+		mailer = mail.NewFileMailer(mailFile, mailFrom)
+	case "":
+		log.Fatal("почта: не задан MAILER: smtp для работы или file для разработки")
+	default:
+		log.Fatalf("почта: неизвестный MAILER=%q, ожидается smtp или file", mailerKind)
+	}
+	accountService := service.NewAccountService(
+		store.NewUserTokenStore(sqlDB),
+		userStore,
+		mailer,
+		passwordParams,
+		envString("APP_BASE_URL", "http://localhost:8100"),
+		envDuration("EMAIL_VERIFICATION_TTL", service.DefaultEmailVerificationTTL),
+		envDuration("PASSWORD_RESET_TTL", service.DefaultPasswordResetTTL),
+	)
+
+	// Защита от подбора паролей: пауза после каждой неудачи и блокировка после
+	// LOGIN_MAX_FAILURES неудач по аккаунту (LOGIN_IP_MAX_FAILURES - по IP)
+	accountThrottle := service.DefaultAccountThrottle
+	accountThrottle.MaxFailures = envInt("LOGIN_MAX_FAILURES", accountThrottle.MaxFailures)
+	accountThrottle.Lockout = envDuration("LOGIN_LOCKOUT", accountThrottle.Lockout)
+	accountThrottle.BackoffBase = envDuration("LOGIN_BACKOFF_BASE", accountThrottle.BackoffBase)
+	accountThrottle.BackoffMax = envDuration("LOGIN_BACKOFF_MAX", accountThrottle.BackoffMax)
+	accountThrottle.Window = envDuration("LOGIN_FAILURE_WINDOW", accountThrottle.Window)
+	ipThrottle := service.DefaultIPThrottle
+	ipThrottle.MaxFailures = envInt("LOGIN_IP_MAX_FAILURES", ipThrottle.MaxFailures)
+	ipThrottle.Lockout = envDuration("LOGIN_IP_LOCKOUT", ipThrottle.Lockout)
+	ipThrottle.Window = envDuration("LOGIN_FAILURE_WINDOW", ipThrottle.Window)
+	for _, policy := range []service.ThrottlePolicy{accountThrottle, ipThrottle} {
+		if err := policy.Validate(); err != nil {
+			log.Fatalf("ограничение попыток входа: %v", err)
+		}
+	}
+	throttleService := service.NewLoginThrottleService(
+		store.NewLoginThrottleStore(sqlDB),
+		userStore,
+		accountThrottle,
+		ipThrottle,
+	)
+	authHandler := handlers.NewAuthHandler(authService, tokenService, accountService, throttleService)
+	securityHandler := handlers.NewSecurityHandler(throttleService)
+	authorized := jwtware.New(jwtware.Config{
+		KeyFunc:      tokenService.Keyfunc,
+		Claims:       &token.AccessClaims{},
+		ContextKey:   handlers.UserContextKey,
+		ErrorHandler: handlers.TokenError,
+	})
+	roleHandler := handlers.NewRoleHandler(roleService)
+
+	idempotencyService := service.NewIdempotencyService(
+		store.NewIdempotencyStore(sqlDB),
+		envDuration("IDEMPOTENCY_LOCK_TIMEOUT", service.DefaultIdempotencyLockTimeout),
+	)
+	idempotent := handlers.Idempotency(idempotencyService)
+
+	webApp := newWebApp()
+
+	registerRoutes(webApp, routeHandlers{
+		authorized:      authorized,
+		idempotent:      idempotent,
+		auth:            authHandler,
+		roles:           roleHandler,
+		security:        securityHandler,
+		products:        productHandler,
+		categories:      categoryHandler,
+		inventory:       inventoryHandler,
+		invoices:        invoiceHandler,
+		payments:        paymentHandler,
+		paymentWebhooks: paymentWebhookHandler,
+		reconciliations: reconciliationHandler,
+		cacheStats:      handlers.CacheStats(productStore.Stats),
+		webhooksEnabled: webhookSecret != "",
+	})
+
+	// Окончательное удаление продуктов из корзины
+	purgeRetention := envDuration("PRODUCTS_PURGE_RETENTION", 30*24*time.Hour)
+	purgeInterval := envDuration("PRODUCTS_PURGE_INTERVAL", time.Hour)
+	go productService.RunPurgeJob(context.Background(), purgeInterval, purgeRetention)
+
+	// Возврат просроченных резервов в доступный остаток
+	reservationsInterval := envDuration("RESERVATIONS_EXPIRY_INTERVAL", time.Minute)
+	go inventoryService.RunExpiryJob(context.Background(), reservationsInterval)
+
+	// Ключи идемпотентности нужны только на время повторов клиента
+	idempotencyTTL := envDuration("IDEMPOTENCY_KEY_TTL", 24*time.Hour)
+	go idempotencyService.RunExpiryJob(context.Background(), time.Hour, idempotencyTTL)
+
+	// Истёкшие refresh-токены больше не нужны даже для обнаружения повторов
+	go tokenService.RunExpiryJob(context.Background(), time.Hour)
+	go accountService.RunExpiryJob(context.Background(), time.Hour)
+	go throttleService.RunExpiryJob(context.Background(), time.Hour)
+
+	port := "8100"
+	if p := os.Getenv("PORT"); p != "" {
+		port = p
+	}
+
+	logrus.Fatal(webApp.Listen(":" + port))
+}
+
+// envString - значение переменной окружения или def, если она не задана
+func envString(key, def string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return def
+}
+
+// envDuration читает длительность из переменной окружения ("720h", "15m")
+func envDuration(key string, def time.Duration) time.Duration {
+	value := os.Getenv(key)
+	if value == "" {
+		return def
+	}
+
+	d, err := time.ParseDuration(value)
+	if err != nil || d <= 0 {
+		logrus.Warnf("некорректное значение %s=%q, используем %s", key, value, def)
+		return def
+	}
+	return d
+}
+
+// envInt читает положительное целое из переменной окружения
+func envInt(key string, def int) int {
+	value := os.Getenv(key)
+	if value == "" {
+		return def
+	}
+
+	n, err := strconv.Atoi(value)
+	if err != nil || n <= 0 {
+		logrus.Warnf("некорректное значение %s=%q, используем %d", key, value, def)
+		return def
+	}
+	return n
 }
 
 /*
@@ -264,18 +460,6 @@ func main() {
 			validator: validate,
 		}
 
-	authHandler := &AuthHandler{
-		storage: &AuthStorage{
-			users: users,
-		},
-		validator: validate,
-	}
-	userHandler := &UserHandler{
-		storage: &AuthStorage{
-			users: users,
-		},
-	}
-
 	viewsEngine := html.New("./template", ".tmpl")
 
 	webApp := fiber.New(fiber.Config{
@@ -283,8 +467,6 @@ func main() {
 	})
 
 	publicGroup := webApp.Group("")
-	publicGroup.Post("/register", authHandler.CreateUser)
-	publicGroup.Post("/login", authHandler.Login)
 	publicGroup.Get("profile1", func(c *fiber.Ctx) error {
 
 		return c.Render("profile", fiber.Map{
@@ -322,16 +504,6 @@ func main() {
 		return c.Render("items", items)
 	})
 
-	authorizedGroup := webApp.Group("")
-	authorizedGroup.Use(jwtware.New(jwtware.Config{
-		SigningKey: jwtware.SigningKey{
-			Key: jwtSignature,
-		},
-		ContextKey: contextKeyUser,
-	}))
-
-	authorizedGroup.Get("/profile", userHandler.Profile)
-
 	/*
 		webApp.Use(limiter.New(limiter.Config{
 			KeyGenerator: func(c *fiber.Ctx) string {
@@ -359,139 +531,6 @@ func main() {
 
 }
 
-type AuthHandler struct {
-	storage   *AuthStorage
-	validator *validator.Validate
-}
-
-type UserHandler struct {
-	storage *AuthStorage
-}
-
-type AuthStorage struct {
-	users map[string]User
-}
-
-func (h *AuthHandler) CreateUser(c *fiber.Ctx) error {
-	var request UserCreateRequest
-	err := c.BodyParser(&request)
-	if err != nil {
-		return c.Status(fiber.StatusBadRequest).SendString(fmt.Sprintf("Error: %w", err))
-	}
-
-	err = h.validator.Struct(request)
-	if err != nil {
-		return c.Status(fiber.StatusUnprocessableEntity).JSON(fiber.Map{
-			"error:":   "bad validateion",
-			"details:": err.Error(),
-		})
-	}
-
-	_, exists := h.storage.users[request.Email]
-	if exists {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error:": "user is already registered",
-		})
-	}
-
-	h.storage.users[request.Email] = User{
-		Email:    request.Email,
-		Name:     request.Name,
-		password: request.Password,
-	}
-
-	return c.Status(fiber.StatusOK).JSON(UserCreateReqsponse{
-		Name:  request.Name,
-		Email: request.Email,
-	})
-
-}
-
-func (h *AuthHandler) Login(c *fiber.Ctx) error {
-	var request LoginRequest
-	err := c.BodyParser(&request)
-	if err != nil {
-		return c.SendStatus(fiber.StatusBadRequest)
-	}
-
-	err = h.validator.Struct(request)
-	if err != nil {
-		return c.Status(fiber.StatusUnprocessableEntity).JSON(fiber.Map{
-			"message:": "Error",
-			"details":  err.Error(),
-		})
-	}
-
-	user, exists := h.storage.users[request.Email]
-	if !exists {
-		return errBadCredentials
-	}
-
-	if user.password != request.Password {
-		return errBadCredentials
-	}
-
-	payload := jwt.MapClaims{
-		"sub": user.Email,
-		"exp": time.Now().Add(time.Hour * 72).Unix(),
-	}
-
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, payload)
-	t, err := token.SignedString(jwtSignature)
-	if err != nil {
-		return c.SendStatus(fiber.StatusOK)
-	}
-
-	return c.Status(fiber.StatusOK).JSON(LoginResponse{
-		AccessToken: t,
-	})
-
-}
-
-func getUserPayloadFromCtx(c *fiber.Ctx) (jwt.MapClaims, bool) {
-	jwtToken, ok := c.Context().Value(contextKeyUser).(*jwt.Token)
-	if !ok {
-		logrus.WithFields(logrus.Fields{
-			"jwt_token_context_value": c.Context().Value(contextKeyUser),
-		}).Error("wrong type of JWT token in context")
-		return nil, false
-	}
-
-	payload, ok := jwtToken.Claims.(jwt.MapClaims)
-	if !ok {
-		logrus.WithFields(logrus.Fields{
-			"jwt_token_claims": jwtToken.Claims,
-		}).Error("wrong type of JWT token claims")
-		return nil, false
-	}
-
-	return payload, true
-
-}
-
-// Структура HTTP-ответа с информацией о пользователе
-type ProfileResponse struct {
-	Email string `json:"email"`
-	Name  string `json:"name"`
-}
-
-func (u *UserHandler) Profile(c *fiber.Ctx) error {
-	payload, ok := getUserPayloadFromCtx(c)
-	if !ok {
-		return c.SendStatus(fiber.StatusUnauthorized)
-	}
-
-	userInfo, ok := u.storage.users[payload["sub"].(string)]
-	if !ok {
-		return errors.New("user not found")
-	}
-
-	return c.Status(fiber.StatusOK).JSON(ProfileResponse{
-		Email: userInfo.Email,
-		Name:  userInfo.Name,
-	})
-}
-
 /*
 	webApp.Post("/search", func(c *fiber.Ctx) error {
 		var request BinarySearchRequest
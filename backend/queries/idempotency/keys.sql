@@ -0,0 +1,28 @@
+-- name: InsertIdempotencyKey :one
+INSERT INTO idempotency_keys (scope, key, fingerprint, locked_by) VALUES ($1, $2, $3, $4)
+ON CONFLICT (scope, key) DO NOTHING
+RETURNING scope,key,fingerprint,status_code,content_type,response_body,created_at,locked_at,completed_at,locked_by;
+
+-- name: GetIdempotencyKey :one
+SELECT scope,key,fingerprint,status_code,content_type,response_body,created_at,locked_at,completed_at,locked_by
+FROM idempotency_keys WHERE scope = $1 AND key = $2;
+
+-- name: ReclaimIdempotencyKey :one
+UPDATE idempotency_keys SET locked_at = CURRENT_TIMESTAMP, locked_by = sqlc.arg(locked_by)
+WHERE scope = sqlc.arg(scope) AND key = sqlc.arg(key) AND fingerprint = sqlc.arg(fingerprint)
+AND completed_at IS NULL
+AND locked_at < CURRENT_TIMESTAMP - make_interval(secs => sqlc.arg(lock_timeout_seconds)::int)
+RETURNING scope,key,fingerprint,status_code,content_type,response_body,created_at,locked_at,completed_at,locked_by;
+
+-- name: CompleteIdempotencyKey :execrows
+UPDATE idempotency_keys
+SET status_code = $3, content_type = $4, response_body = $5, completed_at = CURRENT_TIMESTAMP
+WHERE scope = $1 AND key = $2 AND locked_by = $6 AND completed_at IS NULL;
+
+-- name: ReleaseIdempotencyKey :execrows
+DELETE FROM idempotency_keys
+WHERE scope = $1 AND key = $2 AND locked_by = $3 AND completed_at IS NULL;
+
+-- name: DeleteExpiredIdempotencyKeys :execrows
+DELETE FROM idempotency_keys
+WHERE created_at < CURRENT_TIMESTAMP - make_interval(secs => sqlc.arg(ttl_seconds)::int);
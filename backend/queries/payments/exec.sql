@@ -1,9 +1,14 @@
 -- name: CreatePayment :one
-INSERT INTO payments (invoice_id, amount_cents,status ) values($1,$2,$3)
-RETURNING invoice_id,amount_cents,status,updated_at;
- 
--- name: SetPaymentStatus :one 
-UPDATE payments set status = $1, updated_at = CURRENT_TIMESTAMP where id = $2
-RETURNING *;
+INSERT INTO payments (invoice_id, amount_cents) VALUES ($1, $2)
+RETURNING id,invoice_id,amount_cents,status,updated_at,created_at,status_changed_at;
 
+-- name: SetPaymentStatus :one
+UPDATE payments
+SET status = sqlc.arg(status), updated_at = CURRENT_TIMESTAMP, status_changed_at = CURRENT_TIMESTAMP
+WHERE id = sqlc.arg(id) AND status = ANY(sqlc.arg(from_statuses)::text[])
+RETURNING id,invoice_id,amount_cents,status,updated_at,created_at,status_changed_at;
 
+-- name: InsertPaymentStatusTransition :one
+INSERT INTO payment_status_transitions (payment_id, from_status, to_status)
+VALUES ($1, $2, $3)
+RETURNING id,payment_id,from_status,to_status,created_at;
@@ -0,0 +1,84 @@
+-- name: CreateCustomer :one
+INSERT INTO customers (name, email) VALUES ($1, $2)
+RETURNING id,name,email,created_at;
+
+-- name: GetCustomer :one
+SELECT id,name,email,created_at
+FROM customers WHERE id = $1;
+
+-- name: CreateInvoice :one
+INSERT INTO invoices (customer_id, currency, tax_rate_bp) VALUES ($1, $2, $3)
+RETURNING id,number,customer_id,status,currency,subtotal_cents,discount_cents,tax_rate_bp,tax_cents,total_cents,created_at,updated_at,issued_at,paid_at,voided_at;
+
+-- name: GetInvoice :one
+SELECT id,number,customer_id,status,currency,subtotal_cents,discount_cents,tax_rate_bp,tax_cents,total_cents,created_at,updated_at,issued_at,paid_at,voided_at
+FROM invoices WHERE id = $1;
+
+-- name: GetInvoiceForUpdate :one
+SELECT id,number,customer_id,status,currency,subtotal_cents,discount_cents,tax_rate_bp,tax_cents,total_cents,created_at,updated_at,issued_at,paid_at,voided_at
+FROM invoices WHERE id = $1
+FOR UPDATE;
+
+-- name: GetInvoiceForShare :one
+SELECT id,number,customer_id,status,currency,subtotal_cents,discount_cents,tax_rate_bp,tax_cents,total_cents,created_at,updated_at,issued_at,paid_at,voided_at
+FROM invoices WHERE id = $1
+FOR SHARE;
+
+-- name: ListInvoicesByCustomer :many
+SELECT id,number,customer_id,status,currency,subtotal_cents,discount_cents,tax_rate_bp,tax_cents,total_cents,created_at,updated_at,issued_at,paid_at,voided_at
+FROM invoices WHERE customer_id = $1
+ORDER BY created_at, id;
+
+-- name: UpdateInvoiceTotals :one
+UPDATE invoices
+SET subtotal_cents = sqlc.arg(subtotal_cents),
+    discount_cents = sqlc.arg(discount_cents),
+    tax_rate_bp = sqlc.arg(tax_rate_bp),
+    tax_cents = sqlc.arg(tax_cents),
+    total_cents = sqlc.arg(total_cents),
+    updated_at = CURRENT_TIMESTAMP
+WHERE id = sqlc.arg(id) AND status = 'draft'
+RETURNING id,number,customer_id,status,currency,subtotal_cents,discount_cents,tax_rate_bp,tax_cents,total_cents,created_at,updated_at,issued_at,paid_at,voided_at;
+
+-- name: NextInvoiceNumber :one
+INSERT INTO invoice_number_sequences (year, last_number) VALUES ($1, 1)
+ON CONFLICT (year) DO UPDATE SET last_number = invoice_number_sequences.last_number + 1
+RETURNING last_number;
+
+-- name: IssueInvoice :one
+UPDATE invoices
+SET status = 'issued', number = $2, issued_at = CURRENT_TIMESTAMP, updated_at = CURRENT_TIMESTAMP
+WHERE id = $1 AND status = 'draft'
+RETURNING id,number,customer_id,status,currency,subtotal_cents,discount_cents,tax_rate_bp,tax_cents,total_cents,created_at,updated_at,issued_at,paid_at,voided_at;
+
+-- name: VoidInvoice :one
+UPDATE invoices
+SET status = 'void', voided_at = CURRENT_TIMESTAMP, updated_at = CURRENT_TIMESTAMP
+WHERE id = $1 AND status IN ('draft', 'issued')
+RETURNING id,number,customer_id,status,currency,subtotal_cents,discount_cents,tax_rate_bp,tax_cents,total_cents,created_at,updated_at,issued_at,paid_at,voided_at;
+
+-- name: MarkInvoicePaid :execrows
+UPDATE invoices
+SET status = 'paid', paid_at = CURRENT_TIMESTAMP, updated_at = CURRENT_TIMESTAMP
+WHERE id = $1 AND status = 'issued' AND total_cents <= $2;
+
+-- name: InvoiceCapturedCents :one
+SELECT (
+    coalesce(sum(o.amount_cents) FILTER (WHERE o.kind = 'capture'), 0)
+  - coalesce(sum(o.amount_cents) FILTER (WHERE o.kind = 'refund'), 0)
+)::bigint AS captured_cents
+FROM payment_operations o JOIN payments p ON p.id = o.payment_id
+WHERE p.invoice_id = $1;
+
+-- name: InsertInvoiceLine :one
+INSERT INTO invoice_lines (invoice_id, product_id, description, quantity, price_cents, amount_cents)
+VALUES ($1, $2, $3, $4, $5, $6)
+RETURNING id,invoice_id,product_id,description,quantity,price_cents,amount_cents,created_at;
+
+-- name: DeleteInvoiceLine :execrows
+DELETE FROM invoice_lines WHERE id = $1 AND invoice_id = $2;
+
+-- name: ListInvoiceLines :many
+SELECT id,invoice_id,product_id,description,quantity,price_cents,amount_cents,created_at
+FROM invoice_lines WHERE invoice_id = $1
+ORDER BY id;
@@ -0,0 +1,17 @@
+-- name: InsertPaymentOperation :one
+INSERT INTO payment_operations (payment_id, kind, amount_cents)
+VALUES ($1, $2, $3)
+RETURNING id,payment_id,kind,amount_cents,created_at;
+
+-- name: PaymentOperationTotals :one
+SELECT
+    coalesce(sum(amount_cents) FILTER (WHERE kind = 'authorize'), 0)::bigint AS authorized_cents,
+    coalesce(sum(amount_cents) FILTER (WHERE kind = 'capture'), 0)::bigint AS captured_cents,
+    coalesce(sum(amount_cents) FILTER (WHERE kind = 'refund'), 0)::bigint AS refunded_cents,
+    count(*) FILTER (WHERE kind = 'void') AS voids
+FROM payment_operations WHERE payment_id = $1;
+
+-- name: ListPaymentOperations :many
+SELECT id,payment_id,kind,amount_cents,created_at
+FROM payment_operations WHERE payment_id = $1
+ORDER BY id;
@@ -0,0 +1,18 @@
+-- name: GetPayment :one
+SELECT id,invoice_id,amount_cents,status,updated_at,created_at,status_changed_at
+FROM payments WHERE id = $1;
+
+-- name: ListPaymentStatusTransitions :many
+SELECT id,payment_id,from_status,to_status,created_at
+FROM payment_status_transitions WHERE payment_id = $1
+ORDER BY id;
+
+-- name: GetPaymentForUpdate :one
+SELECT id,invoice_id,amount_cents,status,updated_at,created_at,status_changed_at
+FROM payments WHERE id = $1
+FOR UPDATE;
+
+-- name: ListPaymentsByInvoice :many
+SELECT id,invoice_id,amount_cents,status,updated_at,created_at,status_changed_at
+FROM payments WHERE invoice_id = $1
+ORDER BY created_at, id;
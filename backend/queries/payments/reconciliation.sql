@@ -0,0 +1,33 @@
+-- name: ListSettlementCandidates :many
+SELECT p.id AS payment_id, p.invoice_id, coalesce(i.number, '')::text AS invoice_number,
+       sum(o.amount_cents)::bigint AS captured_cents
+FROM payment_operations o
+JOIN payments p ON p.id = o.payment_id
+JOIN invoices i ON i.id = p.invoice_id
+WHERE o.kind = 'capture' AND o.created_at >= sqlc.arg(period_from) AND o.created_at < sqlc.arg(period_to)
+GROUP BY p.id, p.invoice_id, i.number
+ORDER BY p.id;
+
+-- name: CreateReconciliationReport :one
+INSERT INTO reconciliation_reports (source, period_from, period_to, rows_total, matched, missing, extra, mismatched, invalid)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+RETURNING id,source,period_from,period_to,rows_total,matched,missing,extra,mismatched,invalid,created_at;
+
+-- name: InsertReconciliationEntry :exec
+INSERT INTO reconciliation_entries (report_id, status, line, invoice_ref, settlement_ref, payment_id, settled_cents, expected_cents, note)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9);
+
+-- name: GetReconciliationReport :one
+SELECT id,source,period_from,period_to,rows_total,matched,missing,extra,mismatched,invalid,created_at
+FROM reconciliation_reports WHERE id = $1;
+
+-- name: ListReconciliationReports :many
+SELECT id,source,period_from,period_to,rows_total,matched,missing,extra,mismatched,invalid,created_at
+FROM reconciliation_reports
+ORDER BY created_at DESC, id DESC
+LIMIT $1;
+
+-- name: ListReconciliationEntries :many
+SELECT id,report_id,status,line,invoice_ref,settlement_ref,payment_id,settled_cents,expected_cents,note
+FROM reconciliation_entries WHERE report_id = $1
+ORDER BY id;
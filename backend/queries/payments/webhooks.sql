@@ -0,0 +1,10 @@
+-- name: InsertPaymentWebhookEvent :one
+INSERT INTO payment_webhook_events (event_id, type, payment_id, payload, outcome, reason)
+VALUES ($1, $2, $3, $4, $5, $6)
+ON CONFLICT (event_id) DO NOTHING
+RETURNING event_id,type,payment_id,payload,outcome,reason,received_at;
+
+-- name: ListPaymentWebhookEvents :many
+SELECT event_id,type,payment_id,payload,outcome,reason,received_at
+FROM payment_webhook_events WHERE payment_id = $1
+ORDER BY received_at, event_id;
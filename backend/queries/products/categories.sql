@@ -0,0 +1,44 @@
+-- name: CreateCategory :one
+INSERT INTO categories (parent_id, slug, name)
+VALUES ($1, $2, $3)
+RETURNING id, parent_id, slug, name, created_at;
+
+-- name: GetCategory :one
+SELECT id, parent_id, slug, name, created_at FROM categories
+WHERE id = $1;
+
+-- name: ListCategories :many
+SELECT id, parent_id, slug, name, created_at FROM categories
+ORDER BY parent_id NULLS FIRST, name, id;
+
+-- name: UpdateCategory :execrows
+UPDATE categories SET parent_id = $1, slug = $2, name = $3
+WHERE id = $4;
+
+-- name: DeleteCategory :execrows
+DELETE FROM categories WHERE id = $1;
+
+-- name: CategorySubtreeIDs :many
+WITH RECURSIVE subtree AS (
+    SELECT c.id FROM categories c WHERE c.id = sqlc.arg(root_id)
+    UNION ALL
+    SELECT c.id FROM categories c JOIN subtree s ON c.parent_id = s.id
+)
+SELECT id FROM subtree;
+
+-- name: ListProductCategories :many
+SELECT c.id, c.parent_id, c.slug, c.name, c.created_at FROM categories c
+JOIN product_categories pc ON pc.category_id = c.id
+WHERE pc.product_id = $1
+ORDER BY c.name, c.id;
+
+-- name: DeleteProductCategories :exec
+DELETE FROM product_categories WHERE product_id = $1;
+
+-- name: AddProductCategories :exec
+INSERT INTO product_categories (product_id, category_id)
+SELECT sqlc.arg(product_id)::int, unnest(sqlc.arg(category_ids)::int[])
+ON CONFLICT DO NOTHING;
+
+-- name: LockCategoryTree :exec
+SELECT pg_advisory_xact_lock(hashtext('categories_tree'));
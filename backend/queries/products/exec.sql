@@ -1,13 +1,36 @@
 -- name: CreateProduct :one
-INSERT INTO products (slug,title,description,price_cents)
-values($1,$2,$3,$4)
-RETURNING id,slug,title,description,price_cents,created_at;
+INSERT INTO products (slug,title,description,price_cents,currency)
+values($1,$2,$3,$4,$5)
+RETURNING id,slug,title,description,price_cents,created_at,deleted_at,currency,version;
+
+-- name: CreateProductIfSlugFree :one
+INSERT INTO products (slug,title,description,price_cents,currency)
+values($1,$2,$3,$4,$5)
+ON CONFLICT (slug) DO NOTHING
+RETURNING id,slug,title,description,price_cents,created_at,deleted_at,currency,version;
 
 -- name: DeleteAllProducts :exec
 Delete from products;
 
--- name: UpdateProductPrice :execrows
-UPDATE products set price_cents=$1 where id =$2;
+-- name: UpdateProductPrice :one
+UPDATE products set price_cents=sqlc.arg(price_cents), currency=COALESCE(NULLIF(sqlc.arg(currency), ''), currency), version = version + 1
+where id = sqlc.arg(id) AND deleted_at IS NULL
+AND (sqlc.arg(expected_version)::int = 0 OR version = sqlc.arg(expected_version)::int)
+RETURNING id,slug,title,description,price_cents,created_at,deleted_at,currency,version;
  
 -- name: DeleteProduct :execrows
-DELETE from products where id = $1; 
\ No newline at end of file
+DELETE from products where id = $1;
+
+-- name: SoftDeleteProduct :execrows
+UPDATE products set deleted_at = CURRENT_TIMESTAMP, version = version + 1
+where id = sqlc.arg(id) AND deleted_at IS NULL
+AND (sqlc.arg(expected_version)::int = 0 OR version = sqlc.arg(expected_version)::int);
+
+-- name: RestoreProduct :execrows
+UPDATE products set deleted_at = NULL, version = version + 1
+where id = sqlc.arg(id) AND deleted_at IS NOT NULL
+AND (sqlc.arg(expected_version)::int = 0 OR version = sqlc.arg(expected_version)::int);
+
+-- name: PurgeDeletedProducts :execrows
+DELETE from products
+where deleted_at IS NOT NULL AND deleted_at < sqlc.arg(deleted_before)::timestamp;
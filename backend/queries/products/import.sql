@@ -0,0 +1,36 @@
+-- name: InsertProductsBatch :many
+INSERT INTO products (slug,title,description,price_cents,currency)
+SELECT unnest(sqlc.arg(slugs)::text[]),
+    unnest(sqlc.arg(titles)::text[]),
+    unnest(sqlc.arg(descriptions)::text[]),
+    unnest(sqlc.arg(prices)::bigint[]),
+    unnest(sqlc.arg(currencies)::text[])
+ON CONFLICT (slug) DO NOTHING
+RETURNING id, slug;
+
+-- name: UpsertProductsBatch :many
+INSERT INTO products (slug,title,description,price_cents,currency)
+SELECT unnest(sqlc.arg(slugs)::text[]),
+    unnest(sqlc.arg(titles)::text[]),
+    unnest(sqlc.arg(descriptions)::text[]),
+    unnest(sqlc.arg(prices)::bigint[]),
+    unnest(sqlc.arg(currencies)::text[])
+ON CONFLICT (slug) DO UPDATE SET
+    title = EXCLUDED.title,
+    description = EXCLUDED.description,
+    price_cents = EXCLUDED.price_cents,
+    currency = EXCLUDED.currency,
+    deleted_at = NULL,
+    version = products.version + 1
+RETURNING id, slug, (xmax = 0)::bool AS inserted;
+
+-- name: RecordCurrentPrices :execrows
+INSERT INTO product_prices (product_id, price_cents, currency)
+SELECT p.id, p.price_cents, p.currency FROM products p
+WHERE p.id = ANY(sqlc.arg(ids)::int[])
+AND (p.price_cents, p.currency) IS DISTINCT FROM (
+    SELECT pp.price_cents, pp.currency FROM product_prices pp
+    WHERE pp.product_id = p.id
+    ORDER BY pp.valid_from DESC, pp.id DESC
+    LIMIT 1
+);
@@ -0,0 +1,78 @@
+-- name: GetInventory :one
+SELECT product_id, on_hand, reserved, updated_at FROM inventory
+WHERE product_id = $1;
+
+-- name: AddStock :one
+INSERT INTO inventory (product_id, on_hand)
+VALUES (sqlc.arg(product_id), sqlc.arg(quantity)::bigint)
+ON CONFLICT (product_id) DO UPDATE SET
+    on_hand = inventory.on_hand + EXCLUDED.on_hand,
+    updated_at = CURRENT_TIMESTAMP
+RETURNING product_id, on_hand, reserved, updated_at;
+
+-- name: RemoveStock :one
+UPDATE inventory SET on_hand = on_hand - sqlc.arg(quantity)::bigint, updated_at = CURRENT_TIMESTAMP
+WHERE product_id = sqlc.arg(product_id) AND on_hand - reserved >= sqlc.arg(quantity)::bigint
+RETURNING product_id, on_hand, reserved, updated_at;
+
+-- name: ReserveStock :one
+UPDATE inventory SET reserved = reserved + sqlc.arg(quantity)::bigint, updated_at = CURRENT_TIMESTAMP
+WHERE product_id = sqlc.arg(product_id) AND on_hand - reserved >= sqlc.arg(quantity)::bigint
+RETURNING product_id, on_hand, reserved, updated_at;
+
+-- name: CommitReservedStock :one
+UPDATE inventory SET
+    on_hand = on_hand - sqlc.arg(quantity)::bigint,
+    reserved = reserved - sqlc.arg(quantity)::bigint,
+    updated_at = CURRENT_TIMESTAMP
+WHERE product_id = sqlc.arg(product_id)
+RETURNING product_id, on_hand, reserved, updated_at;
+
+-- name: ReleaseReservedStock :one
+UPDATE inventory SET reserved = reserved - sqlc.arg(quantity)::bigint, updated_at = CURRENT_TIMESTAMP
+WHERE product_id = sqlc.arg(product_id)
+RETURNING product_id, on_hand, reserved, updated_at;
+
+-- name: CreateReservation :one
+INSERT INTO stock_reservations (product_id, quantity, expires_at)
+VALUES (sqlc.arg(product_id), sqlc.arg(quantity),
+    CURRENT_TIMESTAMP + make_interval(secs => sqlc.arg(ttl_seconds)::int))
+RETURNING id, product_id, quantity, status, expires_at, created_at, updated_at;
+
+-- name: GetReservation :one
+SELECT id, product_id, quantity, status, expires_at, created_at, updated_at FROM stock_reservations
+WHERE id = $1;
+
+-- name: CommitReservation :one
+UPDATE stock_reservations SET status = 'committed', updated_at = CURRENT_TIMESTAMP
+WHERE id = $1 AND status = 'active' AND expires_at > CURRENT_TIMESTAMP
+RETURNING id, product_id, quantity, status, expires_at, created_at, updated_at;
+
+-- name: ReleaseReservation :one
+UPDATE stock_reservations SET status = 'released', updated_at = CURRENT_TIMESTAMP
+WHERE id = $1 AND status = 'active'
+RETURNING id, product_id, quantity, status, expires_at, created_at, updated_at;
+
+-- name: ExpireReservations :one
+WITH expired AS (
+    UPDATE stock_reservations SET status = 'expired', updated_at = CURRENT_TIMESTAMP
+    WHERE status = 'active' AND expires_at <= CURRENT_TIMESTAMP
+    RETURNING product_id, quantity
+), released AS (
+    UPDATE inventory i SET reserved = i.reserved - t.quantity, updated_at = CURRENT_TIMESTAMP
+    FROM (SELECT product_id, sum(quantity) AS quantity FROM expired GROUP BY product_id) t
+    WHERE i.product_id = t.product_id
+    RETURNING i.product_id
+)
+SELECT count(*) FROM expired;
+
+-- name: InsertStockMovement :one
+INSERT INTO stock_movements (product_id, kind, quantity, reservation_id, note)
+VALUES ($1, $2, $3, $4, $5)
+RETURNING id, product_id, kind, quantity, reservation_id, note, created_at;
+
+-- name: ListStockMovements :many
+SELECT id, product_id, kind, quantity, reservation_id, note, created_at FROM stock_movements
+WHERE product_id = $1
+ORDER BY created_at DESC, id DESC
+LIMIT $2;
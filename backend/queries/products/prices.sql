@@ -0,0 +1,16 @@
+-- name: InsertProductPrice :one
+INSERT INTO product_prices (product_id, price_cents, currency)
+VALUES ($1, $2, $3)
+RETURNING id, product_id, price_cents, valid_from, currency;
+
+-- name: ListProductPrices :many
+SELECT id, product_id, price_cents, valid_from, currency FROM product_prices
+WHERE product_id = $1
+ORDER BY valid_from DESC, id DESC
+LIMIT $2;
+
+-- name: GetProductPriceAt :one
+SELECT id, product_id, price_cents, valid_from, currency FROM product_prices
+WHERE product_id = sqlc.arg(product_id) AND valid_from <= sqlc.arg(at)::timestamp
+ORDER BY valid_from DESC, id DESC
+LIMIT 1;
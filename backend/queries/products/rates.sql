@@ -0,0 +1,15 @@
+-- name: UpsertExchangeRate :one
+INSERT INTO exchange_rates (base, quote, rate)
+VALUES (sqlc.arg(base), sqlc.arg(quote), sqlc.arg(rate)::numeric)
+ON CONFLICT (base, quote) DO UPDATE SET
+    rate = EXCLUDED.rate,
+    updated_at = CURRENT_TIMESTAMP
+RETURNING base, quote, rate, updated_at;
+
+-- name: GetExchangeRate :one
+SELECT base, quote, rate, updated_at FROM exchange_rates
+WHERE base = $1 AND quote = $2;
+
+-- name: ListExchangeRates :many
+SELECT base, quote, rate, updated_at FROM exchange_rates
+ORDER BY base, quote;
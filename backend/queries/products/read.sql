@@ -1,7 +1,8 @@
 -- name: GetProductByID :one
-SELECT id,slug,title,description,price_cents,created_at 
-from products where id = $1;
+SELECT id,slug,title,description,price_cents,created_at,deleted_at,currency,version
+from products where id = sqlc.arg(id)
+AND (deleted_at IS NULL OR sqlc.arg(include_deleted)::bool);
 
--- name: ListProducts :many 
-SELECT id,slug,title,description,price_cents,created_at FROM products
-LIMIT $1 OFFSET $2;
\ No newline at end of file
+-- name: GetProductBySlug :one
+SELECT id,slug,title,description,price_cents,created_at,deleted_at,currency,version
+from products where slug = $1 AND deleted_at IS NULL;
@@ -0,0 +1,19 @@
+-- name: SearchProducts :many
+SELECT p.id, p.slug, p.title, p.description, p.price_cents, p.currency, p.created_at,
+    ts_rank(s.document, websearch_to_tsquery('russian', sqlc.arg(query)))::real AS rank,
+    ts_headline('russian', p.title, websearch_to_tsquery('russian', sqlc.arg(query)),
+        'HighlightAll=true')::text AS title_snippet,
+    ts_headline('russian', p.description, websearch_to_tsquery('russian', sqlc.arg(query)),
+        'MaxFragments=2, MinWords=5, MaxWords=20')::text AS description_snippet
+FROM product_search s
+JOIN products p ON p.id = s.product_id
+WHERE s.document @@ websearch_to_tsquery('russian', sqlc.arg(query))
+AND p.deleted_at IS NULL
+ORDER BY rank DESC, p.id
+LIMIT sqlc.arg(row_limit) OFFSET sqlc.arg(row_offset);
+
+-- name: CountSearchProducts :one
+SELECT count(*) FROM product_search s
+JOIN products p ON p.id = s.product_id
+WHERE s.document @@ websearch_to_tsquery('russian', sqlc.arg(query))
+AND p.deleted_at IS NULL;
@@ -0,0 +1,35 @@
+-- name: CreateTag :one
+INSERT INTO tags (name) VALUES ($1)
+RETURNING id, name;
+
+-- name: GetTag :one
+SELECT id, name FROM tags WHERE id = $1;
+
+-- name: ListTags :many
+SELECT id, name FROM tags ORDER BY name;
+
+-- name: RenameTag :execrows
+UPDATE tags SET name = $1 WHERE id = $2;
+
+-- name: DeleteTag :execrows
+DELETE FROM tags WHERE id = $1;
+
+-- name: EnsureTags :many
+INSERT INTO tags (name)
+SELECT unnest(sqlc.arg(names)::text[])
+ON CONFLICT (name) DO UPDATE SET name = EXCLUDED.name
+RETURNING id, name;
+
+-- name: ListProductTags :many
+SELECT t.id, t.name FROM tags t
+JOIN product_tags pt ON pt.tag_id = t.id
+WHERE pt.product_id = $1
+ORDER BY t.name;
+
+-- name: DeleteProductTags :exec
+DELETE FROM product_tags WHERE product_id = $1;
+
+-- name: AddProductTags :exec
+INSERT INTO product_tags (product_id, tag_id)
+SELECT sqlc.arg(product_id)::int, unnest(sqlc.arg(tag_ids)::int[])
+ON CONFLICT DO NOTHING;
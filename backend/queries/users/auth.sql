@@ -0,0 +1,11 @@
+-- name: GetUser :one
+SELECT id,name,email,created_at,updated_at,password_hash,password_changed_at,email_verified_at
+FROM users WHERE id = $1;
+
+-- name: GetUserByEmail :one
+SELECT id,name,email,created_at,updated_at,password_hash,password_changed_at,email_verified_at
+FROM users WHERE lower(email) = lower(sqlc.arg(email));
+
+-- name: RehashUserPassword :execrows
+UPDATE users SET password_hash = sqlc.arg(new_hash)
+WHERE id = sqlc.arg(id) AND password_hash = sqlc.arg(old_hash);
@@ -1,7 +1,7 @@
 -- name: CreateUser :one
-INSERT INTO users (email,name)
-VALUES ($1, $2)
-RETURNING id,name,email,created_at;
+INSERT INTO users (email,name,password_hash,password_changed_at)
+VALUES ($1, $2, $3, CURRENT_TIMESTAMP)
+RETURNING id,name,email,created_at,updated_at,password_hash,password_changed_at,email_verified_at;
 
 -- name: UpdateUserName :exec
 UPDATE users set name = $1 where id = $2;
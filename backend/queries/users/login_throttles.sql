@@ -0,0 +1,47 @@
+-- name: InsertLoginThrottle :exec
+INSERT INTO login_throttles (scope, key, failures, last_failure_at)
+VALUES ($1, $2, 0, $3)
+ON CONFLICT (scope, key) DO NOTHING;
+
+-- name: GetLoginThrottleForUpdate :one
+SELECT scope,key,failures,last_failure_at,blocked_until,locked
+FROM login_throttles WHERE scope = $1 AND key = $2
+FOR UPDATE;
+
+-- name: UpdateLoginThrottle :one
+UPDATE login_throttles SET failures = $3, last_failure_at = $4, blocked_until = $5, locked = $6
+WHERE scope = $1 AND key = $2
+RETURNING scope,key,failures,last_failure_at,blocked_until,locked;
+
+-- name: RestoreLoginThrottle :execrows
+UPDATE login_throttles SET
+    failures = sqlc.arg(failures),
+    last_failure_at = sqlc.arg(last_failure_at),
+    blocked_until = sqlc.arg(blocked_until),
+    locked = sqlc.arg(locked)
+WHERE scope = sqlc.arg(scope) AND key = sqlc.arg(key)
+  AND failures = sqlc.arg(claimed_failures)
+  AND last_failure_at = sqlc.arg(claimed_at);
+
+-- name: DecrementLoginThrottle :exec
+UPDATE login_throttles SET failures = GREATEST(failures - 1, 0)
+WHERE scope = $1 AND key = $2;
+
+-- name: DeleteLoginThrottle :execrows
+DELETE FROM login_throttles WHERE scope = $1 AND key = $2;
+
+-- name: DeleteStaleLoginThrottles :execrows
+DELETE FROM login_throttles
+WHERE last_failure_at < sqlc.arg(window_start)
+  AND (blocked_until IS NULL OR blocked_until < sqlc.arg(now));
+
+-- name: InsertSecurityEvent :one
+INSERT INTO security_events (kind, scope, subject, ip, actor_id)
+VALUES ($1, $2, $3, $4, $5)
+RETURNING id,kind,scope,subject,ip,actor_id,created_at;
+
+-- name: ListSecurityEvents :many
+SELECT id,kind,scope,subject,ip,actor_id,created_at
+FROM security_events
+ORDER BY id DESC
+LIMIT $1;
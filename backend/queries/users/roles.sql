@@ -0,0 +1,26 @@
+-- name: ListRoles :many
+SELECT id,name,description,created_at FROM roles ORDER BY name;
+
+-- name: GetRoleByName :one
+SELECT id,name,description,created_at FROM roles WHERE name = $1;
+
+-- name: ListRolePermissions :many
+SELECT r.name AS role, p.name AS permission
+FROM role_permissions rp
+JOIN roles r ON r.id = rp.role_id
+JOIN permissions p ON p.id = rp.permission_id
+ORDER BY r.name, p.name;
+
+-- name: ListUserRoleNames :many
+SELECT r.name
+FROM user_roles ur JOIN roles r ON r.id = ur.role_id
+WHERE ur.user_id = $1
+ORDER BY r.name;
+
+-- name: AssignUserRole :execrows
+INSERT INTO user_roles (user_id, role_id, granted_by)
+VALUES ($1, $2, $3)
+ON CONFLICT (user_id, role_id) DO NOTHING;
+
+-- name: RevokeUserRole :execrows
+DELETE FROM user_roles WHERE user_id = $1 AND role_id = $2;
@@ -0,0 +1,23 @@
+-- name: InsertRefreshToken :one
+INSERT INTO refresh_tokens (user_id, family_id, token_hash, expires_at)
+VALUES ($1, $2, $3, $4)
+RETURNING id,user_id,family_id,token_hash,created_at,expires_at,used_at,revoked_at,revoked_reason;
+
+-- name: GetRefreshTokenForUpdate :one
+SELECT id,user_id,family_id,token_hash,created_at,expires_at,used_at,revoked_at,revoked_reason
+FROM refresh_tokens WHERE token_hash = $1
+FOR UPDATE;
+
+-- name: MarkRefreshTokenUsed :exec
+UPDATE refresh_tokens SET used_at = CURRENT_TIMESTAMP WHERE id = $1;
+
+-- name: RevokeRefreshTokenFamily :execrows
+UPDATE refresh_tokens SET revoked_at = CURRENT_TIMESTAMP, revoked_reason = $2
+WHERE family_id = $1 AND revoked_at IS NULL;
+
+-- name: RevokeUserRefreshTokens :execrows
+UPDATE refresh_tokens SET revoked_at = CURRENT_TIMESTAMP, revoked_reason = $2
+WHERE user_id = $1 AND revoked_at IS NULL;
+
+-- name: DeleteExpiredRefreshTokens :execrows
+DELETE FROM refresh_tokens WHERE expires_at < $1;
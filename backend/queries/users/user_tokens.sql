@@ -0,0 +1,35 @@
+-- name: InsertUserToken :one
+INSERT INTO user_tokens (user_id, purpose, token_hash, email, created_at, expires_at)
+VALUES ($1, $2, $3, $4, $5, $6)
+RETURNING id,user_id,purpose,token_hash,email,created_at,expires_at,used_at;
+
+-- name: GetUserToken :one
+SELECT id,user_id,purpose,token_hash,email,created_at,expires_at,used_at
+FROM user_tokens WHERE token_hash = $1 AND purpose = $2;
+
+-- name: GetUserTokenForUpdate :one
+SELECT id,user_id,purpose,token_hash,email,created_at,expires_at,used_at
+FROM user_tokens WHERE token_hash = $1 AND purpose = $2
+FOR UPDATE;
+
+-- name: CountUserTokensSince :one
+SELECT count(*) FROM user_tokens
+WHERE user_id = $1 AND purpose = $2 AND created_at >= $3;
+
+-- name: UseUserToken :exec
+UPDATE user_tokens SET used_at = sqlc.arg(used_at) WHERE id = sqlc.arg(id);
+
+-- name: InvalidateUserTokens :execrows
+UPDATE user_tokens SET used_at = sqlc.arg(used_at)
+WHERE user_id = sqlc.arg(user_id) AND purpose = sqlc.arg(purpose) AND used_at IS NULL;
+
+-- name: DeleteExpiredUserTokens :execrows
+DELETE FROM user_tokens WHERE expires_at < $1;
+
+-- name: MarkUserEmailVerified :execrows
+UPDATE users SET email_verified_at = CURRENT_TIMESTAMP, updated_at = CURRENT_TIMESTAMP
+WHERE id = sqlc.arg(id) AND lower(email) = lower(sqlc.arg(email));
+
+-- name: SetUserPassword :exec
+UPDATE users SET password_hash = $2, password_changed_at = CURRENT_TIMESTAMP, updated_at = CURRENT_TIMESTAMP
+WHERE id = $1;
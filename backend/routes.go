@@ -0,0 +1,191 @@
+package main
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/sirupsen/logrus"
+
+	"db200/handlers"
+	"db200/service"
+)
+
+// Лимиты тела запроса. Сервер читает большие тела потоком, а лимиты проверяет
+// handlers.BodyLimit: импорту каталога - свой, всем остальным - обычный.
+const (
+	defaultBodyLimit = fiber.DefaultBodyLimit
+	// Импорт каталога присылает файлы на десятки мегабайт
+	importBodyLimit = 64 * 1024 * 1024
+)
+
+// newWebApp - приложение API; маршруты добавляет registerRoutes
+func newWebApp() *fiber.App {
+	return fiber.New(fiber.Config{
+		BodyLimit:         defaultBodyLimit,
+		StreamRequestBody: true,
+		// Иначе multipart с известной длиной читался бы целиком до всех лимитов
+		DisablePreParseMultipartForm: true,
+	})
+}
+
+// routeHandlers - всё, что нужно для регистрации маршрутов
+type routeHandlers struct {
+	// authorized - JWT-middleware, кладёт проверенный токен в c.Locals
+	authorized fiber.Handler
+	idempotent fiber.Handler
+
+	auth            *handlers.AuthHandler
+	roles           *handlers.RoleHandler
+	security        *handlers.SecurityHandler
+	products        *handlers.ProductHandler
+	categories      *handlers.CategoryHandler
+	inventory       *handlers.InventoryHandler
+	invoices        *handlers.InvoiceHandler
+	payments        *handlers.PaymentHandler
+	paymentWebhooks *handlers.PaymentWebhookHandler
+	reconciliations *handlers.ReconciliationHandler
+	cacheStats      fiber.Handler
+
+	// webhooksEnabled - задан секрет подписи вебхуков
+	webhooksEnabled bool
+}
+
+// registerRoutes описывает все маршруты API. Изменяющие маршруты и маршруты
+// с персональными, платёжными и складскими данными идут через authorized и RequirePermission,
+// без них - только вход, регистрация и ссылки из писем (routes_test.go).
+func registerRoutes(app *fiber.App, h routeHandlers) {
+	writeProducts := h.roles.RequirePermission(service.PermissionProductsWrite)
+	readInventory := h.roles.RequirePermission(service.PermissionInventoryRead)
+	readPayments := h.roles.RequirePermission(service.PermissionPaymentsRead)
+	writePayments := h.roles.RequirePermission(service.PermissionPaymentsWrite)
+
+	app.Use(handlers.BodyLimit(defaultBodyLimit, isProductImport))
+
+	app.Post("/register", h.auth.Register)
+	app.Post("/login", h.auth.Login)
+	app.Post("/token/refresh", h.auth.Refresh)
+	app.Post("/logout", h.auth.Logout)
+	app.Post("/logout/all", h.authorized, h.auth.LogoutAll)
+	app.Get("/profile", h.authorized, h.auth.Profile)
+	app.Post("/email/verification", h.authorized, h.auth.RequestEmailVerification)
+	app.Post("/email/verification/confirm", h.auth.ConfirmEmail)
+	app.Post("/password/reset", h.auth.RequestPasswordReset)
+	app.Post("/password/reset/confirm", h.auth.ConfirmPasswordReset)
+
+	// Удалённые продукты (?include_deleted=true) видны только с правом на каталог
+	deletedProducts := []fiber.Handler{onlyIf(includesDeleted, h.authorized), onlyIf(includesDeleted, writeProducts)}
+
+	productsGroup := app.Group("/products")
+	productsGroup.Post("", h.authorized, writeProducts, h.products.CreateProduct)
+	productsGroup.Get("", append(deletedProducts, h.products.ListProducts)...)
+	productsGroup.Post("/import", h.authorized, writeProducts, handlers.BodyLimit(importBodyLimit, nil), h.products.ImportProducts)
+	productsGroup.Get("/search", h.products.SearchProducts)
+	productsGroup.Get("/by-slug/:slug", h.products.GetProductBySlug)
+	productsGroup.Get("/:id", append(deletedProducts, h.products.GetProduct)...)
+	productsGroup.Patch("/:id", h.authorized, writeProducts, h.products.UpdateProductPrice)
+	productsGroup.Get("/:id/prices", h.products.PriceHistory)
+	productsGroup.Get("/:id/price", h.products.PriceAt)
+	productsGroup.Delete("/:id", h.authorized, writeProducts, h.products.DeleteProduct)
+	productsGroup.Post("/:id/restore", h.authorized, writeProducts, h.products.RestoreProduct)
+	productsGroup.Get("/:id/categories", h.categories.ProductCategories)
+	productsGroup.Put("/:id/categories", h.authorized, writeProducts, h.categories.SetProductCategories)
+	productsGroup.Get("/:id/tags", h.categories.ProductTags)
+	productsGroup.Put("/:id/tags", h.authorized, writeProducts, h.categories.SetProductTags)
+	productsGroup.Get("/:id/stock", h.authorized, readInventory, h.inventory.GetStock)
+	productsGroup.Post("/:id/stock/receipts", h.authorized, writeProducts, h.inventory.ReceiveStock)
+	productsGroup.Post("/:id/stock/sales", h.authorized, writeProducts, h.inventory.SellStock)
+	productsGroup.Post("/:id/stock/adjustments", h.authorized, writeProducts, h.inventory.AdjustStock)
+	productsGroup.Get("/:id/stock/movements", h.authorized, readInventory, h.inventory.StockMovements)
+
+	reservationsGroup := app.Group("/reservations", h.authorized, writeProducts)
+	reservationsGroup.Post("", h.inventory.Reserve)
+	reservationsGroup.Get("/:id", h.inventory.GetReservation)
+	reservationsGroup.Post("/:id/commit", h.inventory.CommitReservation)
+	reservationsGroup.Post("/:id/release", h.inventory.ReleaseReservation)
+
+	categoriesGroup := app.Group("/categories")
+	categoriesGroup.Post("", h.authorized, writeProducts, h.categories.CreateCategory)
+	categoriesGroup.Get("", h.categories.ListCategories)
+	categoriesGroup.Get("/:id", h.categories.GetCategory)
+	categoriesGroup.Put("/:id", h.authorized, writeProducts, h.categories.UpdateCategory)
+	categoriesGroup.Delete("/:id", h.authorized, writeProducts, h.categories.DeleteCategory)
+
+	tagsGroup := app.Group("/tags")
+	tagsGroup.Post("", h.authorized, writeProducts, h.categories.CreateTag)
+	tagsGroup.Get("", h.categories.ListTags)
+	tagsGroup.Patch("/:id", h.authorized, writeProducts, h.categories.RenameTag)
+	tagsGroup.Delete("/:id", h.authorized, writeProducts, h.categories.DeleteTag)
+
+	ratesGroup := app.Group("/exchange-rates")
+	ratesGroup.Get("", h.products.ListExchangeRates)
+	ratesGroup.Put("/:base/:quote", h.authorized, writeProducts, h.products.SetExchangeRate)
+
+	// Покупатели - персональные данные, счета - деньги: всё только по разрешениям на платежи
+	customersGroup := app.Group("/customers", h.authorized)
+	customersGroup.Post("", writePayments, h.invoices.CreateCustomer)
+	customersGroup.Get("/:id", readPayments, h.invoices.GetCustomer)
+	customersGroup.Get("/:id/invoices", readPayments, h.invoices.CustomerInvoices)
+
+	invoicesGroup := app.Group("/invoices", h.authorized)
+	invoicesGroup.Post("", writePayments, h.invoices.CreateInvoice)
+	invoicesGroup.Get("/:id", readPayments, h.invoices.GetInvoice)
+	invoicesGroup.Patch("/:id", writePayments, h.invoices.AdjustInvoice)
+	invoicesGroup.Post("/:id/lines", writePayments, h.invoices.AddInvoiceLine)
+	invoicesGroup.Delete("/:id/lines/:line_id", writePayments, h.invoices.RemoveInvoiceLine)
+	invoicesGroup.Post("/:id/issue", writePayments, h.invoices.IssueInvoice)
+	invoicesGroup.Post("/:id/void", writePayments, h.invoices.VoidInvoice)
+	invoicesGroup.Get("/:id/payments", readPayments, h.payments.InvoicePayments)
+
+	paymentsGroup := app.Group("/payments", h.authorized)
+	paymentsGroup.Post("", writePayments, h.idempotent, h.payments.CreatePayment)
+	paymentsGroup.Get("", readPayments, h.payments.ListPayments)
+	paymentsGroup.Get("/:id", readPayments, h.payments.GetPayment)
+	paymentsGroup.Post("/:id/authorize", writePayments, h.idempotent, h.payments.AuthorizePayment)
+	paymentsGroup.Post("/:id/capture", writePayments, h.idempotent, h.payments.CapturePayment)
+	paymentsGroup.Post("/:id/refund", writePayments, h.idempotent, h.payments.RefundPayment)
+	paymentsGroup.Post("/:id/void", writePayments, h.idempotent, h.payments.VoidPayment)
+	paymentsGroup.Get("/:id/operations", readPayments, h.payments.PaymentOperations)
+	paymentsGroup.Get("/:id/status-history", readPayments, h.payments.PaymentStatusHistory)
+	paymentsGroup.Get("/:id/webhook-events", readPayments, h.paymentWebhooks.PaymentWebhookEvents)
+
+	reconciliationsGroup := app.Group("/reconciliations", h.authorized, readPayments)
+	reconciliationsGroup.Get("", h.reconciliations.ListReconciliations)
+	reconciliationsGroup.Get("/:id", h.reconciliations.GetReconciliation)
+	reconciliationsGroup.Get("/:id/export", h.reconciliations.ExportReconciliation)
+
+	if h.webhooksEnabled {
+		app.Post("/webhooks/payments", h.paymentWebhooks.ReceivePaymentEvent)
+	} else {
+		logrus.Warn("PAYMENT_WEBHOOK_SECRET не задан, приём вебхуков платежей выключен")
+	}
+
+	adminGroup := app.Group("/admin", h.authorized, h.roles.RequirePermission(service.PermissionUsersManage))
+	adminGroup.Get("/roles", h.roles.ListRoles)
+	adminGroup.Get("/users/:id/roles", h.roles.UserRoles)
+	adminGroup.Post("/users/:id/roles", h.roles.AssignRole)
+	adminGroup.Delete("/users/:id/roles/:role", h.roles.RevokeRole)
+	adminGroup.Post("/users/:id/unlock", h.security.UnlockUser)
+	adminGroup.Post("/ips/:ip/unlock", h.security.UnlockIP)
+	adminGroup.Get("/security-events", h.security.SecurityEvents)
+
+	app.Get("/debug/cache/products", h.authorized, h.roles.RequirePermission(service.PermissionSystemDebug),
+		h.cacheStats)
+}
+
+// onlyIf пропускает запрос через handler, только когда cond для него верно
+func onlyIf(cond func(*fiber.Ctx) bool, handler fiber.Handler) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if !cond(c) {
+			return c.Next()
+		}
+		return handler(c)
+	}
+}
+
+// includesDeleted - запрос просит показать и удалённые продукты
+func includesDeleted(c *fiber.Ctx) bool {
+	return c.QueryBool("include_deleted")
+}
+
+// isProductImport - запрос импорта каталога, его лимит тела - importBodyLimit
+func isProductImport(c *fiber.Ctx) bool {
+	return c.Method() == fiber.MethodPost && c.Path() == "/products/import"
+}
@@ -0,0 +1,211 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"net/http/httptest"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	jwtware "github.com/gofiber/contrib/jwt"
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/recover"
+	"github.com/golang-jwt/jwt/v5"
+
+	"db200/handlers"
+	"db200/internal/token"
+	"db200/service"
+)
+
+// Маршруты без токена: вход, регистрация, ссылки из писем, вебхуки с подписью
+var publicRoutes = map[string]bool{
+	"POST /register":                   true,
+	"POST /login":                      true,
+	"POST /token/refresh":              true,
+	"POST /logout":                     true,
+	"POST /email/verification/confirm": true,
+	"POST /password/reset":             true,
+	"POST /password/reset/confirm":     true,
+	"POST /webhooks/payments":          true,
+}
+
+// Маршруты для любого вошедшего пользователя: касаются только его самого
+var selfServiceRoutes = map[string]bool{
+	"GET /profile":             true,
+	"POST /logout/all":         true,
+	"POST /email/verification": true,
+}
+
+// GET под этими префиксами отдают персональные, платёжные или служебные данные
+var protectedReadPrefixes = []string{
+	"/customers", "/invoices", "/payments", "/reconciliations", "/reservations", "/admin", "/debug",
+}
+
+// Публичные GET, которые с этими параметрами отдают закрытые данные
+var protectedQueries = []string{
+	"/products?include_deleted=true",
+	"/products/1?include_deleted=true",
+}
+
+// Закрытые GET вне protectedReadPrefixes
+var protectedReadRoutes = map[string]bool{
+	"GET /products/:id/stock":           true,
+	"GET /products/:id/stock/movements": true,
+}
+
+var routeParam = regexp.MustCompile(`:[a-z_]+`)
+
+func TestRoutesRequirePermission(t *testing.T) {
+	keys, err := token.NewEphemeralKeyring()
+	if err != nil {
+		t.Fatal(err)
+	}
+	noRoles, err := keys.Sign(token.AccessClaims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject:   "1",
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Minute)),
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Обработчики без сервисов: если запрос до них дойдёт, recover ответит 500
+	app := newWebApp()
+	app.Use(recover.New())
+	unreachable := func(c *fiber.Ctx) error {
+		return c.SendStatus(fiber.StatusTeapot)
+	}
+	registerRoutes(app, routeHandlers{
+		authorized: jwtware.New(jwtware.Config{
+			KeyFunc:      keys.Keyfunc,
+			Claims:       &token.AccessClaims{},
+			ContextKey:   handlers.UserContextKey,
+			ErrorHandler: handlers.TokenError,
+		}),
+		idempotent:      unreachable,
+		auth:            &handlers.AuthHandler{},
+		roles:           handlers.NewRoleHandler(service.NewRoleService(nil, nil, time.Minute)),
+		security:        &handlers.SecurityHandler{},
+		products:        &handlers.ProductHandler{},
+		categories:      &handlers.CategoryHandler{},
+		inventory:       &handlers.InventoryHandler{},
+		invoices:        &handlers.InvoiceHandler{},
+		payments:        &handlers.PaymentHandler{},
+		paymentWebhooks: &handlers.PaymentWebhookHandler{},
+		reconciliations: &handlers.ReconciliationHandler{},
+		cacheStats:      unreachable,
+		webhooksEnabled: true,
+	})
+
+	checked := 0
+	for _, route := range app.GetRoutes(true) {
+		key := route.Method + " " + route.Path
+		if route.Method == fiber.MethodHead || publicRoutes[key] {
+			continue
+		}
+		if route.Method == fiber.MethodGet && !selfServiceRoutes[key] && !protectedReadRoutes[key] && !hasProtectedPrefix(route.Path) {
+			continue
+		}
+		checked++
+
+		path := routeParam.ReplaceAllString(route.Path, "1")
+		if status := testRequest(t, app, route.Method, path, ""); status != fiber.StatusUnauthorized {
+			t.Errorf("%s without token: status %d, want 401", key, status)
+		}
+		if selfServiceRoutes[key] {
+			continue
+		}
+		if status := testRequest(t, app, route.Method, path, noRoles); status != fiber.StatusForbidden {
+			t.Errorf("%s without permission: status %d, want 403", key, status)
+		}
+	}
+	if checked == 0 {
+		t.Fatal("no routes checked")
+	}
+
+	for _, path := range protectedQueries {
+		if status := testRequest(t, app, fiber.MethodGet, path, ""); status != fiber.StatusUnauthorized {
+			t.Errorf("GET %s without token: status %d, want 401", path, status)
+		}
+		if status := testRequest(t, app, fiber.MethodGet, path, noRoles); status != fiber.StatusForbidden {
+			t.Errorf("GET %s without permission: status %d, want 403", path, status)
+		}
+	}
+}
+
+func hasProtectedPrefix(path string) bool {
+	for _, prefix := range protectedReadPrefixes {
+		if path == prefix || strings.HasPrefix(path, prefix+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+func testRequest(t *testing.T, app *fiber.App, method, path, accessToken string) int {
+	t.Helper()
+	req := httptest.NewRequest(method, path, nil)
+	if accessToken != "" {
+		req.Header.Set("Authorization", "Bearer "+accessToken)
+	}
+	resp, err := app.Test(req)
+	if err != nil {
+		t.Fatalf("%s %s: %v", method, path, err)
+	}
+	defer resp.Body.Close()
+	return resp.StatusCode
+}
+
+func TestBodyLimit(t *testing.T) {
+	app := newWebApp()
+	app.Use(handlers.BodyLimit(defaultBodyLimit, isProductImport))
+	bodyLength := func(c *fiber.Ctx) error {
+		return c.SendString(strconv.Itoa(len(c.Body())))
+	}
+	app.Post("/register", bodyLength)
+	app.Post("/products/import", handlers.BodyLimit(importBodyLimit, nil), bodyLength)
+
+	tests := []struct {
+		name    string
+		path    string
+		size    int
+		chunked bool
+		want    int
+	}{
+		{name: "small body", path: "/register", size: 1024, want: fiber.StatusOK},
+		{name: "default limit", path: "/register", size: defaultBodyLimit, want: fiber.StatusOK},
+		{name: "over default limit", path: "/register", size: defaultBodyLimit + 1, want: fiber.StatusRequestEntityTooLarge},
+		{name: "chunked over default limit", path: "/register", size: defaultBodyLimit + 1, chunked: true, want: fiber.StatusRequestEntityTooLarge},
+		{name: "import over default limit", path: "/products/import", size: 2 * defaultBodyLimit, want: fiber.StatusOK},
+		{name: "chunked import", path: "/products/import", size: 2 * defaultBodyLimit, chunked: true, want: fiber.StatusOK},
+		{name: "import over import limit", path: "/products/import", size: importBodyLimit + 1, want: fiber.StatusRequestEntityTooLarge},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(fiber.MethodPost, tt.path, bytes.NewReader(bytes.Repeat([]byte("x"), tt.size)))
+			if tt.chunked {
+				// Длина тела заранее неизвестна
+				req.TransferEncoding = []string{"chunked"}
+			}
+			resp, err := app.Test(req, -1)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer resp.Body.Close()
+
+			if resp.StatusCode != tt.want {
+				t.Fatalf("status %d, want %d", resp.StatusCode, tt.want)
+			}
+			if tt.want == fiber.StatusOK {
+				got, _ := io.ReadAll(resp.Body)
+				if string(got) != strconv.Itoa(tt.size) {
+					t.Errorf("handler got %s bytes, want %d", got, tt.size)
+				}
+			}
+		})
+	}
+}
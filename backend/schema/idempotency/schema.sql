@@ -0,0 +1,19 @@
+-- Ключи идемпотентности мутирующих запросов.
+-- scope - клиент, метод и путь запроса, fingerprint - sha256 тела;
+-- пока completed_at пуст, запрос выполняется (locked_at - когда его взяли,
+-- locked_by - случайный токен взявшего запроса).
+CREATE TABLE idempotency_keys(
+    scope TEXT NOT NULL,
+    key TEXT NOT NULL,
+    fingerprint TEXT NOT NULL,
+    status_code INTEGER,
+    content_type TEXT NOT NULL DEFAULT '',
+    response_body BYTEA,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    locked_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    locked_by TEXT NOT NULL DEFAULT '',
+    completed_at TIMESTAMP,
+    PRIMARY KEY (scope, key)
+);
+
+CREATE INDEX idempotency_keys_created_at_idx ON idempotency_keys (created_at);
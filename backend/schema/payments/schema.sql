@@ -1,7 +1,136 @@
+CREATE TABLE customers(
+    id SERIAL PRIMARY KEY,
+    name TEXT NOT NULL,
+    email TEXT NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE UNIQUE INDEX customers_email_key ON customers (lower(email));
+
+-- Последний выданный номер счёта по году
+CREATE TABLE invoice_number_sequences(
+    year INTEGER PRIMARY KEY,
+    last_number INTEGER NOT NULL
+);
+
+-- Счёт: draft -> issued -> paid, draft и issued можно аннулировать (void);
+-- total = subtotal - discount + tax
+CREATE TABLE invoices(
+    id SERIAL PRIMARY KEY,
+    number TEXT UNIQUE,
+    customer_id INTEGER NOT NULL REFERENCES customers(id),
+    status TEXT NOT NULL DEFAULT 'draft' CHECK (status IN ('draft', 'issued', 'paid', 'void')),
+    currency TEXT NOT NULL DEFAULT 'RUB',
+    subtotal_cents BIGINT NOT NULL DEFAULT 0,
+    discount_cents BIGINT NOT NULL DEFAULT 0 CHECK (discount_cents >= 0),
+    tax_rate_bp INTEGER NOT NULL DEFAULT 0 CHECK (tax_rate_bp BETWEEN 0 AND 10000),
+    tax_cents BIGINT NOT NULL DEFAULT 0,
+    total_cents BIGINT NOT NULL DEFAULT 0 CHECK (total_cents >= 0),
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    issued_at TIMESTAMP,
+    paid_at TIMESTAMP,
+    voided_at TIMESTAMP
+);
+
+CREATE INDEX invoices_customer_idx ON invoices (customer_id, created_at, id);
+
+-- Строки счёта с ценой продукта на момент добавления
+CREATE TABLE invoice_lines(
+    id BIGSERIAL PRIMARY KEY,
+    invoice_id INTEGER NOT NULL REFERENCES invoices(id) ON DELETE CASCADE,
+    -- NULL - продукт окончательно удалён из каталога
+    product_id INTEGER REFERENCES products(id) ON DELETE SET NULL,
+    description TEXT NOT NULL,
+    quantity INTEGER NOT NULL CHECK (quantity > 0),
+    price_cents BIGINT NOT NULL CHECK (price_cents >= 0),
+    amount_cents BIGINT NOT NULL CHECK (amount_cents >= 0),
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX invoice_lines_invoice_idx ON invoice_lines (invoice_id, id);
+
 CREATE TABLE payments(
     id SERIAL PRIMARY KEY,
-    invoice_id TEXT NOT NULL,
+    invoice_id INTEGER NOT NULL REFERENCES invoices(id),
     amount_cents INTEGER NOT NULL,
-    status TEXT ,
-    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
-);
\ No newline at end of file
+    -- Жизненный цикл: created -> authorized -> captured -> partially_refunded/refunded,
+    -- failed и cancelled - конечные
+    status TEXT NOT NULL DEFAULT 'created' CHECK (status IN (
+        'created', 'authorized', 'captured', 'partially_refunded', 'refunded', 'failed', 'cancelled'
+    )),
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    status_changed_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX payments_invoice_id_idx ON payments (invoice_id, created_at, id);
+
+-- Журнал смены статусов, по записи на каждый переход
+CREATE TABLE payment_status_transitions(
+    id BIGSERIAL PRIMARY KEY,
+    payment_id INTEGER NOT NULL REFERENCES payments(id) ON DELETE CASCADE,
+    from_status TEXT NOT NULL,
+    to_status TEXT NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX payment_status_transitions_payment_idx ON payment_status_transitions (payment_id, id);
+
+-- Операции по платежу; суммы и статус платежа выводятся из них
+CREATE TABLE payment_operations(
+    id BIGSERIAL PRIMARY KEY,
+    payment_id INTEGER NOT NULL REFERENCES payments(id) ON DELETE CASCADE,
+    kind TEXT NOT NULL CHECK (kind IN ('authorize', 'capture', 'refund', 'void')),
+    amount_cents BIGINT NOT NULL CHECK (amount_cents >= 0),
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX payment_operations_payment_idx ON payment_operations (payment_id, id);
+
+-- События провайдера; event_id - ключ дедупликации повторных доставок
+CREATE TABLE payment_webhook_events(
+    event_id TEXT PRIMARY KEY,
+    type TEXT NOT NULL,
+    payment_id INTEGER NOT NULL,
+    payload JSONB NOT NULL,
+    outcome TEXT NOT NULL CHECK (outcome IN ('applied', 'ignored')),
+    reason TEXT NOT NULL DEFAULT '',
+    received_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX payment_webhook_events_payment_idx ON payment_webhook_events (payment_id, received_at);
+
+-- Сверка файла расчётов эквайера со списаниями за период [period_from, period_to)
+CREATE TABLE reconciliation_reports(
+    id SERIAL PRIMARY KEY,
+    source TEXT NOT NULL,
+    period_from TIMESTAMP NOT NULL,
+    period_to TIMESTAMP NOT NULL,
+    rows_total INTEGER NOT NULL,
+    matched INTEGER NOT NULL,
+    missing INTEGER NOT NULL,
+    extra INTEGER NOT NULL,
+    mismatched INTEGER NOT NULL,
+    invalid INTEGER NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CHECK (period_from < period_to)
+);
+
+CREATE INDEX reconciliation_reports_created_idx ON reconciliation_reports (created_at DESC, id DESC);
+
+-- Результат по строке файла или по платежу, которого в файле нет (missing, line IS NULL)
+CREATE TABLE reconciliation_entries(
+    id BIGSERIAL PRIMARY KEY,
+    report_id INTEGER NOT NULL REFERENCES reconciliation_reports(id) ON DELETE CASCADE,
+    status TEXT NOT NULL CHECK (status IN ('matched', 'missing', 'extra', 'amount_mismatch', 'invalid')),
+    line INTEGER,
+    invoice_ref TEXT NOT NULL DEFAULT '',
+    settlement_ref TEXT NOT NULL DEFAULT '',
+    payment_id INTEGER REFERENCES payments(id) ON DELETE SET NULL,
+    settled_cents BIGINT,
+    expected_cents BIGINT,
+    note TEXT NOT NULL DEFAULT ''
+);
+
+CREATE INDEX reconciliation_entries_report_idx ON reconciliation_entries (report_id, id);
@@ -3,6 +3,108 @@ CREATE TABLE products(
     slug TEXT NOT NULL UNIQUE,
     title TEXT NOT NULL,
     description TEXT NOT NULL,
-    price_cents INTEGER NOT NULL,
+    price_cents BIGINT NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    deleted_at TIMESTAMP,
+    currency TEXT NOT NULL DEFAULT 'RUB' CHECK (currency ~ '^[A-Z]{3}$'),
+    -- version растёт при каждом изменении, для оптимистичных блокировок
+    version INTEGER NOT NULL DEFAULT 1
+);
+
+CREATE INDEX products_created_at_id_idx ON products (created_at, id);
+CREATE INDEX products_deleted_at_idx ON products (deleted_at) WHERE deleted_at IS NOT NULL;
+
+-- Документ для полнотекстового поиска, заполняется триггером (см. миграции)
+CREATE TABLE product_search(
+    product_id INTEGER PRIMARY KEY REFERENCES products(id) ON DELETE CASCADE,
+    document TSVECTOR NOT NULL
+);
+
+CREATE INDEX product_search_document_idx ON product_search USING GIN (document);
+
+
+-- История цен: каждая запись действует с valid_from до следующей записи
+CREATE TABLE product_prices(
+    id BIGSERIAL PRIMARY KEY,
+    product_id INTEGER NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+    price_cents BIGINT NOT NULL,
+    valid_from TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    currency TEXT NOT NULL DEFAULT 'RUB' CHECK (currency ~ '^[A-Z]{3}$')
+);
+
+CREATE INDEX product_prices_product_valid_from_idx ON product_prices (product_id, valid_from DESC, id DESC);
+
+-- Курсы валют: сколько единиц quote за одну единицу base
+CREATE TABLE exchange_rates(
+    base TEXT NOT NULL,
+    quote TEXT NOT NULL,
+    rate NUMERIC(20, 10) NOT NULL CHECK (rate > 0),
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (base, quote)
+);
+
+-- Дерево категорий: корневые категории без parent_id
+CREATE TABLE categories(
+    id SERIAL PRIMARY KEY,
+    parent_id INTEGER REFERENCES categories(id) ON DELETE RESTRICT,
+    slug TEXT NOT NULL UNIQUE,
+    name TEXT NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX categories_parent_id_idx ON categories (parent_id);
+
+CREATE TABLE tags(
+    id SERIAL PRIMARY KEY,
+    name TEXT NOT NULL UNIQUE
+);
+
+CREATE TABLE product_categories(
+    product_id INTEGER NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+    category_id INTEGER NOT NULL REFERENCES categories(id) ON DELETE CASCADE,
+    PRIMARY KEY (product_id, category_id)
+);
+
+CREATE INDEX product_categories_category_id_idx ON product_categories (category_id);
+
+CREATE TABLE product_tags(
+    product_id INTEGER NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+    tag_id INTEGER NOT NULL REFERENCES tags(id) ON DELETE CASCADE,
+    PRIMARY KEY (product_id, tag_id)
+);
+
+CREATE INDEX product_tags_tag_id_idx ON product_tags (tag_id);
+
+-- Остатки: on_hand - физически на складе, reserved - под активными резервами.
+-- Доступно к продаже on_hand - reserved, ограничения не дают уйти в минус.
+CREATE TABLE inventory(
+    product_id INTEGER PRIMARY KEY REFERENCES products(id) ON DELETE CASCADE,
+    on_hand BIGINT NOT NULL DEFAULT 0 CHECK (on_hand >= 0),
+    reserved BIGINT NOT NULL DEFAULT 0 CHECK (reserved >= 0 AND reserved <= on_hand),
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE stock_reservations(
+    id BIGSERIAL PRIMARY KEY,
+    product_id INTEGER NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+    quantity BIGINT NOT NULL CHECK (quantity > 0),
+    status TEXT NOT NULL DEFAULT 'active' CHECK (status IN ('active', 'committed', 'released', 'expired')),
+    expires_at TIMESTAMP NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX stock_reservations_active_expires_at_idx ON stock_reservations (expires_at) WHERE status = 'active';
+
+-- Журнал движения остатков: quantity - изменение on_hand со знаком
+CREATE TABLE stock_movements(
+    id BIGSERIAL PRIMARY KEY,
+    product_id INTEGER NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+    kind TEXT NOT NULL CHECK (kind IN ('receipt', 'sale', 'adjustment')),
+    quantity BIGINT NOT NULL,
+    reservation_id BIGINT REFERENCES stock_reservations(id) ON DELETE SET NULL,
+    note TEXT NOT NULL DEFAULT '',
     created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
-);
\ No newline at end of file
+);
+
+CREATE INDEX stock_movements_product_created_at_idx ON stock_movements (product_id, created_at DESC, id DESC);
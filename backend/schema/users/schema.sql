@@ -3,6 +3,112 @@ CREATE TABLE users (
     name TEXT NOT NULL,
     email TEXT UNIQUE NOT NULL,
     created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
-    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    -- PHC-строка: алгоритм, параметры и соль хранятся вместе с хэшем.
+    -- Пустая строка - пароля нет, войти нельзя, пока его не зададут заново.
+    password_hash TEXT NOT NULL DEFAULT '',
+    password_changed_at TIMESTAMP,
+    email_verified_at TIMESTAMP
 );
- 
\ No newline at end of file
+
+CREATE UNIQUE INDEX users_email_lower_key ON users (lower(email));
+
+CREATE TABLE refresh_tokens (
+    id BIGSERIAL PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    -- Семейство - цепочка ротаций от одного входа; повтор старого токена отзывает всю цепочку
+    family_id TEXT NOT NULL,
+    -- SHA-256 от токена, сам токен не хранится
+    token_hash BYTEA NOT NULL UNIQUE,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    -- В UTC, сравнивается со временем приложения
+    expires_at TIMESTAMP NOT NULL,
+    -- Когда токен обменяли на следующий
+    used_at TIMESTAMP,
+    revoked_at TIMESTAMP,
+    revoked_reason TEXT NOT NULL DEFAULT ''
+);
+
+CREATE INDEX refresh_tokens_family_idx ON refresh_tokens (family_id);
+CREATE INDEX refresh_tokens_user_active_idx ON refresh_tokens (user_id) WHERE revoked_at IS NULL;
+CREATE INDEX refresh_tokens_expires_idx ON refresh_tokens (expires_at);
+
+CREATE TABLE roles (
+    id SERIAL PRIMARY KEY,
+    name TEXT NOT NULL UNIQUE,
+    description TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Разрешение - "ресурс:действие", его и проверяют маршруты
+CREATE TABLE permissions (
+    id SERIAL PRIMARY KEY,
+    name TEXT NOT NULL UNIQUE,
+    description TEXT NOT NULL DEFAULT ''
+);
+
+CREATE TABLE role_permissions (
+    role_id INTEGER NOT NULL REFERENCES roles(id) ON DELETE CASCADE,
+    permission_id INTEGER NOT NULL REFERENCES permissions(id) ON DELETE CASCADE,
+    PRIMARY KEY (role_id, permission_id)
+);
+
+CREATE TABLE user_roles (
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    role_id INTEGER NOT NULL REFERENCES roles(id) ON DELETE CASCADE,
+    granted_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    -- Кто выдал роль; NULL - миграция или консольная команда
+    granted_by INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    PRIMARY KEY (user_id, role_id)
+);
+
+CREATE INDEX user_roles_role_idx ON user_roles (role_id);
+
+-- Одноразовые токены из писем: подтверждение email и сброс пароля
+CREATE TABLE user_tokens (
+    id BIGSERIAL PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    purpose TEXT NOT NULL CHECK (purpose IN ('email_verification', 'password_reset')),
+    -- SHA-256 от токена, сам токен есть только в письме
+    token_hash BYTEA NOT NULL UNIQUE,
+    -- Адрес, на который ушло письмо; подтверждается только он
+    email TEXT NOT NULL,
+    -- Время приложения в UTC
+    created_at TIMESTAMP NOT NULL,
+    expires_at TIMESTAMP NOT NULL,
+    used_at TIMESTAMP
+);
+
+CREATE INDEX user_tokens_user_purpose_idx ON user_tokens (user_id, purpose, created_at);
+CREATE INDEX user_tokens_expires_idx ON user_tokens (expires_at);
+
+-- Неудачные входы подряд: по аккаунту (key - email в нижнем регистре,
+-- в том числе несуществующий) и по IP. Время - время приложения в UTC.
+CREATE TABLE login_throttles (
+    scope TEXT NOT NULL CHECK (scope IN ('account', 'ip')),
+    key TEXT NOT NULL,
+    failures INTEGER NOT NULL,
+    last_failure_at TIMESTAMP NOT NULL,
+    -- До этого момента вход не проверяется вовсе
+    blocked_until TIMESTAMP,
+    -- true - блокировка после N неудач, false - пауза между попытками
+    locked BOOLEAN NOT NULL DEFAULT FALSE,
+    PRIMARY KEY (scope, key)
+);
+
+CREATE INDEX login_throttles_last_failure_idx ON login_throttles (last_failure_at);
+
+CREATE TABLE security_events (
+    id BIGSERIAL PRIMARY KEY,
+    kind TEXT NOT NULL,
+    scope TEXT NOT NULL,
+    -- email или IP, к которому относится событие
+    subject TEXT NOT NULL,
+    -- IP запроса, вызвавшего событие; пусто для действий администратора
+    ip TEXT NOT NULL DEFAULT '',
+    -- Администратор, если событие - его действие
+    actor_id INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX security_events_created_idx ON security_events (created_at);
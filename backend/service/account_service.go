@@ -0,0 +1,245 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	usersdb "db200/internal/db/users"
+	"db200/internal/mail"
+	"db200/internal/password"
+	"db200/internal/store"
+)
+
+// Сроки жизни ссылок из писем по умолчанию
+const (
+	DefaultEmailVerificationTTL = 48 * time.Hour
+	DefaultPasswordResetTTL     = time.Hour
+)
+
+const (
+	// mailResendInterval - не чаще одного письма одного назначения за этот интервал
+	mailResendInterval = time.Minute
+	// mailSendTimeout - для писем, которые отправляются в фоне
+	mailSendTimeout = 30 * time.Second
+	mailTimeLayout  = "2006-01-02 15:04"
+)
+
+// ErrRateLimited - запрос повторён слишком быстро
+var ErrRateLimited = errors.New("too many requests, try again later")
+
+// AccountService - подтверждение email и сброс пароля по одноразовым ссылкам из писем
+type AccountService struct {
+	tokens *store.UserTokenStore
+	users  *store.UserStore
+	mailer mail.Mailer
+	params password.Params
+	// baseURL - адрес фронтенда, страницы /verify-email и /reset-password
+	// отправляют токен из ссылки на эндпоинты подтверждения
+	baseURL         string
+	verificationTTL time.Duration
+	resetTTL        time.Duration
+	now             func() time.Time
+}
+
+func NewAccountService(userTokenStore *store.UserTokenStore, userStore *store.UserStore, mailer mail.Mailer, params password.Params, baseURL string, verificationTTL, resetTTL time.Duration) *AccountService {
+	return &AccountService{
+		tokens:          userTokenStore,
+		users:           userStore,
+		mailer:          mailer,
+		params:          params,
+		baseURL:         strings.TrimRight(baseURL, "/"),
+		verificationTTL: verificationTTL,
+		resetTTL:        resetTTL,
+		now:             time.Now,
+	}
+}
+
+// RequestEmailVerification отправляет письмо со ссылкой подтверждения;
+// предыдущие ссылки перестают действовать
+func (s *AccountService) RequestEmailVerification(ctx context.Context, userID int32) error {
+	user, err := s.users.Get(ctx, userID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("service: request email verification: %w: user %d not found", ErrNotFound, userID)
+		}
+		return fmt.Errorf("service: request email verification: %w", err)
+	}
+	if user.EmailVerifiedAt.Valid {
+		return fmt.Errorf("service: request email verification: %w: email already verified", ErrConflict)
+	}
+
+	link, expiresAt, err := s.issue(ctx, user, store.UserTokenEmailVerification, "/verify-email", s.verificationTTL)
+	if err != nil {
+		return fmt.Errorf("service: request email verification: %w", err)
+	}
+	msg := mail.Message{
+		To:      user.Email,
+		Subject: "Confirm your email",
+		Body: fmt.Sprintf("Hello, %s!\n\nTo confirm your email address, open the link below:\n%s\n\n"+
+			"The link is valid until %s UTC. If you did not create an account, ignore this email.\n",
+			user.Name, link, expiresAt.Format(mailTimeLayout)),
+	}
+	if err := s.mailer.Send(ctx, msg); err != nil {
+		return fmt.Errorf("service: request email verification: %w", err)
+	}
+	return nil
+}
+
+// ConfirmEmail подтверждает email по токену из письма
+func (s *AccountService) ConfirmEmail(ctx context.Context, token string) error {
+	hash, err := hashSecretToken(token)
+	if err != nil {
+		return fmt.Errorf("service: confirm email: %w", err)
+	}
+
+	if _, err := s.tokens.VerifyEmail(ctx, hash, s.now().UTC()); err != nil {
+		return fmt.Errorf("service: confirm email: %w", userTokenError(err))
+	}
+	return nil
+}
+
+// RequestPasswordReset отправляет ссылку сброса пароля. Ответ одинаковый,
+// есть такой email или нет, а письмо уходит в фоне, чтобы и по времени
+// ответа нельзя было узнать, зарегистрирован ли адрес.
+func (s *AccountService) RequestPasswordReset(ctx context.Context, email string) error {
+	email = strings.TrimSpace(email)
+	if email == "" {
+		return fmt.Errorf("service: request password reset: %w: email is required", ErrInvalidInput)
+	}
+
+	user, err := s.users.GetByEmail(ctx, email)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil
+		}
+		return fmt.Errorf("service: request password reset: %w", err)
+	}
+
+	link, expiresAt, err := s.issue(ctx, user, store.UserTokenPasswordReset, "/reset-password", s.resetTTL)
+	if err != nil {
+		if errors.Is(err, ErrRateLimited) {
+			return nil
+		}
+		return fmt.Errorf("service: request password reset: %w", err)
+	}
+	msg := mail.Message{
+		To:      user.Email,
+		Subject: "Password reset",
+		Body: fmt.Sprintf("Hello, %s!\n\nTo set a new password, open the link below:\n%s\n\n"+
+			"The link is valid until %s UTC and works once. "+
+			"If you did not request a password reset, ignore this email.\n",
+			user.Name, link, expiresAt.Format(mailTimeLayout)),
+	}
+
+	go func() {
+		ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), mailSendTimeout)
+		defer cancel()
+		if err := s.mailer.Send(ctx, msg); err != nil {
+			logrus.WithError(err).WithField("user_id", user.ID).Error("send password reset email")
+		}
+	}()
+	return nil
+}
+
+// ResetPassword ставит новый пароль по токену из письма и завершает все сессии.
+// Возвращает id пользователя, чей пароль сменён. Токен проверяется до хэширования
+// пароля, чтобы перебор токенов не заставлял сервер считать argon2.
+func (s *AccountService) ResetPassword(ctx context.Context, token, newPassword string) (int32, error) {
+	hash, err := hashSecretToken(token)
+	if err != nil {
+		return 0, fmt.Errorf("service: reset password: %w", err)
+	}
+	if err := validatePassword(newPassword); err != nil {
+		return 0, fmt.Errorf("service: reset password: %w", err)
+	}
+	if _, err := s.tokens.CheckPasswordReset(ctx, hash, s.now().UTC()); err != nil {
+		return 0, fmt.Errorf("service: reset password: %w", userTokenError(err))
+	}
+
+	// Токен проверяется ещё раз при гашении: его могли использовать параллельно
+	passwordHash, err := password.Hash(newPassword, s.params)
+	if err != nil {
+		return 0, fmt.Errorf("service: reset password: %w", err)
+	}
+
+	used, err := s.tokens.ResetPassword(ctx, hash, s.now().UTC(), passwordHash)
+	if err != nil {
+		return 0, fmt.Errorf("service: reset password: %w", userTokenError(err))
+	}
+	logrus.WithField("user_id", used.UserID).Info("password reset, sessions revoked")
+	return used.UserID, nil
+}
+
+// RunExpiryJob раз в interval удаляет истёкшие токены из писем.
+// Блокируется до отмены ctx, запускать в отдельной горутине.
+func (s *AccountService) RunExpiryJob(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			count, err := s.tokens.DeleteExpired(ctx, s.now().UTC())
+			if err != nil {
+				logrus.WithError(err).Error("delete expired user tokens")
+				continue
+			}
+			if count > 0 {
+				logrus.WithField("deleted", count).Info("deleted expired user tokens")
+			}
+		}
+	}
+}
+
+// issue сохраняет новый токен назначения purpose со сроком ttl
+// и возвращает ссылку на страницу page с ним
+func (s *AccountService) issue(ctx context.Context, user usersdb.User, purpose, page string, ttl time.Duration) (string, time.Time, error) {
+	now := s.now().UTC()
+	recent, err := s.tokens.CountSince(ctx, user.ID, purpose, now.Add(-mailResendInterval))
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	if recent > 0 {
+		return "", time.Time{}, ErrRateLimited
+	}
+
+	token, hash, err := newSecretToken()
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	expiresAt := now.Add(ttl)
+	_, err = s.tokens.Create(ctx, usersdb.InsertUserTokenParams{
+		UserID:    user.ID,
+		Purpose:   purpose,
+		TokenHash: hash,
+		Email:     user.Email,
+		CreatedAt: now,
+		ExpiresAt: expiresAt,
+	})
+	if err != nil {
+		return "", time.Time{}, err
+	}
+
+	return s.baseURL + page + "?" + url.Values{"token": {token}}.Encode(), expiresAt, nil
+}
+
+// userTokenError сводит причины отказа к ErrInvalidToken: клиенту нужен новый токен
+func userTokenError(err error) error {
+	switch {
+	case errors.Is(err, store.ErrUserTokenNotFound),
+		errors.Is(err, store.ErrUserTokenUsed),
+		errors.Is(err, store.ErrUserTokenExpired),
+		errors.Is(err, store.ErrUserEmailChanged):
+		return ErrInvalidToken
+	}
+	return err
+}
@@ -0,0 +1,193 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/mail"
+	"strings"
+	"sync"
+	"unicode/utf8"
+
+	"github.com/sirupsen/logrus"
+
+	usersdb "db200/internal/db/users"
+	"db200/internal/password"
+	"db200/internal/store"
+)
+
+// Ограничения на имя и пароль в символах
+const (
+	minUserNameLength = 3
+	maxUserNameLength = 50
+	minPasswordLength = 8
+	maxPasswordLength = 128
+)
+
+// maxEmailLength - максимальная длина адреса в байтах (RFC 5321)
+const maxEmailLength = 254
+
+// ErrBadCredentials - неверный email или пароль; что именно не так, не сообщаем
+var ErrBadCredentials = errors.New("email or password is incorrect")
+
+type AuthService struct {
+	store *store.UserStore
+	// params - параметры для новых хэшей; старые хэши пересчитываются при входе
+	params password.Params
+
+	// dummyHash сверяется, когда пользователя нет, чтобы по времени ответа
+	// нельзя было узнать, зарегистрирован ли email
+	dummyOnce sync.Once
+	dummyHash string
+}
+
+func NewAuthService(userStore *store.UserStore, params password.Params) *AuthService {
+	return &AuthService{
+		store:  userStore,
+		params: params,
+	}
+}
+
+type RegisterInput struct {
+	Email    string
+	Name     string
+	Password string
+}
+
+func (input *RegisterInput) normalize() error {
+	input.Name = strings.TrimSpace(input.Name)
+	input.Email = strings.TrimSpace(input.Email)
+	if n := utf8.RuneCountInString(input.Name); n < minUserNameLength || n > maxUserNameLength {
+		return fmt.Errorf("%w: name must be %d to %d characters", ErrInvalidInput, minUserNameLength, maxUserNameLength)
+	}
+	if len(input.Email) > maxEmailLength {
+		return fmt.Errorf("%w: email must be at most %d bytes", ErrInvalidInput, maxEmailLength)
+	}
+	if address, err := mail.ParseAddress(input.Email); err != nil || address.Address != input.Email {
+		return fmt.Errorf("%w: invalid email %q", ErrInvalidInput, input.Email)
+	}
+	return validatePassword(input.Password)
+}
+
+func validatePassword(s string) error {
+	if n := utf8.RuneCountInString(s); n < minPasswordLength || n > maxPasswordLength {
+		return fmt.Errorf("%w: password must be %d to %d characters", ErrInvalidInput, minPasswordLength, maxPasswordLength)
+	}
+	return nil
+}
+
+// Register создаёт пользователя; пароль хранится только argon2id-хэшем
+func (s *AuthService) Register(ctx context.Context, input RegisterInput) (usersdb.User, error) {
+	if err := input.normalize(); err != nil {
+		return usersdb.User{}, fmt.Errorf("service: register: %w", err)
+	}
+
+	hash, err := password.Hash(input.Password, s.params)
+	if err != nil {
+		return usersdb.User{}, fmt.Errorf("service: register: %w", err)
+	}
+
+	user, err := s.store.Create(ctx, input.Email, input.Name, hash)
+	if err != nil {
+		if errors.Is(err, store.ErrEmailTaken) {
+			return user, fmt.Errorf("service: register: %w: email %q already registered", ErrConflict, input.Email)
+		}
+		return user, fmt.Errorf("service: register: %w", err)
+	}
+	return user, nil
+}
+
+// Authenticate проверяет email и пароль. Если хэш посчитан со старыми
+// параметрами или другим алгоритмом, он тут же пересчитывается с текущими.
+func (s *AuthService) Authenticate(ctx context.Context, email, plain string) (usersdb.User, error) {
+	email = strings.TrimSpace(email)
+	if email == "" || plain == "" || len(email) > maxEmailLength {
+		return usersdb.User{}, fmt.Errorf("service: authenticate: %w", ErrBadCredentials)
+	}
+
+	user, err := s.store.GetByEmail(ctx, email)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			s.verifyDummy(plain)
+			return usersdb.User{}, fmt.Errorf("service: authenticate: %w", ErrBadCredentials)
+		}
+		return usersdb.User{}, fmt.Errorf("service: authenticate: %w", err)
+	}
+
+	// Пароля нет (аккаунт перенесён без него) - как неверный пароль
+	if user.PasswordHash == "" {
+		s.verifyDummy(plain)
+		return usersdb.User{}, fmt.Errorf("service: authenticate: %w", ErrBadCredentials)
+	}
+
+	ok, err := password.Verify(plain, user.PasswordHash)
+	if err != nil {
+		return usersdb.User{}, fmt.Errorf("service: authenticate user %d: %w", user.ID, err)
+	}
+	if !ok {
+		return usersdb.User{}, fmt.Errorf("service: authenticate: %w", ErrBadCredentials)
+	}
+
+	if password.NeedsRehash(user.PasswordHash, s.params) {
+		s.rehash(ctx, &user, plain)
+	}
+	return user, nil
+}
+
+func (s *AuthService) Get(ctx context.Context, id int32) (usersdb.User, error) {
+	user, err := s.store.Get(ctx, id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return user, fmt.Errorf("service: get user: %w: user %d not found", ErrNotFound, id)
+		}
+		return user, fmt.Errorf("service: get user %d: %w", id, err)
+	}
+	return user, nil
+}
+
+// GetByEmail ищет пользователя без учёта регистра email
+func (s *AuthService) GetByEmail(ctx context.Context, email string) (usersdb.User, error) {
+	email = strings.TrimSpace(email)
+	user, err := s.store.GetByEmail(ctx, email)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return user, fmt.Errorf("service: get user: %w: email %q not found", ErrNotFound, email)
+		}
+		return user, fmt.Errorf("service: get user by email: %w", err)
+	}
+	return user, nil
+}
+
+// rehash пересчитывает хэш после успешного входа. Ошибка не мешает войти:
+// старый хэш остаётся рабочим, пересчёт повторится при следующем входе.
+func (s *AuthService) rehash(ctx context.Context, user *usersdb.User, plain string) {
+	hash, err := password.Hash(plain, s.params)
+	if err != nil {
+		logrus.WithError(err).WithField("user_id", user.ID).Error("rehash password")
+		return
+	}
+
+	updated, err := s.store.Rehash(ctx, user.ID, user.PasswordHash, hash)
+	if err != nil {
+		logrus.WithError(err).WithField("user_id", user.ID).Error("rehash password")
+		return
+	}
+	if updated {
+		user.PasswordHash = hash
+	}
+}
+
+func (s *AuthService) verifyDummy(plain string) {
+	s.dummyOnce.Do(func() {
+		hash, err := password.Hash("dummy password", s.params)
+		if err != nil {
+			logrus.WithError(err).Error("hash dummy password")
+			return
+		}
+		s.dummyHash = hash
+	})
+	if s.dummyHash != "" {
+		_, _ = password.Verify(plain, s.dummyHash)
+	}
+}
@@ -0,0 +1,335 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	productsdb "db200/internal/db/products"
+	"db200/internal/slug"
+	"db200/internal/store"
+)
+
+const (
+	maxCategoryNameLength = 100
+	maxTagLength          = 50
+	// maxProductTags - ограничение на количество тегов у продукта и в фильтре
+	maxProductTags = 50
+)
+
+type CategoryService struct {
+	store *store.CategoryStore
+}
+
+func NewCategoryService(categoryStore *store.CategoryStore) *CategoryService {
+	return &CategoryService{
+		store: categoryStore,
+	}
+}
+
+// CategoryInput - если Slug пустой, он строится из Name; ParentID == 0 - корневая категория
+type CategoryInput struct {
+	ParentID int32
+	Slug     string
+	Name     string
+}
+
+func (input *CategoryInput) normalize() error {
+	input.Name = strings.TrimSpace(input.Name)
+	if input.Name == "" {
+		return fmt.Errorf("%w: name is required", ErrInvalidInput)
+	}
+	if utf8.RuneCountInString(input.Name) > maxCategoryNameLength {
+		return fmt.Errorf("%w: name too long", ErrInvalidInput)
+	}
+	if input.ParentID < 0 {
+		return fmt.Errorf("%w: invalid parent id %d", ErrInvalidInput, input.ParentID)
+	}
+	if input.Slug == "" {
+		input.Slug = slug.Make(input.Name)
+	}
+	if !slug.Valid(input.Slug) {
+		return fmt.Errorf("%w: malformed slug %q", ErrInvalidInput, input.Slug)
+	}
+	return nil
+}
+
+// CategoryNode - категория с подкатегориями, для навигации витрины
+type CategoryNode struct {
+	productsdb.Category
+	Children []CategoryNode
+}
+
+func (s *CategoryService) CreateCategory(ctx context.Context, input CategoryInput) (productsdb.Category, error) {
+	if err := input.normalize(); err != nil {
+		return productsdb.Category{}, fmt.Errorf("service: create category: %w", err)
+	}
+
+	category, err := s.store.CreateCategory(ctx, productsdb.CreateCategoryParams{
+		ParentID: nullInt32(input.ParentID),
+		Slug:     input.Slug,
+		Name:     input.Name,
+	})
+	if err != nil {
+		return category, fmt.Errorf("service: create category: %w", categoryStoreError(err))
+	}
+	return category, nil
+}
+
+func (s *CategoryService) GetCategory(ctx context.Context, id int32) (productsdb.Category, error) {
+	category, err := s.store.GetCategory(ctx, id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return category, fmt.Errorf("service: get category: %w: category %d not found", ErrNotFound, id)
+		}
+		return category, fmt.Errorf("service: get category %d: %w", id, err)
+	}
+	return category, nil
+}
+
+func (s *CategoryService) ListCategories(ctx context.Context) ([]productsdb.Category, error) {
+	categories, err := s.store.ListCategories(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("service: list categories: %w", err)
+	}
+	return categories, nil
+}
+
+// CategoryTree - все категории деревом, внутри уровня порядок по имени
+func (s *CategoryService) CategoryTree(ctx context.Context) ([]CategoryNode, error) {
+	categories, err := s.ListCategories(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	children := make(map[int32][]productsdb.Category)
+	var roots []productsdb.Category
+	for _, c := range categories {
+		if c.ParentID.Valid {
+			children[c.ParentID.Int32] = append(children[c.ParentID.Int32], c)
+		} else {
+			roots = append(roots, c)
+		}
+	}
+
+	var build func([]productsdb.Category) []CategoryNode
+	build = func(level []productsdb.Category) []CategoryNode {
+		nodes := make([]CategoryNode, 0, len(level))
+		for _, c := range level {
+			nodes = append(nodes, CategoryNode{
+				Category: c,
+				Children: build(children[c.ID]),
+			})
+		}
+		return nodes
+	}
+
+	return build(roots), nil
+}
+
+// UpdateCategory заменяет название, slug и родителя категории
+func (s *CategoryService) UpdateCategory(ctx context.Context, id int32, input CategoryInput) (productsdb.Category, error) {
+	if id <= 0 {
+		return productsdb.Category{}, fmt.Errorf("service: update category: %w: invalid id %d", ErrInvalidInput, id)
+	}
+	if err := input.normalize(); err != nil {
+		return productsdb.Category{}, fmt.Errorf("service: update category: %w", err)
+	}
+
+	rows, err := s.store.UpdateCategory(ctx, productsdb.UpdateCategoryParams{
+		ParentID: nullInt32(input.ParentID),
+		Slug:     input.Slug,
+		Name:     input.Name,
+		ID:       id,
+	})
+	if err != nil {
+		return productsdb.Category{}, fmt.Errorf("service: update category: %w", categoryStoreError(err))
+	}
+	if rows == 0 {
+		return productsdb.Category{}, fmt.Errorf("service: update category: %w: category %d not found", ErrNotFound, id)
+	}
+
+	return s.GetCategory(ctx, id)
+}
+
+// DeleteCategory удаляет только листовую категорию
+func (s *CategoryService) DeleteCategory(ctx context.Context, id int32) error {
+	if id <= 0 {
+		return fmt.Errorf("service: delete category: %w: invalid id %d", ErrInvalidInput, id)
+	}
+
+	rows, err := s.store.DeleteCategory(ctx, id)
+	if err != nil {
+		return fmt.Errorf("service: delete category: %w", categoryStoreError(err))
+	}
+	if rows == 0 {
+		return fmt.Errorf("service: delete category: %w: category %d not found", ErrNotFound, id)
+	}
+	return nil
+}
+
+func (s *CategoryService) CreateTag(ctx context.Context, name string) (productsdb.Tag, error) {
+	name, err := normalizeTag(name)
+	if err != nil {
+		return productsdb.Tag{}, fmt.Errorf("service: create tag: %w", err)
+	}
+
+	tag, err := s.store.CreateTag(ctx, name)
+	if err != nil {
+		return tag, fmt.Errorf("service: create tag: %w", categoryStoreError(err))
+	}
+	return tag, nil
+}
+
+func (s *CategoryService) ListTags(ctx context.Context) ([]productsdb.Tag, error) {
+	tags, err := s.store.ListTags(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("service: list tags: %w", err)
+	}
+	return tags, nil
+}
+
+func (s *CategoryService) RenameTag(ctx context.Context, id int32, name string) (productsdb.Tag, error) {
+	if id <= 0 {
+		return productsdb.Tag{}, fmt.Errorf("service: rename tag: %w: invalid id %d", ErrInvalidInput, id)
+	}
+	name, err := normalizeTag(name)
+	if err != nil {
+		return productsdb.Tag{}, fmt.Errorf("service: rename tag: %w", err)
+	}
+
+	rows, err := s.store.RenameTag(ctx, id, name)
+	if err != nil {
+		return productsdb.Tag{}, fmt.Errorf("service: rename tag: %w", categoryStoreError(err))
+	}
+	if rows == 0 {
+		return productsdb.Tag{}, fmt.Errorf("service: rename tag: %w: tag %d not found", ErrNotFound, id)
+	}
+
+	return productsdb.Tag{ID: id, Name: name}, nil
+}
+
+func (s *CategoryService) DeleteTag(ctx context.Context, id int32) error {
+	if id <= 0 {
+		return fmt.Errorf("service: delete tag: %w: invalid id %d", ErrInvalidInput, id)
+	}
+
+	rows, err := s.store.DeleteTag(ctx, id)
+	if err != nil {
+		return fmt.Errorf("service: delete tag: %w", err)
+	}
+	if rows == 0 {
+		return fmt.Errorf("service: delete tag: %w: tag %d not found", ErrNotFound, id)
+	}
+	return nil
+}
+
+// SetProductCategories заменяет категории продукта; пустой список снимает все
+func (s *CategoryService) SetProductCategories(ctx context.Context, productID int32, categoryIDs []int32) ([]productsdb.Category, error) {
+	if productID <= 0 {
+		return nil, fmt.Errorf("service: set product categories: %w: invalid id %d", ErrInvalidInput, productID)
+	}
+	for _, id := range categoryIDs {
+		if id <= 0 {
+			return nil, fmt.Errorf("service: set product categories: %w: invalid category id %d",
+				ErrInvalidInput, id)
+		}
+	}
+
+	if err := s.store.SetProductCategories(ctx, productID, categoryIDs); err != nil {
+		return nil, fmt.Errorf("service: set product categories: %w", categoryStoreError(err))
+	}
+	return s.ProductCategories(ctx, productID)
+}
+
+func (s *CategoryService) ProductCategories(ctx context.Context, productID int32) ([]productsdb.Category, error) {
+	categories, err := s.store.ProductCategories(ctx, productID)
+	if err != nil {
+		return nil, fmt.Errorf("service: product categories: %w", err)
+	}
+	return categories, nil
+}
+
+// SetProductTags заменяет теги продукта, новые теги создаются автоматически
+func (s *CategoryService) SetProductTags(ctx context.Context, productID int32, names []string) ([]productsdb.Tag, error) {
+	if productID <= 0 {
+		return nil, fmt.Errorf("service: set product tags: %w: invalid id %d", ErrInvalidInput, productID)
+	}
+	names, err := normalizeTags(names)
+	if err != nil {
+		return nil, fmt.Errorf("service: set product tags: %w", err)
+	}
+
+	tags, err := s.store.SetProductTags(ctx, productID, names)
+	if err != nil {
+		return nil, fmt.Errorf("service: set product tags: %w", categoryStoreError(err))
+	}
+	return tags, nil
+}
+
+func (s *CategoryService) ProductTags(ctx context.Context, productID int32) ([]productsdb.Tag, error) {
+	tags, err := s.store.ProductTags(ctx, productID)
+	if err != nil {
+		return nil, fmt.Errorf("service: product tags: %w", err)
+	}
+	return tags, nil
+}
+
+// categoryStoreError переводит ошибки хранилища категорий в ошибки сервиса
+func categoryStoreError(err error) error {
+	switch {
+	case errors.Is(err, store.ErrSlugTaken), errors.Is(err, store.ErrNameTaken):
+		return fmt.Errorf("%w: %v", ErrConflict, err)
+	case errors.Is(err, store.ErrCategoryHasChildren):
+		return fmt.Errorf("%w: %v", ErrConflict, err)
+	case errors.Is(err, store.ErrCategoryCycle):
+		return fmt.Errorf("%w: %v", ErrInvalidInput, err)
+	case errors.Is(err, store.ErrReferenceNotFound):
+		return fmt.Errorf("%w: %v", ErrNotFound, err)
+	}
+	return err
+}
+
+// normalizeTag - теги храним в нижнем регистре без пробелов по краям
+func normalizeTag(name string) (string, error) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	if name == "" {
+		return "", fmt.Errorf("%w: empty tag", ErrInvalidInput)
+	}
+	if utf8.RuneCountInString(name) > maxTagLength {
+		return "", fmt.Errorf("%w: tag %q too long", ErrInvalidInput, name)
+	}
+	return name, nil
+}
+
+// normalizeTags нормализует и убирает повторы, порядок сохраняется
+func normalizeTags(names []string) ([]string, error) {
+	if len(names) > maxProductTags {
+		return nil, fmt.Errorf("%w: too many tags, max %d", ErrInvalidInput, maxProductTags)
+	}
+
+	result := make([]string, 0, len(names))
+	seen := make(map[string]bool, len(names))
+	for _, name := range names {
+		tag, err := normalizeTag(name)
+		if err != nil {
+			return nil, err
+		}
+		if seen[tag] {
+			continue
+		}
+		seen[tag] = true
+		result = append(result, tag)
+	}
+	return result, nil
+}
+
+func nullInt32(v int32) sql.NullInt32 {
+	return sql.NullInt32{
+		Int32: v,
+		Valid: v != 0,
+	}
+}
@@ -0,0 +1,84 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+
+	productsdb "db200/internal/db/products"
+	"db200/internal/money"
+	"db200/internal/store"
+)
+
+// SetExchangeRate сохраняет курс: сколько единиц quote стоит одна единица base.
+// rate - десятичная строка, например "0.0105".
+func (s *ProductService) SetExchangeRate(ctx context.Context, base, quote, rate string) (productsdb.ExchangeRate, error) {
+	baseCurrency, err := money.ParseCurrency(base)
+	if err != nil {
+		return productsdb.ExchangeRate{}, fmt.Errorf("service: set exchange rate: %w: %v", ErrInvalidInput, err)
+	}
+	quoteCurrency, err := money.ParseCurrency(quote)
+	if err != nil {
+		return productsdb.ExchangeRate{}, fmt.Errorf("service: set exchange rate: %w: %v", ErrInvalidInput, err)
+	}
+	if baseCurrency == quoteCurrency {
+		return productsdb.ExchangeRate{}, fmt.Errorf("service: set exchange rate: %w: base and quote are the same",
+			ErrInvalidInput)
+	}
+	value, err := money.ParseRate(rate)
+	if err != nil {
+		return productsdb.ExchangeRate{}, fmt.Errorf("service: set exchange rate: %w: %v", ErrInvalidInput, err)
+	}
+
+	saved, err := s.store.SetExchangeRate(ctx, baseCurrency, quoteCurrency, value)
+	if err != nil {
+		return saved, fmt.Errorf("service: set exchange rate: %w", err)
+	}
+	return saved, nil
+}
+
+func (s *ProductService) ListExchangeRates(ctx context.Context) ([]productsdb.ExchangeRate, error) {
+	rates, err := s.store.ListExchangeRates(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("service: list exchange rates: %w", err)
+	}
+	return rates, nil
+}
+
+// convertProducts пересчитывает цены в валюту to. Пустая to - без изменений.
+// Курс для каждой исходной валюты читается из базы один раз на вызов.
+func (s *ProductService) convertProducts(ctx context.Context, products []productsdb.Product, to string) ([]productsdb.Product, error) {
+	if to == "" || len(products) == 0 {
+		return products, nil
+	}
+	target, err := money.ParseCurrency(to)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidInput, err)
+	}
+
+	rates := make(map[money.Currency]*big.Rat)
+	for i, product := range products {
+		from := money.Currency(product.Currency)
+		rate, ok := rates[from]
+		if !ok {
+			rate, err = s.store.ExchangeRate(ctx, from, target)
+			if err != nil {
+				if errors.Is(err, store.ErrRateNotFound) {
+					return nil, fmt.Errorf("%w: no exchange rate %s/%s", ErrInvalidInput, from, target)
+				}
+				return nil, err
+			}
+			rates[from] = rate
+		}
+
+		price, err := money.New(product.PriceCents, from).Convert(target, rate)
+		if err != nil {
+			return nil, fmt.Errorf("convert product %d price: %w", product.ID, err)
+		}
+		products[i].PriceCents = price.Amount
+		products[i].Currency = string(price.Currency)
+	}
+
+	return products, nil
+}
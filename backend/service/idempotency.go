@@ -0,0 +1,91 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	idempotencydb "db200/internal/db/idempotency"
+	"db200/internal/store"
+)
+
+// DefaultIdempotencyLockTimeout - через сколько незавершённый запрос считается брошенным
+const DefaultIdempotencyLockTimeout = time.Minute
+
+// Ошибки ключей идемпотентности
+var (
+	ErrIdempotencyKeyReused     = fmt.Errorf("%w: idempotency key reused with a different request", ErrInvalidInput)
+	ErrIdempotencyKeyInProgress = fmt.Errorf("%w: request with this idempotency key is in progress", ErrConflict)
+)
+
+type IdempotencyService struct {
+	store       *store.IdempotencyStore
+	lockTimeout time.Duration
+}
+
+func NewIdempotencyService(idempotencyStore *store.IdempotencyStore, lockTimeout time.Duration) *IdempotencyService {
+	if lockTimeout <= 0 {
+		lockTimeout = DefaultIdempotencyLockTimeout
+	}
+	return &IdempotencyService{
+		store:       idempotencyStore,
+		lockTimeout: lockTimeout,
+	}
+}
+
+// Begin занимает ключ; started == false - запрос уже выполнен и в записи его ответ
+func (s *IdempotencyService) Begin(ctx context.Context, scope, key, fingerprint string) (idempotencydb.IdempotencyKey, bool, error) {
+	record, started, err := s.store.Begin(ctx, scope, key, fingerprint, s.lockTimeout)
+	if err != nil {
+		switch {
+		case errors.Is(err, store.ErrIdempotencyKeyMismatch):
+			return record, false, ErrIdempotencyKeyReused
+		case errors.Is(err, store.ErrIdempotencyKeyInProgress):
+			return record, false, ErrIdempotencyKeyInProgress
+		}
+		return record, false, fmt.Errorf("service: begin idempotent request: %w", err)
+	}
+	return record, started, nil
+}
+
+// Complete сохраняет ответ; lockedBy - record.LockedBy из Begin
+func (s *IdempotencyService) Complete(ctx context.Context, scope, key, lockedBy string, statusCode int, contentType string, body []byte) error {
+	if err := s.store.Complete(ctx, scope, key, lockedBy, statusCode, contentType, body); err != nil {
+		return fmt.Errorf("service: complete idempotent request: %w", err)
+	}
+	return nil
+}
+
+// Release освобождает ключ, если его ещё держит этот запрос
+func (s *IdempotencyService) Release(ctx context.Context, scope, key, lockedBy string) error {
+	if err := s.store.Release(ctx, scope, key, lockedBy); err != nil {
+		return fmt.Errorf("service: release idempotent request: %w", err)
+	}
+	return nil
+}
+
+// RunExpiryJob раз в interval удаляет ключи старше ttl.
+// Блокируется до отмены ctx, запускать в отдельной горутине.
+func (s *IdempotencyService) RunExpiryJob(ctx context.Context, interval, ttl time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			count, err := s.store.DeleteExpired(ctx, ttl)
+			if err != nil {
+				logrus.WithError(err).Error("delete expired idempotency keys")
+				continue
+			}
+			if count > 0 {
+				logrus.WithField("deleted", count).Info("deleted expired idempotency keys")
+			}
+		}
+	}
+}
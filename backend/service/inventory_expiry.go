@@ -0,0 +1,31 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// RunExpiryJob раз в interval закрывает просроченные резервы и возвращает товар в доступный остаток.
+// Блокируется до отмены ctx, запускать в отдельной горутине.
+func (s *InventoryService) RunExpiryJob(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			count, err := s.ExpireReservations(ctx)
+			if err != nil {
+				logrus.WithError(err).Error("expire stock reservations")
+				continue
+			}
+			if count > 0 {
+				logrus.WithField("expired", count).Info("expired stock reservations")
+			}
+		}
+	}
+}
@@ -0,0 +1,231 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	productsdb "db200/internal/db/products"
+	"db200/internal/store"
+)
+
+const (
+	// DefaultReservationTTL - срок резерва, если клиент его не указал
+	DefaultReservationTTL = 15 * time.Minute
+	MaxReservationTTL     = 24 * time.Hour
+
+	maxMovementNoteLength = 500
+)
+
+// Ошибки остатков - частные случаи ErrConflict
+var (
+	ErrInsufficientStock    = fmt.Errorf("%w: insufficient stock", ErrConflict)
+	ErrReservationNotActive = fmt.Errorf("%w: reservation is not active", ErrConflict)
+	ErrReservationExpired   = fmt.Errorf("%w: reservation expired", ErrConflict)
+)
+
+type InventoryService struct {
+	store *store.InventoryStore
+}
+
+func NewInventoryService(inventoryStore *store.InventoryStore) *InventoryService {
+	return &InventoryService{
+		store: inventoryStore,
+	}
+}
+
+// StockLevel - остаток продукта, Available = OnHand - Reserved
+type StockLevel struct {
+	ProductID int32
+	OnHand    int64
+	Reserved  int64
+	Available int64
+}
+
+func (s *InventoryService) Stock(ctx context.Context, productID int32) (StockLevel, error) {
+	if productID <= 0 {
+		return StockLevel{}, fmt.Errorf("service: stock: %w: invalid id %d", ErrInvalidInput, productID)
+	}
+
+	inventory, err := s.store.Get(ctx, productID)
+	if err != nil {
+		return StockLevel{}, fmt.Errorf("service: stock: %w", err)
+	}
+	return toStockLevel(inventory), nil
+}
+
+// Receive - приход товара на склад
+func (s *InventoryService) Receive(ctx context.Context, productID int32, quantity int64, note string) (StockLevel, error) {
+	if quantity <= 0 {
+		return StockLevel{}, fmt.Errorf("service: receive stock: %w: quantity must be positive, got %d",
+			ErrInvalidInput, quantity)
+	}
+	return s.changeStock(ctx, "receive stock", productID, quantity, store.MovementReceipt, note)
+}
+
+// Sell - продажа без резерва, списывает только из доступного остатка
+func (s *InventoryService) Sell(ctx context.Context, productID int32, quantity int64, note string) (StockLevel, error) {
+	if quantity <= 0 {
+		return StockLevel{}, fmt.Errorf("service: sell stock: %w: quantity must be positive, got %d",
+			ErrInvalidInput, quantity)
+	}
+	return s.changeStock(ctx, "sell stock", productID, -quantity, store.MovementSale, note)
+}
+
+// Adjust - корректировка по инвентаризации, delta со знаком
+func (s *InventoryService) Adjust(ctx context.Context, productID int32, delta int64, note string) (StockLevel, error) {
+	if delta == 0 {
+		return StockLevel{}, fmt.Errorf("service: adjust stock: %w: delta must not be zero", ErrInvalidInput)
+	}
+	if strings.TrimSpace(note) == "" {
+		return StockLevel{}, fmt.Errorf("service: adjust stock: %w: note is required for adjustments",
+			ErrInvalidInput)
+	}
+	return s.changeStock(ctx, "adjust stock", productID, delta, store.MovementAdjustment, note)
+}
+
+func (s *InventoryService) changeStock(ctx context.Context, op string, productID int32, delta int64, kind, note string) (StockLevel, error) {
+	if productID <= 0 {
+		return StockLevel{}, fmt.Errorf("service: %s: %w: invalid id %d", op, ErrInvalidInput, productID)
+	}
+	note = strings.TrimSpace(note)
+	if len(note) > maxMovementNoteLength {
+		return StockLevel{}, fmt.Errorf("service: %s: %w: note too long", op, ErrInvalidInput)
+	}
+
+	inventory, err := s.store.ChangeStock(ctx, productID, delta, kind, note)
+	if err != nil {
+		return StockLevel{}, fmt.Errorf("service: %s: %w", op, inventoryStoreError(err))
+	}
+	return toStockLevel(inventory), nil
+}
+
+type ReserveInput struct {
+	ProductID int32
+	Quantity  int64
+	// TTL - срок резерва, 0 - DefaultReservationTTL
+	TTL time.Duration
+}
+
+// Reserve резервирует товар; без Commit резерв истекает через TTL
+func (s *InventoryService) Reserve(ctx context.Context, input ReserveInput) (productsdb.StockReservation, error) {
+	if input.ProductID <= 0 {
+		return productsdb.StockReservation{}, fmt.Errorf("service: reserve stock: %w: invalid id %d",
+			ErrInvalidInput, input.ProductID)
+	}
+	if input.Quantity <= 0 {
+		return productsdb.StockReservation{}, fmt.Errorf("service: reserve stock: %w: quantity must be positive, got %d",
+			ErrInvalidInput, input.Quantity)
+	}
+	if input.TTL == 0 {
+		input.TTL = DefaultReservationTTL
+	}
+	if input.TTL < time.Second || input.TTL > MaxReservationTTL {
+		return productsdb.StockReservation{}, fmt.Errorf("service: reserve stock: %w: ttl must be between 1s and %s",
+			ErrInvalidInput, MaxReservationTTL)
+	}
+
+	reservation, err := s.store.Reserve(ctx, input.ProductID, input.Quantity, int32(input.TTL/time.Second))
+	if err != nil {
+		return reservation, fmt.Errorf("service: reserve stock: %w", inventoryStoreError(err))
+	}
+	return reservation, nil
+}
+
+func (s *InventoryService) GetReservation(ctx context.Context, id int64) (productsdb.StockReservation, error) {
+	if id <= 0 {
+		return productsdb.StockReservation{}, fmt.Errorf("service: get reservation: %w: invalid id %d",
+			ErrInvalidInput, id)
+	}
+
+	reservation, err := s.store.GetReservation(ctx, id)
+	if err != nil {
+		return reservation, fmt.Errorf("service: get reservation: %w", inventoryStoreError(err))
+	}
+	return reservation, nil
+}
+
+// Commit списывает зарезервированный товар
+func (s *InventoryService) Commit(ctx context.Context, id int64) (productsdb.StockReservation, error) {
+	if id <= 0 {
+		return productsdb.StockReservation{}, fmt.Errorf("service: commit reservation: %w: invalid id %d",
+			ErrInvalidInput, id)
+	}
+
+	reservation, err := s.store.Commit(ctx, id)
+	if err != nil {
+		return reservation, fmt.Errorf("service: commit reservation: %w", inventoryStoreError(err))
+	}
+	return reservation, nil
+}
+
+// Release возвращает зарезервированный товар в доступный остаток
+func (s *InventoryService) Release(ctx context.Context, id int64) (productsdb.StockReservation, error) {
+	if id <= 0 {
+		return productsdb.StockReservation{}, fmt.Errorf("service: release reservation: %w: invalid id %d",
+			ErrInvalidInput, id)
+	}
+
+	reservation, err := s.store.Release(ctx, id)
+	if err != nil {
+		return reservation, fmt.Errorf("service: release reservation: %w", inventoryStoreError(err))
+	}
+	return reservation, nil
+}
+
+// ExpireReservations закрывает все просроченные резервы
+func (s *InventoryService) ExpireReservations(ctx context.Context) (int64, error) {
+	count, err := s.store.ExpireReservations(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("service: expire reservations: %w", err)
+	}
+	return count, nil
+}
+
+// Movements - журнал движений товара, новые записи первыми
+func (s *InventoryService) Movements(ctx context.Context, productID, limit int32) ([]productsdb.StockMovement, error) {
+	if productID <= 0 {
+		return nil, fmt.Errorf("service: stock movements: %w: invalid id %d", ErrInvalidInput, productID)
+	}
+	if limit <= 0 {
+		limit = store.MaxListLimit
+	}
+	if limit > store.MaxListLimit {
+		return nil, fmt.Errorf("service: stock movements: %w: limit too large %d", ErrInvalidInput, limit)
+	}
+
+	movements, err := s.store.Movements(ctx, productID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("service: stock movements: %w", err)
+	}
+	return movements, nil
+}
+
+// inventoryStoreError переводит ошибки хранилища остатков в ошибки сервиса
+func inventoryStoreError(err error) error {
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		return fmt.Errorf("%w: reservation not found", ErrNotFound)
+	case errors.Is(err, store.ErrReferenceNotFound):
+		return fmt.Errorf("%w: product not found", ErrNotFound)
+	case errors.Is(err, store.ErrInsufficientStock):
+		return ErrInsufficientStock
+	case errors.Is(err, store.ErrReservationExpired):
+		return ErrReservationExpired
+	case errors.Is(err, store.ErrReservationNotActive):
+		return fmt.Errorf("%w: %v", ErrReservationNotActive, err)
+	}
+	return err
+}
+
+func toStockLevel(inventory productsdb.Inventory) StockLevel {
+	return StockLevel{
+		ProductID: inventory.ProductID,
+		OnHand:    inventory.OnHand,
+		Reserved:  inventory.Reserved,
+		Available: inventory.OnHand - inventory.Reserved,
+	}
+}
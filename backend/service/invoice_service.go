@@ -0,0 +1,344 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/mail"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	paymentsdb "db200/internal/db/payments"
+	productsdb "db200/internal/db/products"
+	"db200/internal/money"
+	"db200/internal/store"
+)
+
+// InvoiceStatus - этап жизненного цикла счёта:
+// draft -> issued -> paid; draft и issued можно аннулировать (void).
+// Строки и скидку можно менять только у черновика.
+type InvoiceStatus string
+
+const (
+	InvoiceDraft  InvoiceStatus = "draft"
+	InvoiceIssued InvoiceStatus = "issued"
+	InvoicePaid   InvoiceStatus = "paid"
+	InvoiceVoid   InvoiceStatus = "void"
+)
+
+// maxTaxRateBp - 100% в базисных пунктах
+const maxTaxRateBp = 10000
+
+// maxCustomerNameLength - ограничение на имя покупателя в символах
+const maxCustomerNameLength = 200
+
+// Ошибки счетов
+var (
+	ErrInvoiceNotDraft         = fmt.Errorf("%w: invoice is not a draft", ErrConflict)
+	ErrInvoiceNotPayable       = fmt.Errorf("%w: invoice is not open for payment", ErrConflict)
+	ErrInvoiceEmpty            = fmt.Errorf("%w: invoice has no lines", ErrConflict)
+	ErrInvoiceHasPayments      = fmt.Errorf("%w: invoice has captured payments", ErrConflict)
+	ErrDiscountExceedsSubtotal = fmt.Errorf("%w: discount exceeds subtotal", ErrConflict)
+)
+
+// InvoiceProducts - откуда счёт берёт цену продукта; реализует *ProductService
+type InvoiceProducts interface {
+	GetInCurrency(ctx context.Context, id int32, currency string) (productsdb.Product, error)
+}
+
+type InvoiceService struct {
+	store    *store.InvoiceStore
+	products InvoiceProducts
+	// now - часы для года в номере счёта
+	now func() time.Time
+}
+
+func NewInvoiceService(invoiceStore *store.InvoiceStore, products InvoiceProducts) *InvoiceService {
+	return &InvoiceService{
+		store:    invoiceStore,
+		products: products,
+		now:      time.Now,
+	}
+}
+
+// InvoiceDetails - счёт вместе со строками и суммой, списанной по его платежам
+type InvoiceDetails struct {
+	Invoice       paymentsdb.Invoice
+	Lines         []paymentsdb.InvoiceLine
+	CapturedCents int64
+}
+
+type CreateCustomerInput struct {
+	Name  string
+	Email string
+}
+
+func (input *CreateCustomerInput) normalize() error {
+	input.Name = strings.TrimSpace(input.Name)
+	input.Email = strings.TrimSpace(input.Email)
+	if input.Name == "" {
+		return fmt.Errorf("%w: name is required", ErrInvalidInput)
+	}
+	if utf8.RuneCountInString(input.Name) > maxCustomerNameLength {
+		return fmt.Errorf("%w: name too long", ErrInvalidInput)
+	}
+	if address, err := mail.ParseAddress(input.Email); err != nil || address.Address != input.Email {
+		return fmt.Errorf("%w: invalid email %q", ErrInvalidInput, input.Email)
+	}
+	return nil
+}
+
+func (s *InvoiceService) CreateCustomer(ctx context.Context, input CreateCustomerInput) (paymentsdb.Customer, error) {
+	if err := input.normalize(); err != nil {
+		return paymentsdb.Customer{}, fmt.Errorf("service: create customer: %w", err)
+	}
+
+	customer, err := s.store.CreateCustomer(ctx, input.Name, input.Email)
+	if err != nil {
+		if errors.Is(err, store.ErrEmailTaken) {
+			return customer, fmt.Errorf("service: create customer: %w: email %q already registered", ErrConflict, input.Email)
+		}
+		return customer, fmt.Errorf("service: create customer: %w", err)
+	}
+	return customer, nil
+}
+
+func (s *InvoiceService) GetCustomer(ctx context.Context, id int32) (paymentsdb.Customer, error) {
+	customer, err := s.store.GetCustomer(ctx, id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return customer, fmt.Errorf("service: get customer: %w: customer %d not found", ErrNotFound, id)
+		}
+		return customer, fmt.Errorf("service: get customer %d: %w", id, err)
+	}
+	return customer, nil
+}
+
+type CreateInvoiceInput struct {
+	CustomerID int32
+	// Currency - валюта счёта, цены строк пересчитываются в неё; пусто - валюта каталога
+	Currency  string
+	TaxRateBp int32
+}
+
+// Create заводит пустой черновик счёта для покупателя
+func (s *InvoiceService) Create(ctx context.Context, input CreateInvoiceInput) (paymentsdb.Invoice, error) {
+	if input.Currency == "" {
+		input.Currency = string(money.DefaultCurrency)
+	}
+	currency, err := money.ParseCurrency(input.Currency)
+	if err != nil {
+		return paymentsdb.Invoice{}, fmt.Errorf("service: create invoice: %w: %v", ErrInvalidInput, err)
+	}
+	if err := validTaxRate(input.TaxRateBp); err != nil {
+		return paymentsdb.Invoice{}, fmt.Errorf("service: create invoice: %w", err)
+	}
+	if _, err := s.GetCustomer(ctx, input.CustomerID); err != nil {
+		return paymentsdb.Invoice{}, err
+	}
+
+	invoice, err := s.store.Create(ctx, input.CustomerID, string(currency), input.TaxRateBp)
+	if err != nil {
+		return invoice, fmt.Errorf("service: create invoice: %w", err)
+	}
+	return invoice, nil
+}
+
+func (s *InvoiceService) Get(ctx context.Context, id int32) (InvoiceDetails, error) {
+	invoice, err := s.store.Get(ctx, id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return InvoiceDetails{}, fmt.Errorf("service: get invoice: %w: invoice %d not found", ErrNotFound, id)
+		}
+		return InvoiceDetails{}, fmt.Errorf("service: get invoice %d: %w", id, err)
+	}
+
+	lines, err := s.store.Lines(ctx, id)
+	if err != nil {
+		return InvoiceDetails{}, fmt.Errorf("service: get invoice %d: %w", id, err)
+	}
+	captured, err := s.store.CapturedCents(ctx, id)
+	if err != nil {
+		return InvoiceDetails{}, fmt.Errorf("service: get invoice %d: %w", id, err)
+	}
+	return InvoiceDetails{Invoice: invoice, Lines: lines, CapturedCents: captured}, nil
+}
+
+// ListByCustomer - счета покупателя в порядке создания
+func (s *InvoiceService) ListByCustomer(ctx context.Context, customerID int32) ([]paymentsdb.Invoice, error) {
+	if _, err := s.GetCustomer(ctx, customerID); err != nil {
+		return nil, err
+	}
+
+	invoices, err := s.store.ListByCustomer(ctx, customerID)
+	if err != nil {
+		return nil, fmt.Errorf("service: list invoices: %w", err)
+	}
+	return invoices, nil
+}
+
+// AddLine добавляет в черновик quantity единиц продукта. Название и цена
+// продукта в валюте счёта копируются в строку и дальше от каталога не зависят.
+func (s *InvoiceService) AddLine(ctx context.Context, invoiceID, productID, quantity int32) (InvoiceDetails, error) {
+	if quantity <= 0 {
+		return InvoiceDetails{}, fmt.Errorf("service: add invoice line: %w: quantity must be positive, got %d", ErrInvalidInput, quantity)
+	}
+
+	details, err := s.Get(ctx, invoiceID)
+	if err != nil {
+		return InvoiceDetails{}, err
+	}
+	product, err := s.products.GetInCurrency(ctx, productID, details.Invoice.Currency)
+	if err != nil {
+		return InvoiceDetails{}, fmt.Errorf("service: add invoice line: %w", err)
+	}
+
+	amount, err := money.New(product.PriceCents, money.Currency(product.Currency)).Mul(int64(quantity))
+	if err != nil {
+		return InvoiceDetails{}, fmt.Errorf("service: add invoice line: %w: %v", ErrInvalidInput, err)
+	}
+
+	_, _, err = s.store.AddLine(ctx, paymentsdb.InsertInvoiceLineParams{
+		InvoiceID:   invoiceID,
+		ProductID:   sql.NullInt32{Int32: productID, Valid: true},
+		Description: product.Title,
+		Quantity:    quantity,
+		PriceCents:  product.PriceCents,
+		AmountCents: amount.Amount,
+	}, func(invoice paymentsdb.Invoice, lines []paymentsdb.InvoiceLine) (store.InvoiceTotals, error) {
+		return invoiceTotals(invoice, lines, invoice.DiscountCents, invoice.TaxRateBp)
+	})
+	if err != nil {
+		return InvoiceDetails{}, invoiceError("add invoice line", invoiceID, err)
+	}
+	return s.Get(ctx, invoiceID)
+}
+
+// RemoveLine удаляет строку из черновика
+func (s *InvoiceService) RemoveLine(ctx context.Context, invoiceID int32, lineID int64) (InvoiceDetails, error) {
+	_, err := s.store.RemoveLine(ctx, invoiceID, lineID, func(invoice paymentsdb.Invoice, lines []paymentsdb.InvoiceLine) (store.InvoiceTotals, error) {
+		return invoiceTotals(invoice, lines, invoice.DiscountCents, invoice.TaxRateBp)
+	})
+	if err != nil {
+		if errors.Is(err, store.ErrInvoiceLineNotFound) {
+			return InvoiceDetails{}, fmt.Errorf("service: remove invoice line: %w: line %d not found in invoice %d", ErrNotFound, lineID, invoiceID)
+		}
+		return InvoiceDetails{}, invoiceError("remove invoice line", invoiceID, err)
+	}
+	return s.Get(ctx, invoiceID)
+}
+
+// InvoiceAdjustments - изменение скидки и ставки налога черновика; nil - не менять
+type InvoiceAdjustments struct {
+	DiscountCents *int64
+	TaxRateBp     *int32
+}
+
+// Adjust меняет скидку и ставку налога черновика и пересчитывает итоги
+func (s *InvoiceService) Adjust(ctx context.Context, invoiceID int32, input InvoiceAdjustments) (InvoiceDetails, error) {
+	if input.DiscountCents != nil && *input.DiscountCents < 0 {
+		return InvoiceDetails{}, fmt.Errorf("service: adjust invoice: %w: negative discount %d", ErrInvalidInput, *input.DiscountCents)
+	}
+	if input.TaxRateBp != nil {
+		if err := validTaxRate(*input.TaxRateBp); err != nil {
+			return InvoiceDetails{}, fmt.Errorf("service: adjust invoice: %w", err)
+		}
+	}
+
+	_, err := s.store.Recalculate(ctx, invoiceID, func(invoice paymentsdb.Invoice, lines []paymentsdb.InvoiceLine) (store.InvoiceTotals, error) {
+		discount, rate := invoice.DiscountCents, invoice.TaxRateBp
+		if input.DiscountCents != nil {
+			discount = *input.DiscountCents
+		}
+		if input.TaxRateBp != nil {
+			rate = *input.TaxRateBp
+		}
+		return invoiceTotals(invoice, lines, discount, rate)
+	})
+	if err != nil {
+		return InvoiceDetails{}, invoiceError("adjust invoice", invoiceID, err)
+	}
+	return s.Get(ctx, invoiceID)
+}
+
+// Issue выставляет черновик и присваивает ему номер вида 2026-000042;
+// нумерация своя для каждого года по UTC
+func (s *InvoiceService) Issue(ctx context.Context, invoiceID int32) (InvoiceDetails, error) {
+	_, err := s.store.Issue(ctx, invoiceID, int32(s.now().UTC().Year()))
+	if err != nil {
+		return InvoiceDetails{}, invoiceError("issue invoice", invoiceID, err)
+	}
+	return s.Get(ctx, invoiceID)
+}
+
+// Void аннулирует черновик или выставленный счёт, по которому ничего не списано
+func (s *InvoiceService) Void(ctx context.Context, invoiceID int32) (InvoiceDetails, error) {
+	invoice, err := s.store.Void(ctx, invoiceID)
+	if err != nil {
+		if errors.Is(err, store.ErrInvoiceStatusMismatch) {
+			return InvoiceDetails{}, fmt.Errorf("service: void invoice: %w: invoice %d is %s", ErrConflict, invoiceID, invoice.Status)
+		}
+		return InvoiceDetails{}, invoiceError("void invoice", invoiceID, err)
+	}
+	return s.Get(ctx, invoiceID)
+}
+
+// invoiceTotals: subtotal - сумма строк, налог считается от суммы после скидки
+// и округляется до минорной единицы (половина - от нуля), total = subtotal - discount + tax
+func invoiceTotals(invoice paymentsdb.Invoice, lines []paymentsdb.InvoiceLine, discountCents int64, taxRateBp int32) (store.InvoiceTotals, error) {
+	currency := money.Currency(invoice.Currency)
+	subtotal := money.New(0, currency)
+	for _, line := range lines {
+		var err error
+		if subtotal, err = subtotal.Add(money.New(line.AmountCents, currency)); err != nil {
+			return store.InvoiceTotals{}, fmt.Errorf("%w: invoice subtotal: %v", ErrInvalidInput, err)
+		}
+	}
+	if discountCents > subtotal.Amount {
+		return store.InvoiceTotals{}, fmt.Errorf("%w: %d discount, %d subtotal", ErrDiscountExceedsSubtotal, discountCents, subtotal.Amount)
+	}
+
+	taxable := subtotal.Amount - discountCents
+	rate := big.NewRat(int64(taxRateBp), maxTaxRateBp)
+	tax, err := money.Round(new(big.Rat).Mul(new(big.Rat).SetInt64(taxable), rate))
+	if err != nil {
+		return store.InvoiceTotals{}, fmt.Errorf("%w: invoice tax: %v", ErrInvalidInput, err)
+	}
+	total, err := money.New(taxable, currency).Add(money.New(tax, currency))
+	if err != nil {
+		return store.InvoiceTotals{}, fmt.Errorf("%w: invoice total: %v", ErrInvalidInput, err)
+	}
+
+	return store.InvoiceTotals{
+		SubtotalCents: subtotal.Amount,
+		DiscountCents: discountCents,
+		TaxRateBp:     taxRateBp,
+		TaxCents:      tax,
+		TotalCents:    total.Amount,
+	}, nil
+}
+
+func validTaxRate(rate int32) error {
+	if rate < 0 || rate > maxTaxRateBp {
+		return fmt.Errorf("%w: tax_rate_bp must be between 0 and %d, got %d", ErrInvalidInput, maxTaxRateBp, rate)
+	}
+	return nil
+}
+
+// invoiceError переводит ошибки хранилища счетов в ошибки сервиса
+func invoiceError(op string, invoiceID int32, err error) error {
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		return fmt.Errorf("service: %s: %w: invoice %d not found", op, ErrNotFound, invoiceID)
+	case errors.Is(err, store.ErrInvoiceStatusMismatch):
+		return fmt.Errorf("service: %s: %w", op, ErrInvoiceNotDraft)
+	case errors.Is(err, store.ErrInvoiceEmpty):
+		return fmt.Errorf("service: %s: %w", op, ErrInvoiceEmpty)
+	case errors.Is(err, store.ErrInvoiceHasPayments):
+		return fmt.Errorf("service: %s: %w", op, ErrInvoiceHasPayments)
+	}
+	return fmt.Errorf("service: %s: %w", op, err)
+}
@@ -0,0 +1,371 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	usersdb "db200/internal/db/users"
+	"db200/internal/store"
+)
+
+// Виды событий безопасности
+const (
+	SecurityEventLoginLocked   = "login_locked"
+	SecurityEventLoginUnlocked = "login_unlocked"
+)
+
+// ThrottlePolicy - правила для одного счётчика неудачных входов.
+// После каждой неудачи вход закрывается на BackoffBase, 2*BackoffBase, 4*BackoffBase...
+// (не дольше BackoffMax), после MaxFailures неудач - на Lockout. Счёт
+// обнуляется успешным входом или сбросом пароля (только для аккаунта)
+// или через Window без неудач.
+type ThrottlePolicy struct {
+	MaxFailures int
+	Lockout     time.Duration
+	// BackoffBase 0 - без пауз, только блокировка
+	BackoffBase time.Duration
+	BackoffMax  time.Duration
+	Window      time.Duration
+}
+
+var (
+	// DefaultAccountThrottle - подбор пароля к одному аккаунту
+	DefaultAccountThrottle = ThrottlePolicy{
+		MaxFailures: 5,
+		Lockout:     15 * time.Minute,
+		BackoffBase: time.Second,
+		BackoffMax:  30 * time.Second,
+		Window:      time.Hour,
+	}
+	// DefaultIPThrottle - перебор аккаунтов с одного адреса. Без пауз:
+	// за одним адресом (NAT, офис) бывает много честных пользователей.
+	DefaultIPThrottle = ThrottlePolicy{
+		MaxFailures: 50,
+		Lockout:     15 * time.Minute,
+		Window:      time.Hour,
+	}
+)
+
+func (p ThrottlePolicy) Validate() error {
+	if p.MaxFailures < 1 {
+		return fmt.Errorf("%w: max failures must be at least 1", ErrInvalidInput)
+	}
+	if p.Lockout <= 0 || p.Window <= 0 {
+		return fmt.Errorf("%w: lockout and window must be positive", ErrInvalidInput)
+	}
+	if p.BackoffBase < 0 || p.BackoffMax < p.BackoffBase {
+		return fmt.Errorf("%w: backoff max must not be less than backoff base", ErrInvalidInput)
+	}
+	return nil
+}
+
+// block - на сколько закрыть вход после failures неудач подряд и блокировка ли это
+func (p ThrottlePolicy) block(failures int) (time.Duration, bool) {
+	if failures >= p.MaxFailures {
+		return p.Lockout, true
+	}
+	if p.BackoffBase == 0 {
+		return 0, false
+	}
+
+	delay := p.BackoffBase
+	for i := 1; i < failures && delay < p.BackoffMax; i++ {
+		delay *= 2
+	}
+	return min(delay, p.BackoffMax), false
+}
+
+// LoginThrottledError - вход временно закрыт; RetryAfter - через сколько можно повторить
+type LoginThrottledError struct {
+	RetryAfter time.Duration
+	Locked     bool
+}
+
+func (e *LoginThrottledError) Error() string {
+	retry := e.RetryAfter.Round(time.Second)
+	if e.Locked {
+		return fmt.Sprintf("login temporarily locked after too many failed attempts, retry in %s", retry)
+	}
+	return fmt.Sprintf("too many failed login attempts, retry in %s", retry)
+}
+
+func (e *LoginThrottledError) Unwrap() error {
+	return ErrRateLimited
+}
+
+// LoginThrottleService ограничивает подбор паролей. Счётчик аккаунта ведётся
+// по email, даже несуществующему, чтобы ответы не выдавали, есть ли аккаунт.
+// Блокировку аккаунта может держать кто угодно, кто знает email, поэтому
+// владелец снимает её сам сбросом пароля по ссылке из письма (ResetUser).
+// Попытка учитывается как неудача до проверки пароля (Begin) и отменяется
+// при успехе, поэтому параллельные попытки не проходят мимо паузы и блокировки.
+type LoginThrottleService struct {
+	store   *store.LoginThrottleStore
+	users   *store.UserStore
+	account ThrottlePolicy
+	ip      ThrottlePolicy
+	now     func() time.Time
+}
+
+func NewLoginThrottleService(throttleStore *store.LoginThrottleStore, userStore *store.UserStore, account, ip ThrottlePolicy) *LoginThrottleService {
+	return &LoginThrottleService{
+		store:   throttleStore,
+		users:   userStore,
+		account: account,
+		ip:      ip,
+		now:     time.Now,
+	}
+}
+
+// LoginAttempt - попытка входа, заранее учтённая как неудача во всех своих
+// счётчиках. После проверки пароля её завершают Succeeded, Failed или Cancel.
+type LoginAttempt struct {
+	ip     string
+	claims []store.ThrottleClaim
+}
+
+// Begin учитывает попытку входа до проверки пароля: пауза или блокировка,
+// которую назначила бы её неудача, начинает действовать сразу. Если вход уже
+// закрыт, возвращает *LoginThrottledError, и попытка не учитывается.
+func (s *LoginThrottleService) Begin(ctx context.Context, email, ip string) (*LoginAttempt, error) {
+	now := s.now().UTC()
+	attempt := &LoginAttempt{ip: ip}
+	var throttled *LoginThrottledError
+	for _, k := range throttleKeys(email, ip) {
+		policy := s.policy(k.scope)
+		claim, err := s.store.Claim(ctx, k.scope, k.key, now, now.Add(-policy.Window),
+			func(failures int32) (time.Time, bool) {
+				delay, locked := policy.block(int(failures))
+				if delay == 0 {
+					return time.Time{}, false
+				}
+				return now.Add(delay), locked
+			})
+		if err != nil {
+			s.cancel(ctx, attempt)
+			return nil, fmt.Errorf("service: begin login attempt: %w", err)
+		}
+
+		if claim.Blocked {
+			retry := claim.Current.BlockedUntil.Time.Sub(now)
+			if throttled == nil || retry > throttled.RetryAfter {
+				throttled = &LoginThrottledError{RetryAfter: retry, Locked: claim.Current.Locked}
+			}
+			continue
+		}
+		attempt.claims = append(attempt.claims, claim)
+	}
+
+	if throttled != nil {
+		s.cancel(ctx, attempt)
+		return nil, fmt.Errorf("service: begin login attempt: %w", throttled)
+	}
+	return attempt, nil
+}
+
+// Failed - пароль не подошёл: попытка остаётся учтённой неудачей.
+// Если она привела к блокировке, пишет событие безопасности.
+func (s *LoginThrottleService) Failed(ctx context.Context, attempt *LoginAttempt) {
+	for _, claim := range attempt.claims {
+		if !claim.Current.Locked {
+			continue
+		}
+		logrus.WithFields(logrus.Fields{
+			"scope":    claim.Scope,
+			"subject":  claim.Key,
+			"ip":       attempt.ip,
+			"failures": claim.Current.Failures,
+			"until":    claim.Current.BlockedUntil.Time,
+		}).Warn("login locked after repeated failures")
+		s.event(ctx, usersdb.InsertSecurityEventParams{
+			Kind:    SecurityEventLoginLocked,
+			Scope:   claim.Scope,
+			Subject: claim.Key,
+			IP:      attempt.ip,
+		})
+	}
+}
+
+// Succeeded сбрасывает счётчик аккаунта. Со счётчика IP снимается только
+// эта попытка: иначе своим аккаунтом можно было бы обнулять перебор чужих
+// с того же адреса.
+func (s *LoginThrottleService) Succeeded(ctx context.Context, attempt *LoginAttempt) error {
+	// Вход уже состоялся, обрыв запроса не должен оставить попытку неудачей
+	ctx = context.WithoutCancel(ctx)
+	for _, claim := range attempt.claims {
+		var err error
+		if claim.Scope == store.ThrottleAccount {
+			_, err = s.store.Reset(ctx, claim.Scope, claim.Key)
+		} else {
+			err = s.store.Release(ctx, claim)
+		}
+		if err != nil {
+			return fmt.Errorf("service: login succeeded: %w", err)
+		}
+	}
+	return nil
+}
+
+// Cancel снимает попытку, когда пароль не удалось проверить (ошибка базы,
+// неверный запрос): такая попытка не считается неудачей
+func (s *LoginThrottleService) Cancel(ctx context.Context, attempt *LoginAttempt) {
+	s.cancel(context.WithoutCancel(ctx), attempt)
+}
+
+func (s *LoginThrottleService) cancel(ctx context.Context, attempt *LoginAttempt) {
+	for _, claim := range attempt.claims {
+		if err := s.store.Release(ctx, claim); err != nil {
+			logrus.WithError(err).WithField("scope", claim.Scope).Error("release login attempt")
+		}
+	}
+}
+
+// ResetUser сбрасывает счётчик аккаунта userID после сброса пароля по ссылке
+// из письма. Событие безопасности не пишется: сброс пароля журналируется сам.
+func (s *LoginThrottleService) ResetUser(ctx context.Context, userID int32) error {
+	user, err := s.users.Get(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("service: reset user login throttle %d: %w", userID, err)
+	}
+	if _, err := s.store.Reset(ctx, store.ThrottleAccount, loginKey(user.Email)); err != nil {
+		return fmt.Errorf("service: reset user login throttle %d: %w", userID, err)
+	}
+	return nil
+}
+
+// UnlockUser снимает блокировку и паузу входа в аккаунт; actorID - администратор.
+// false - аккаунт не был заблокирован.
+func (s *LoginThrottleService) UnlockUser(ctx context.Context, userID, actorID int32) (bool, error) {
+	user, err := s.users.Get(ctx, userID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return false, fmt.Errorf("service: unlock user: %w: user %d not found", ErrNotFound, userID)
+		}
+		return false, fmt.Errorf("service: unlock user %d: %w", userID, err)
+	}
+
+	return s.unlock(ctx, store.ThrottleAccount, loginKey(user.Email), actorID)
+}
+
+// UnlockIP снимает блокировку входа с адреса
+func (s *LoginThrottleService) UnlockIP(ctx context.Context, ip string, actorID int32) (bool, error) {
+	parsed := net.ParseIP(strings.TrimSpace(ip))
+	if parsed == nil {
+		return false, fmt.Errorf("service: unlock ip: %w: invalid ip %q", ErrInvalidInput, ip)
+	}
+
+	return s.unlock(ctx, store.ThrottleIP, parsed.String(), actorID)
+}
+
+// Events - последние события безопасности
+func (s *LoginThrottleService) Events(ctx context.Context, limit int32) ([]usersdb.SecurityEvent, error) {
+	if limit <= 0 {
+		limit = store.MaxListLimit
+	}
+	if limit > store.MaxListLimit {
+		return nil, fmt.Errorf("service: list security events: %w: limit too large %d",
+			ErrInvalidInput, limit)
+	}
+
+	events, err := s.store.Events(ctx, limit)
+	if err != nil {
+		return nil, fmt.Errorf("service: list security events: %w", err)
+	}
+	return events, nil
+}
+
+// RunExpiryJob раз в interval удаляет счётчики, которые уже ни на что не влияют.
+// Блокируется до отмены ctx, запускать в отдельной горутине.
+func (s *LoginThrottleService) RunExpiryJob(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			now := s.now().UTC()
+			window := max(s.account.Window, s.ip.Window)
+			count, err := s.store.DeleteStale(ctx, now.Add(-window), now)
+			if err != nil {
+				logrus.WithError(err).Error("delete stale login throttles")
+				continue
+			}
+			if count > 0 {
+				logrus.WithField("deleted", count).Info("deleted stale login throttles")
+			}
+		}
+	}
+}
+
+func (s *LoginThrottleService) unlock(ctx context.Context, scope, key string, actorID int32) (bool, error) {
+	unlocked, err := s.store.Reset(ctx, scope, key)
+	if err != nil {
+		return false, fmt.Errorf("service: unlock %s: %w", scope, err)
+	}
+
+	if unlocked {
+		logrus.WithFields(logrus.Fields{
+			"scope":    scope,
+			"subject":  key,
+			"actor_id": actorID,
+		}).Info("login unlocked by admin")
+		s.event(ctx, usersdb.InsertSecurityEventParams{
+			Kind:    SecurityEventLoginUnlocked,
+			Scope:   scope,
+			Subject: key,
+			ActorID: sql.NullInt32{Int32: actorID, Valid: actorID > 0},
+		})
+	}
+	return unlocked, nil
+}
+
+// event пишет событие в журнал; ошибка записи не мешает основному действию
+func (s *LoginThrottleService) event(ctx context.Context, event usersdb.InsertSecurityEventParams) {
+	if _, err := s.store.AddEvent(ctx, event); err != nil {
+		logrus.WithError(err).WithField("kind", event.Kind).Error("add security event")
+	}
+}
+
+func (s *LoginThrottleService) policy(scope string) ThrottlePolicy {
+	if scope == store.ThrottleIP {
+		return s.ip
+	}
+	return s.account
+}
+
+type throttleKey struct {
+	scope string
+	key   string
+}
+
+// throttleKeys - счётчики, которые касаются попытки входа; пустые значения пропускаются
+func throttleKeys(email, ip string) []throttleKey {
+	keys := make([]throttleKey, 0, 2)
+	if key := loginKey(email); key != "" {
+		keys = append(keys, throttleKey{scope: store.ThrottleAccount, key: key})
+	}
+	if ip != "" {
+		keys = append(keys, throttleKey{scope: store.ThrottleIP, key: ip})
+	}
+	return keys
+}
+
+// loginKey - ключ счётчика аккаунта. Email длиннее допустимого при регистрации
+// не может принадлежать аккаунту, счётчик по нему не ведётся (остаётся счётчик IP),
+// чтобы в базу не попадали ключи произвольной длины.
+func loginKey(email string) string {
+	key := strings.ToLower(strings.TrimSpace(email))
+	if len(key) > maxEmailLength {
+		return ""
+	}
+	return key
+}
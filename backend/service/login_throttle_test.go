@@ -0,0 +1,130 @@
+package service
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"db200/internal/store"
+)
+
+func TestThrottlePolicyBlock(t *testing.T) {
+	policy := ThrottlePolicy{
+		MaxFailures: 6,
+		Lockout:     15 * time.Minute,
+		BackoffBase: time.Second,
+		BackoffMax:  5 * time.Second,
+		Window:      time.Hour,
+	}
+	noBackoff := policy
+	noBackoff.BackoffBase, noBackoff.BackoffMax = 0, 0
+
+	tests := []struct {
+		name       string
+		policy     ThrottlePolicy
+		failures   int
+		wantDelay  time.Duration
+		wantLocked bool
+	}{
+		{name: "first failure", policy: policy, failures: 1, wantDelay: time.Second},
+		{name: "doubles", policy: policy, failures: 2, wantDelay: 2 * time.Second},
+		{name: "doubles again", policy: policy, failures: 3, wantDelay: 4 * time.Second},
+		{name: "capped at max", policy: policy, failures: 4, wantDelay: 5 * time.Second},
+		{name: "stays at max", policy: policy, failures: 5, wantDelay: 5 * time.Second},
+		{name: "locked at max failures", policy: policy, failures: 6, wantDelay: 15 * time.Minute, wantLocked: true},
+		{name: "locked after max failures", policy: policy, failures: 100, wantDelay: 15 * time.Minute, wantLocked: true},
+		{name: "no backoff", policy: noBackoff, failures: 5, wantDelay: 0},
+		{name: "no backoff still locks", policy: noBackoff, failures: 6, wantDelay: 15 * time.Minute, wantLocked: true},
+		{name: "default account first failure", policy: DefaultAccountThrottle, failures: 1, wantDelay: time.Second},
+		{name: "default account lockout", policy: DefaultAccountThrottle, failures: 5, wantDelay: 15 * time.Minute, wantLocked: true},
+		{name: "default ip has no backoff", policy: DefaultIPThrottle, failures: 49, wantDelay: 0},
+		{name: "default ip lockout", policy: DefaultIPThrottle, failures: 50, wantDelay: 15 * time.Minute, wantLocked: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			delay, locked := tt.policy.block(tt.failures)
+			if delay != tt.wantDelay || locked != tt.wantLocked {
+				t.Errorf("block(%d) = %s, %v; want %s, %v", tt.failures, delay, locked, tt.wantDelay, tt.wantLocked)
+			}
+		})
+	}
+}
+
+// Длинная пауза не должна переполнить time.Duration при удвоении
+func TestThrottlePolicyBlockLargeBackoff(t *testing.T) {
+	policy := ThrottlePolicy{
+		MaxFailures: 1000,
+		Lockout:     time.Hour,
+		BackoffBase: time.Hour,
+		BackoffMax:  24 * time.Hour,
+		Window:      time.Hour,
+	}
+	for failures := 1; failures < policy.MaxFailures; failures++ {
+		delay, locked := policy.block(failures)
+		if locked || delay < policy.BackoffBase || delay > policy.BackoffMax {
+			t.Fatalf("block(%d) = %s, %v; want delay within [%s, %s]", failures, delay, locked, policy.BackoffBase, policy.BackoffMax)
+		}
+	}
+}
+
+func TestThrottlePolicyValidate(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(*ThrottlePolicy)
+		valid  bool
+	}{
+		{name: "default", modify: func(*ThrottlePolicy) {}, valid: true},
+		{name: "no backoff", modify: func(p *ThrottlePolicy) { p.BackoffBase, p.BackoffMax = 0, 0 }, valid: true},
+		{name: "no failures allowed", modify: func(p *ThrottlePolicy) { p.MaxFailures = 0 }},
+		{name: "no lockout", modify: func(p *ThrottlePolicy) { p.Lockout = 0 }},
+		{name: "no window", modify: func(p *ThrottlePolicy) { p.Window = 0 }},
+		{name: "negative backoff", modify: func(p *ThrottlePolicy) { p.BackoffBase = -time.Second }},
+		{name: "max below base", modify: func(p *ThrottlePolicy) { p.BackoffMax = p.BackoffBase / 2 }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := DefaultAccountThrottle
+			tt.modify(&p)
+			err := p.Validate()
+			if tt.valid && err != nil {
+				t.Errorf("Validate: %v", err)
+			}
+			if !tt.valid && !errors.Is(err, ErrInvalidInput) {
+				t.Errorf("Validate error = %v, want %v", err, ErrInvalidInput)
+			}
+		})
+	}
+}
+
+func TestThrottleKeys(t *testing.T) {
+	longEmail := strings.Repeat("a", maxEmailLength-len("@example.com")+1) + "@example.com"
+
+	tests := []struct {
+		name  string
+		email string
+		ip    string
+		want  []throttleKey
+	}{
+		{
+			name: "account and ip", email: " User@Example.com ", ip: "10.0.0.1",
+			want: []throttleKey{{scope: store.ThrottleAccount, key: "user@example.com"}, {scope: store.ThrottleIP, key: "10.0.0.1"}},
+		},
+		{name: "no ip", email: "user@example.com", want: []throttleKey{{scope: store.ThrottleAccount, key: "user@example.com"}}},
+		{name: "no email", email: "  ", ip: "10.0.0.1", want: []throttleKey{{scope: store.ThrottleIP, key: "10.0.0.1"}}},
+		{name: "email too long for an account", email: longEmail, ip: "10.0.0.1", want: []throttleKey{{scope: store.ThrottleIP, key: "10.0.0.1"}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := throttleKeys(tt.email, tt.ip)
+			if len(got) != len(tt.want) {
+				t.Fatalf("throttleKeys = %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("throttleKeys = %v, want %v", got, tt.want)
+				}
+			}
+		})
+	}
+}
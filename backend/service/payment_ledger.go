@@ -0,0 +1,209 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	paymentsdb "db200/internal/db/payments"
+	"db200/internal/store"
+)
+
+// Виды операций по платежу
+const (
+	OperationAuthorize = "authorize"
+	OperationCapture   = "capture"
+	OperationRefund    = "refund"
+	OperationVoid      = "void"
+)
+
+// Ошибки сумм операций - частные случаи ErrConflict
+var (
+	ErrCaptureExceedsAuthorized = fmt.Errorf("%w: capture exceeds authorized amount", ErrConflict)
+	ErrRefundExceedsCaptured    = fmt.Errorf("%w: refund exceeds captured amount", ErrConflict)
+)
+
+// PaymentLedger - платёж с суммами, выведенными из его операций
+type PaymentLedger struct {
+	Payment         paymentsdb.Payment
+	AuthorizedCents int64
+	CapturedCents   int64
+	RefundedCents   int64
+	Voided          bool
+}
+
+func newPaymentLedger(payment paymentsdb.Payment, totals paymentsdb.PaymentOperationTotalsRow) PaymentLedger {
+	return PaymentLedger{
+		Payment:         payment,
+		AuthorizedCents: totals.AuthorizedCents,
+		CapturedCents:   totals.CapturedCents,
+		RefundedCents:   totals.RefundedCents,
+		Voided:          totals.Voids > 0,
+	}
+}
+
+// derivedStatus - статус, который следует из сумм операций
+func (l PaymentLedger) derivedStatus() PaymentStatus {
+	switch {
+	case l.Voided:
+		return PaymentCancelled
+	case l.CapturedCents == 0 && l.AuthorizedCents > 0:
+		return PaymentAuthorized
+	case l.CapturedCents == 0:
+		return PaymentCreated
+	case l.RefundedCents == 0:
+		return PaymentCaptured
+	case l.RefundedCents < l.CapturedCents:
+		return PaymentPartiallyRefunded
+	}
+	return PaymentRefunded
+}
+
+// apply проверяет операцию по текущим суммам и возвращает суммы после неё.
+// amount == 0 у capture и refund - весь доступный остаток.
+func (l PaymentLedger) apply(kind string, amount int64) (PaymentLedger, int64, error) {
+	status := PaymentStatus(l.Payment.Status)
+	if amount < 0 {
+		return l, 0, fmt.Errorf("%w: negative amount %d", ErrInvalidInput, amount)
+	}
+	illegal := &PaymentTransitionError{PaymentID: l.Payment.ID, From: status}
+
+	switch kind {
+	case OperationAuthorize:
+		// Авторизуется вся сумма платежа и только один раз
+		if status != PaymentCreated {
+			illegal.To = PaymentAuthorized
+			return l, 0, illegal
+		}
+		amount = int64(l.Payment.AmountCents)
+		l.AuthorizedCents += amount
+	case OperationCapture:
+		// Частичные списания возможны, пока не было возвратов
+		if status != PaymentAuthorized && status != PaymentCaptured {
+			illegal.To = PaymentCaptured
+			return l, 0, illegal
+		}
+		available := l.AuthorizedCents - l.CapturedCents
+		if amount == 0 {
+			amount = available
+		}
+		if amount > available {
+			return l, 0, fmt.Errorf("%w: %d requested, %d available", ErrCaptureExceedsAuthorized, amount, available)
+		}
+		l.CapturedCents += amount
+	case OperationRefund:
+		available := l.CapturedCents - l.RefundedCents
+		if amount == 0 {
+			amount = available
+		}
+		if amount > available {
+			return l, 0, fmt.Errorf("%w: %d requested, %d available", ErrRefundExceedsCaptured, amount, available)
+		}
+		l.RefundedCents += amount
+	case OperationVoid:
+		amount = 0
+		l.Voided = true
+	default:
+		return l, 0, fmt.Errorf("%w: unknown payment operation %q", ErrInvalidInput, kind)
+	}
+
+	if to := l.derivedStatus(); to != status && !status.CanTransitionTo(to) {
+		illegal.To = to
+		return l, 0, illegal
+	}
+	if kind != OperationVoid && amount == 0 {
+		return l, 0, fmt.Errorf("%w: nothing to %s", ErrConflict, kind)
+	}
+	return l, amount, nil
+}
+
+// operation - apply в виде операции для store: сумма и статус после неё
+func (l PaymentLedger) operation(kind string, amount int64) (PaymentLedger, store.PaymentOperation, error) {
+	next, amount, err := l.apply(kind, amount)
+	if err != nil {
+		return l, store.PaymentOperation{}, err
+	}
+
+	// Следующая операция в той же транзакции видит платёж уже в новом статусе
+	next.Payment.Status = string(next.derivedStatus())
+	return next, store.PaymentOperation{
+		Kind:        kind,
+		AmountCents: amount,
+		Status:      next.Payment.Status,
+	}, nil
+}
+
+// Ledger - платёж с суммами авторизации, списаний и возвратов
+func (s *PaymentService) Ledger(ctx context.Context, id int32) (PaymentLedger, error) {
+	payment, err := s.Get(ctx, id)
+	if err != nil {
+		return PaymentLedger{}, err
+	}
+
+	totals, err := s.store.Totals(ctx, id)
+	if err != nil {
+		return PaymentLedger{}, fmt.Errorf("service: payment ledger: %w", err)
+	}
+	return newPaymentLedger(payment, totals), nil
+}
+
+func (s *PaymentService) Operations(ctx context.Context, id int32) ([]paymentsdb.PaymentOperation, error) {
+	if _, err := s.Get(ctx, id); err != nil {
+		return nil, err
+	}
+
+	operations, err := s.store.Operations(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("service: payment operations: %w", err)
+	}
+	return operations, nil
+}
+
+// Authorize авторизует полную сумму платежа
+func (s *PaymentService) Authorize(ctx context.Context, id int32) (PaymentLedger, error) {
+	return s.recordOperation(ctx, id, OperationAuthorize, 0)
+}
+
+// Capture списывает amountCents из авторизованной суммы, 0 - весь остаток
+func (s *PaymentService) Capture(ctx context.Context, id int32, amountCents int64) (PaymentLedger, error) {
+	return s.recordOperation(ctx, id, OperationCapture, amountCents)
+}
+
+// Refund возвращает amountCents из списанной суммы, 0 - весь остаток
+func (s *PaymentService) Refund(ctx context.Context, id int32, amountCents int64) (PaymentLedger, error) {
+	return s.recordOperation(ctx, id, OperationRefund, amountCents)
+}
+
+// Void отменяет платёж до списания
+func (s *PaymentService) Void(ctx context.Context, id int32) (PaymentLedger, error) {
+	return s.recordOperation(ctx, id, OperationVoid, 0)
+}
+
+func (s *PaymentService) recordOperation(ctx context.Context, id int32, kind string, amountCents int64) (PaymentLedger, error) {
+	if id <= 0 {
+		return PaymentLedger{}, fmt.Errorf("service: payment %s: %w: invalid id %d", kind, ErrInvalidInput, id)
+	}
+
+	var ledger PaymentLedger
+	payment, err := s.store.RecordOperation(ctx, id, func(payment paymentsdb.Payment, totals paymentsdb.PaymentOperationTotalsRow) ([]store.PaymentOperation, error) {
+		next, operation, err := newPaymentLedger(payment, totals).operation(kind, amountCents)
+		if err != nil {
+			return nil, err
+		}
+		ledger = next
+		return []store.PaymentOperation{operation}, nil
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return PaymentLedger{}, fmt.Errorf("service: payment %s: %w: payment %d not found", kind, ErrNotFound, id)
+		case errors.Is(err, store.ErrInvoiceStatusMismatch):
+			return PaymentLedger{}, fmt.Errorf("service: payment %s: %w: invoice %d is void", kind, ErrInvoiceNotPayable, payment.InvoiceID)
+		}
+		return PaymentLedger{}, fmt.Errorf("service: payment %s: %w", kind, err)
+	}
+
+	ledger.Payment = payment
+	return ledger, nil
+}
@@ -0,0 +1,97 @@
+package service
+
+import (
+	"errors"
+	"testing"
+
+	paymentsdb "db200/internal/db/payments"
+)
+
+type ledgerStep struct {
+	kind   string
+	amount int64
+}
+
+// applySteps проводит операции по платежу на 10000 копеек так же, как
+// recordOperation: после каждой статус платежа берётся из сумм
+func applySteps(steps []ledgerStep) (PaymentLedger, error) {
+	ledger := PaymentLedger{Payment: paymentsdb.Payment{ID: 1, AmountCents: 10000, Status: string(PaymentCreated)}}
+	for _, step := range steps {
+		next, _, err := ledger.apply(step.kind, step.amount)
+		if err != nil {
+			return ledger, err
+		}
+		next.Payment.Status = string(next.derivedStatus())
+		ledger = next
+	}
+	return ledger, nil
+}
+
+func TestPaymentLedger(t *testing.T) {
+	authorize := ledgerStep{kind: OperationAuthorize}
+	capture := func(amount int64) ledgerStep { return ledgerStep{kind: OperationCapture, amount: amount} }
+	refund := func(amount int64) ledgerStep { return ledgerStep{kind: OperationRefund, amount: amount} }
+	void := ledgerStep{kind: OperationVoid}
+
+	tests := []struct {
+		name       string
+		steps      []ledgerStep
+		wantStatus PaymentStatus
+		wantErr    error
+	}{
+		{name: "authorized", steps: []ledgerStep{authorize}, wantStatus: PaymentAuthorized},
+		{name: "partial captures", steps: []ledgerStep{authorize, capture(3000), capture(2000)}, wantStatus: PaymentCaptured},
+		{name: "capture rest", steps: []ledgerStep{authorize, capture(3000), capture(0)}, wantStatus: PaymentCaptured},
+		{name: "partial refund", steps: []ledgerStep{authorize, capture(0), refund(4000)}, wantStatus: PaymentPartiallyRefunded},
+		{name: "two partial refunds", steps: []ledgerStep{authorize, capture(0), refund(4000), refund(1000)}, wantStatus: PaymentPartiallyRefunded},
+		{name: "partial refunds add up to full", steps: []ledgerStep{authorize, capture(0), refund(4000), refund(6000)}, wantStatus: PaymentRefunded},
+		{name: "full refund", steps: []ledgerStep{authorize, capture(6000), refund(0)}, wantStatus: PaymentRefunded},
+		{name: "void before capture", steps: []ledgerStep{authorize, void}, wantStatus: PaymentCancelled},
+		{name: "void before authorize", steps: []ledgerStep{void}, wantStatus: PaymentCancelled},
+
+		{name: "over capture", steps: []ledgerStep{authorize, capture(6000), capture(4001)}, wantErr: ErrCaptureExceedsAuthorized},
+		{name: "over refund", steps: []ledgerStep{authorize, capture(5000), refund(5001)}, wantErr: ErrRefundExceedsCaptured},
+		{name: "refund after full refund", steps: []ledgerStep{authorize, capture(0), refund(0), refund(1)}, wantErr: ErrRefundExceedsCaptured},
+		{name: "refund before capture", steps: []ledgerStep{authorize, refund(1)}, wantErr: ErrRefundExceedsCaptured},
+		{name: "capture after void", steps: []ledgerStep{authorize, void, capture(0)}, wantErr: ErrConflict},
+		{name: "capture after refund", steps: []ledgerStep{authorize, capture(5000), refund(1000), capture(1000)}, wantErr: ErrConflict},
+		{name: "void after capture", steps: []ledgerStep{authorize, capture(0), void}, wantErr: ErrConflict},
+		{name: "authorize twice", steps: []ledgerStep{authorize, authorize}, wantErr: ErrConflict},
+		{name: "capture without authorization", steps: []ledgerStep{capture(0)}, wantErr: ErrConflict},
+		{name: "negative amount", steps: []ledgerStep{authorize, capture(-1)}, wantErr: ErrInvalidInput},
+		{name: "unknown operation", steps: []ledgerStep{{kind: "chargeback"}}, wantErr: ErrInvalidInput},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ledger, err := applySteps(tt.steps)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := PaymentStatus(ledger.Payment.Status); got != tt.wantStatus {
+				t.Errorf("status = %s, want %s", got, tt.wantStatus)
+			}
+		})
+	}
+}
+
+// Отказ в операции не меняет суммы
+func TestPaymentLedgerRejectedOperationKeepsTotals(t *testing.T) {
+	ledger, err := applySteps([]ledgerStep{{kind: OperationAuthorize}, {kind: OperationCapture, amount: 7000}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	next, amount, err := ledger.apply(OperationRefund, 7001)
+	if !errors.Is(err, ErrRefundExceedsCaptured) {
+		t.Fatalf("error = %v, want %v", err, ErrRefundExceedsCaptured)
+	}
+	if amount != 0 || next.CapturedCents != 7000 || next.RefundedCents != 0 {
+		t.Errorf("after rejected refund: amount %d, captured %d, refunded %d", amount, next.CapturedCents, next.RefundedCents)
+	}
+}
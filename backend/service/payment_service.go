@@ -0,0 +1,126 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	paymentsdb "db200/internal/db/payments"
+	"db200/internal/store"
+)
+
+type PaymentService struct {
+	store *store.PaymentStore
+}
+
+func NewPaymentService(paymentStore *store.PaymentStore) *PaymentService {
+	return &PaymentService{
+		store: paymentStore,
+	}
+}
+
+type CreatePaymentInput struct {
+	InvoiceID   int32
+	AmountCents int32
+}
+
+func (input *CreatePaymentInput) normalize() error {
+	if input.InvoiceID <= 0 {
+		return fmt.Errorf("%w: invoice_id is required", ErrInvalidInput)
+	}
+	if input.AmountCents <= 0 {
+		return fmt.Errorf("%w: amount_cents must be positive, got %d", ErrInvalidInput, input.AmountCents)
+	}
+	return nil
+}
+
+// Create создаёт платёж по выставленному счёту, новый платёж всегда в статусе created
+func (s *PaymentService) Create(ctx context.Context, input CreatePaymentInput) (paymentsdb.Payment, error) {
+	if err := input.normalize(); err != nil {
+		return paymentsdb.Payment{}, fmt.Errorf("service: create payment: %w", err)
+	}
+
+	payment, err := s.store.Create(ctx, input.InvoiceID, input.AmountCents)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return payment, fmt.Errorf("service: create payment: %w: invoice %d not found", ErrNotFound, input.InvoiceID)
+		case errors.Is(err, store.ErrInvoiceStatusMismatch):
+			return payment, fmt.Errorf("service: create payment: %w: invoice %d", ErrInvoiceNotPayable, input.InvoiceID)
+		}
+		return payment, fmt.Errorf("service: create payment: %w", err)
+	}
+	return payment, nil
+}
+
+func (s *PaymentService) Get(ctx context.Context, id int32) (paymentsdb.Payment, error) {
+	payment, err := s.store.Get(ctx, id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return payment, fmt.Errorf("service: get payment: %w: payment %d not found", ErrNotFound, id)
+		}
+		return payment, fmt.Errorf("service: get payment %d: %w", id, err)
+	}
+	return payment, nil
+}
+
+// ListByInvoice - платежи по счёту; для неизвестного счёта - пустой список
+func (s *PaymentService) ListByInvoice(ctx context.Context, invoiceID int32) ([]paymentsdb.Payment, error) {
+	if invoiceID <= 0 {
+		return nil, fmt.Errorf("service: list payments: %w: invoice_id is required", ErrInvalidInput)
+	}
+
+	payments, err := s.store.ListByInvoice(ctx, invoiceID)
+	if err != nil {
+		return nil, fmt.Errorf("service: list payments: %w", err)
+	}
+	return payments, nil
+}
+
+// Fail отмечает платёж неуспешным (отказ провайдера).
+// Остальные статусы выводятся из операций, см. Authorize, Capture, Refund и Void.
+func (s *PaymentService) Fail(ctx context.Context, id int32) (paymentsdb.Payment, error) {
+	return s.transition(ctx, id, PaymentFailed)
+}
+
+// transition переводит платёж в статус to. Недопустимый переход -
+// *PaymentTransitionError; проверка повторяется в SQL, так что
+// конкурентный переход не проскочит между чтением и записью.
+func (s *PaymentService) transition(ctx context.Context, id int32, to PaymentStatus) (paymentsdb.Payment, error) {
+	if id <= 0 {
+		return paymentsdb.Payment{}, fmt.Errorf("service: payment transition: %w: invalid id %d", ErrInvalidInput, id)
+	}
+	if _, err := ParsePaymentStatus(string(to)); err != nil {
+		return paymentsdb.Payment{}, fmt.Errorf("service: payment transition: %w", err)
+	}
+
+	payment, err := s.store.SetStatus(ctx, id, string(to), paymentStatusSources(to))
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return payment, fmt.Errorf("service: payment transition: %w: payment %d not found", ErrNotFound, id)
+		case errors.Is(err, store.ErrPaymentStatusMismatch):
+			return payment, fmt.Errorf("service: payment transition: %w", &PaymentTransitionError{
+				PaymentID: id,
+				From:      PaymentStatus(payment.Status),
+				To:        to,
+			})
+		}
+		return payment, fmt.Errorf("service: payment transition: %w", err)
+	}
+	return payment, nil
+}
+
+// StatusHistory - все переходы статусов платежа со временем каждого
+func (s *PaymentService) StatusHistory(ctx context.Context, id int32) ([]paymentsdb.PaymentStatusTransition, error) {
+	if _, err := s.Get(ctx, id); err != nil {
+		return nil, err
+	}
+
+	transitions, err := s.store.StatusHistory(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("service: payment status history: %w", err)
+	}
+	return transitions, nil
+}
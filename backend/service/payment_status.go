@@ -0,0 +1,95 @@
+package service
+
+import (
+	"fmt"
+)
+
+// PaymentStatus - этап жизненного цикла платежа:
+// created -> authorized -> captured -> partially_refunded/refunded.
+// failed и cancelled - конечные, как и refunded.
+type PaymentStatus string
+
+const (
+	PaymentCreated           PaymentStatus = "created"
+	PaymentAuthorized        PaymentStatus = "authorized"
+	PaymentCaptured          PaymentStatus = "captured"
+	PaymentPartiallyRefunded PaymentStatus = "partially_refunded"
+	PaymentRefunded          PaymentStatus = "refunded"
+	PaymentFailed            PaymentStatus = "failed"
+	PaymentCancelled         PaymentStatus = "cancelled"
+)
+
+// paymentTransitions - разрешённые переходы; повторный partially_refunded -
+// ещё один частичный возврат
+var paymentTransitions = map[PaymentStatus][]PaymentStatus{
+	PaymentCreated:           {PaymentAuthorized, PaymentFailed, PaymentCancelled},
+	PaymentAuthorized:        {PaymentCaptured, PaymentFailed, PaymentCancelled},
+	PaymentCaptured:          {PaymentPartiallyRefunded, PaymentRefunded},
+	PaymentPartiallyRefunded: {PaymentPartiallyRefunded, PaymentRefunded},
+	PaymentRefunded:          {},
+	PaymentFailed:            {},
+	PaymentCancelled:         {},
+}
+
+func ParsePaymentStatus(s string) (PaymentStatus, error) {
+	status := PaymentStatus(s)
+	if _, ok := paymentTransitions[status]; !ok {
+		return "", fmt.Errorf("%w: unknown payment status %q", ErrInvalidInput, s)
+	}
+	return status, nil
+}
+
+// Terminal - из статуса нет переходов
+func (s PaymentStatus) Terminal() bool {
+	return len(paymentTransitions[s]) == 0
+}
+
+func (s PaymentStatus) CanTransitionTo(to PaymentStatus) bool {
+	for _, next := range paymentTransitions[s] {
+		if next == to {
+			return true
+		}
+	}
+	return false
+}
+
+// paymentStatusSources - статусы, из которых можно перейти в to; уходят в условный UPDATE
+func paymentStatusSources(to PaymentStatus) []string {
+	var sources []string
+	for _, from := range paymentStatusOrder {
+		if from.CanTransitionTo(to) {
+			sources = append(sources, string(from))
+		}
+	}
+	return sources
+}
+
+// paymentStatusOrder - все статусы в порядке жизненного цикла
+var paymentStatusOrder = []PaymentStatus{
+	PaymentCreated,
+	PaymentAuthorized,
+	PaymentCaptured,
+	PaymentPartiallyRefunded,
+	PaymentRefunded,
+	PaymentFailed,
+	PaymentCancelled,
+}
+
+// PaymentTransitionError - недопустимая смена статуса платежа.
+// errors.Is(err, ErrConflict) == true.
+type PaymentTransitionError struct {
+	PaymentID int32
+	From      PaymentStatus
+	To        PaymentStatus
+}
+
+func (e *PaymentTransitionError) Error() string {
+	if e.From.Terminal() {
+		return fmt.Sprintf("payment %d is %s, no further transitions allowed", e.PaymentID, e.From)
+	}
+	return fmt.Sprintf("payment %d: illegal status transition %s -> %s", e.PaymentID, e.From, e.To)
+}
+
+func (e *PaymentTransitionError) Unwrap() error {
+	return ErrConflict
+}
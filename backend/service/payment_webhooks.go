@@ -0,0 +1,180 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	paymentsdb "db200/internal/db/payments"
+	"db200/internal/store"
+)
+
+// Типы событий провайдера
+const (
+	WebhookPaymentSucceeded = "payment.succeeded"
+	WebhookPaymentFailed    = "payment.failed"
+	WebhookPaymentRefunded  = "payment.refunded"
+)
+
+// Результаты обработки события
+const (
+	WebhookEventApplied   = "applied"
+	WebhookEventIgnored   = "ignored"
+	WebhookEventDuplicate = "duplicate"
+)
+
+// maxWebhookEventIDLength - ограничение на длину id события
+const maxWebhookEventIDLength = 255
+
+// PaymentWebhookEvent - событие провайдера по платежу.
+// AmountCents для succeeded - списанная сумма, для refunded - возвращённая;
+// 0 - весь доступный остаток.
+type PaymentWebhookEvent struct {
+	ID          string
+	Type        string
+	PaymentID   int32
+	AmountCents int64
+	// Payload - исходное тело события, сохраняется как есть
+	Payload json.RawMessage
+}
+
+func (event *PaymentWebhookEvent) normalize() error {
+	event.ID = strings.TrimSpace(event.ID)
+	if event.ID == "" {
+		return fmt.Errorf("%w: event id is required", ErrInvalidInput)
+	}
+	if len(event.ID) > maxWebhookEventIDLength {
+		return fmt.Errorf("%w: event id too long", ErrInvalidInput)
+	}
+	switch event.Type {
+	case WebhookPaymentSucceeded, WebhookPaymentFailed, WebhookPaymentRefunded:
+	default:
+		return fmt.Errorf("%w: unknown event type %q", ErrInvalidInput, event.Type)
+	}
+	if event.PaymentID <= 0 {
+		return fmt.Errorf("%w: invalid payment id %d", ErrInvalidInput, event.PaymentID)
+	}
+	if event.AmountCents < 0 {
+		return fmt.Errorf("%w: negative amount %d", ErrInvalidInput, event.AmountCents)
+	}
+	if !json.Valid(event.Payload) {
+		return fmt.Errorf("%w: payload is not valid JSON", ErrInvalidInput)
+	}
+	return nil
+}
+
+// PaymentWebhookResult - чем закончилась обработка события.
+// Reason объясняет, почему событие проигнорировано.
+type PaymentWebhookResult struct {
+	Outcome string
+	Reason  string
+	Payment paymentsdb.Payment
+}
+
+// HandleWebhookEvent применяет событие провайдера к платежу через те же
+// операции и переходы статусов, что и API:
+//   - payment.succeeded - авторизация (если её ещё не было) и списание;
+//   - payment.failed - перевод в failed;
+//   - payment.refunded - возврат.
+//
+// Каждое применённое событие обрабатывается один раз: повтор с тем же id -
+// WebhookEventDuplicate. Если платежа ещё нет или переход сейчас недопустим
+// (события пришли не по порядку), событие не записывается и возвращается
+// ошибка с ErrNotFound/ErrConflict - провайдер повторит его позже. Как ignored
+// записываются только события, которые не применить никогда: счёт аннулирован.
+// Прочие ошибки означают сбой, после которого повтор тоже имеет смысл.
+func (s *PaymentService) HandleWebhookEvent(ctx context.Context, event PaymentWebhookEvent) (PaymentWebhookResult, error) {
+	if err := event.normalize(); err != nil {
+		return PaymentWebhookResult{}, fmt.Errorf("service: payment webhook: %w", err)
+	}
+
+	record := paymentsdb.InsertPaymentWebhookEventParams{
+		EventID:   event.ID,
+		Type:      event.Type,
+		PaymentID: event.PaymentID,
+		Payload:   event.Payload,
+		Outcome:   WebhookEventApplied,
+	}
+	payment, err := s.store.ApplyWebhookEvent(ctx, record, func(payment paymentsdb.Payment, totals paymentsdb.PaymentOperationTotalsRow) ([]store.PaymentOperation, error) {
+		return webhookOperations(event, newPaymentLedger(payment, totals))
+	})
+	if err == nil {
+		return PaymentWebhookResult{Outcome: WebhookEventApplied, Payment: payment}, nil
+	}
+
+	switch {
+	case errors.Is(err, store.ErrWebhookEventDuplicate):
+		return PaymentWebhookResult{Outcome: WebhookEventDuplicate}, nil
+	case errors.Is(err, sql.ErrNoRows):
+		return PaymentWebhookResult{}, fmt.Errorf("service: payment webhook: %w: payment %d not found", ErrNotFound, event.PaymentID)
+	case errors.Is(err, store.ErrInvoiceStatusMismatch):
+		err = fmt.Errorf("%w: invoice %d is void", ErrInvoiceNotPayable, payment.InvoiceID)
+	case errors.Is(err, ErrInvoiceNotPayable):
+	default:
+		return PaymentWebhookResult{}, fmt.Errorf("service: payment webhook: %w", err)
+	}
+
+	// Событие не применить и при повторе - записываем, чтобы не обрабатывать снова
+	record.Outcome = WebhookEventIgnored
+	record.Reason = err.Error()
+	if err := s.store.RecordWebhookEvent(ctx, record); err != nil {
+		if errors.Is(err, store.ErrWebhookEventDuplicate) {
+			return PaymentWebhookResult{Outcome: WebhookEventDuplicate}, nil
+		}
+		return PaymentWebhookResult{}, fmt.Errorf("service: payment webhook: %w", err)
+	}
+	return PaymentWebhookResult{Outcome: WebhookEventIgnored, Reason: record.Reason}, nil
+}
+
+// WebhookEvents - события провайдера по платежу, включая проигнорированные
+func (s *PaymentService) WebhookEvents(ctx context.Context, id int32) ([]paymentsdb.PaymentWebhookEvent, error) {
+	if _, err := s.Get(ctx, id); err != nil {
+		return nil, err
+	}
+
+	events, err := s.store.WebhookEvents(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("service: payment webhook events: %w", err)
+	}
+	return events, nil
+}
+
+// webhookOperations переводит событие в операции по текущему состоянию платежа
+func webhookOperations(event PaymentWebhookEvent, ledger PaymentLedger) ([]store.PaymentOperation, error) {
+	switch event.Type {
+	case WebhookPaymentSucceeded:
+		var operations []store.PaymentOperation
+		// Провайдер мог авторизовать и списать одним шагом
+		if PaymentStatus(ledger.Payment.Status) == PaymentCreated {
+			next, operation, err := ledger.operation(OperationAuthorize, 0)
+			if err != nil {
+				return nil, err
+			}
+			ledger = next
+			operations = append(operations, operation)
+		}
+		_, operation, err := ledger.operation(OperationCapture, event.AmountCents)
+		if err != nil {
+			return nil, err
+		}
+		return append(operations, operation), nil
+
+	case WebhookPaymentFailed:
+		status := PaymentStatus(ledger.Payment.Status)
+		if !status.CanTransitionTo(PaymentFailed) {
+			return nil, &PaymentTransitionError{PaymentID: ledger.Payment.ID, From: status, To: PaymentFailed}
+		}
+		return []store.PaymentOperation{{Status: string(PaymentFailed)}}, nil
+
+	case WebhookPaymentRefunded:
+		_, operation, err := ledger.operation(OperationRefund, event.AmountCents)
+		if err != nil {
+			return nil, err
+		}
+		return []store.PaymentOperation{operation}, nil
+	}
+	return nil, fmt.Errorf("%w: unknown event type %q", ErrInvalidInput, event.Type)
+}
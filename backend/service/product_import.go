@@ -0,0 +1,306 @@
+package service
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"db200/internal/slug"
+	"db200/internal/store"
+)
+
+// MaxImportRows - ограничение на количество строк в одном импорте
+const MaxImportRows = 100000
+
+// maxImportLineBytes - ограничение на длину одной строки JSON Lines
+const maxImportLineBytes = 1 << 20
+
+type ImportFormat string
+
+const (
+	ImportFormatCSV   ImportFormat = "csv"
+	ImportFormatJSONL ImportFormat = "jsonl"
+)
+
+// ParseImportFormat понимает "csv", "jsonl" и "ndjson"
+func ParseImportFormat(s string) (ImportFormat, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "csv":
+		return ImportFormatCSV, nil
+	case "jsonl", "ndjson":
+		return ImportFormatJSONL, nil
+	}
+	return "", fmt.Errorf("%w: unknown import format %q", ErrInvalidInput, s)
+}
+
+type ImportOptions struct {
+	Format ImportFormat
+	// Upsert - обновлять продукты с уже существующим slug вместо отказа
+	Upsert bool
+}
+
+const (
+	ImportStatusCreated  = "created"
+	ImportStatusUpdated  = "updated"
+	ImportStatusRejected = "rejected"
+)
+
+// ImportRowResult - итог по одной строке входного файла
+type ImportRowResult struct {
+	Line      int    `json:"line"`
+	Slug      string `json:"slug,omitempty"`
+	Status    string `json:"status"`
+	Reason    string `json:"reason,omitempty"`
+	ProductID int32  `json:"product_id,omitempty"`
+}
+
+type ImportReport struct {
+	Accepted int               `json:"accepted"`
+	Created  int               `json:"created"`
+	Updated  int               `json:"updated"`
+	Rejected int               `json:"rejected"`
+	Rows     []ImportRowResult `json:"rows"`
+}
+
+// importRecord - строка файла после разбора, до валидации
+type importRecord struct {
+	line  int
+	input CreateProductInput
+	err   error
+}
+
+// importJSONRow - формат строки JSON Lines
+type importJSONRow struct {
+	Slug        string `json:"slug"`
+	Title       string `json:"title"`
+	Description string `json:"description"`
+	PriceCents  int64  `json:"price_cents"`
+	Currency    string `json:"currency"`
+}
+
+// Import загружает продукты из CSV или JSON Lines.
+// Каждая строка проверяется по тем же правилам, что и Create; строки с ошибками
+// попадают в отчёт, остальные записываются одной транзакцией.
+// Ошибка возвращается только если файл не читается или упала база.
+func (s *ProductService) Import(ctx context.Context, r io.Reader, opts ImportOptions) (ImportReport, error) {
+	var (
+		records []importRecord
+		err     error
+	)
+	switch opts.Format {
+	case ImportFormatCSV:
+		records, err = parseImportCSV(r)
+	case ImportFormatJSONL:
+		records, err = parseImportJSONL(r)
+	default:
+		err = fmt.Errorf("%w: unknown import format %q", ErrInvalidInput, opts.Format)
+	}
+	if err != nil {
+		return ImportReport{}, fmt.Errorf("service: import products: %w", err)
+	}
+
+	report := ImportReport{
+		Rows: make([]ImportRowResult, len(records)),
+	}
+
+	var (
+		products  []store.ImportProduct
+		positions []int
+	)
+	seen := make(map[string]int, len(records))
+
+	for i, rec := range records {
+		row := &report.Rows[i]
+		row.Line = rec.line
+		row.Slug = rec.input.Slug
+
+		if rec.err == nil {
+			rec.err = rec.input.validate()
+		}
+		if rec.err != nil {
+			row.Status = ImportStatusRejected
+			row.Reason = strings.TrimPrefix(rec.err.Error(), ErrInvalidInput.Error()+": ")
+			continue
+		}
+
+		// Без явного slug строим его из названия - без суффиксов,
+		// чтобы повторный импорт того же файла попадал в те же продукты
+		if row.Slug == "" {
+			row.Slug = slug.Make(rec.input.Title)
+		}
+		if first, ok := seen[row.Slug]; ok {
+			row.Status = ImportStatusRejected
+			row.Reason = fmt.Sprintf("duplicate slug %q, first seen on line %d", row.Slug, first)
+			continue
+		}
+		seen[row.Slug] = rec.line
+
+		currency, _ := rec.input.currency()
+		products = append(products, store.ImportProduct{
+			Slug:        row.Slug,
+			Title:       rec.input.Title,
+			Description: rec.input.Description,
+			PriceCents:  rec.input.PriceCents,
+			Currency:    currency,
+		})
+		positions = append(positions, i)
+	}
+
+	if len(products) > 0 {
+		imported, err := s.store.Import(ctx, products, opts.Upsert)
+		if err != nil {
+			return ImportReport{}, fmt.Errorf("service: import products: %w", err)
+		}
+
+		for j, res := range imported {
+			row := &report.Rows[positions[j]]
+			switch {
+			case res.ID == 0:
+				row.Status = ImportStatusRejected
+				row.Reason = fmt.Sprintf("slug %q already exists", res.Slug)
+			case res.Inserted:
+				row.Status = ImportStatusCreated
+				row.ProductID = res.ID
+			default:
+				row.Status = ImportStatusUpdated
+				row.ProductID = res.ID
+			}
+		}
+	}
+
+	for _, row := range report.Rows {
+		switch row.Status {
+		case ImportStatusCreated:
+			report.Created++
+		case ImportStatusUpdated:
+			report.Updated++
+		default:
+			report.Rejected++
+		}
+	}
+	report.Accepted = report.Created + report.Updated
+
+	return report, nil
+}
+
+// parseImportCSV ждёт заголовок с колонками slug, title, description, price_cents, currency.
+// Обязательны title и price_cents, порядок колонок произвольный.
+func parseImportCSV(r io.Reader) ([]importRecord, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil, fmt.Errorf("%w: empty file", ErrInvalidInput)
+		}
+		return nil, fmt.Errorf("%w: read csv header: %v", ErrInvalidInput, err)
+	}
+
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		name = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))
+		columns[name] = i
+	}
+	for _, required := range []string{"title", "price_cents"} {
+		if _, ok := columns[required]; !ok {
+			return nil, fmt.Errorf("%w: csv header has no %q column", ErrInvalidInput, required)
+		}
+	}
+
+	field := func(record []string, name string) string {
+		i, ok := columns[name]
+		if !ok || i >= len(record) {
+			return ""
+		}
+		return strings.TrimSpace(record[i])
+	}
+
+	var records []importRecord
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+
+		// Неверное число полей - ошибка строки, остальное (битые кавычки) - всего файла
+		if err != nil && !errors.Is(err, csv.ErrFieldCount) {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidInput, err)
+		}
+		line, _ := reader.FieldPos(0)
+		if len(records) >= MaxImportRows {
+			return nil, fmt.Errorf("%w: more than %d rows", ErrInvalidInput, MaxImportRows)
+		}
+
+		rec := importRecord{
+			line: line,
+			input: CreateProductInput{
+				Slug:        field(record, "slug"),
+				Title:       field(record, "title"),
+				Description: field(record, "description"),
+				Currency:    field(record, "currency"),
+			},
+		}
+		if err != nil {
+			rec.err = fmt.Errorf("wrong number of fields")
+		} else if price, pErr := strconv.ParseInt(field(record, "price_cents"), 10, 64); pErr != nil {
+			rec.err = fmt.Errorf("price_cents must be an integer")
+		} else {
+			rec.input.PriceCents = price
+		}
+
+		records = append(records, rec)
+	}
+
+	return records, nil
+}
+
+// parseImportJSONL - по одному JSON-объекту на строку, пустые строки пропускаются
+func parseImportJSONL(r io.Reader) ([]importRecord, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), maxImportLineBytes)
+
+	var records []importRecord
+	line := 0
+	for scanner.Scan() {
+		line++
+		raw := bytes.TrimSpace(scanner.Bytes())
+		if len(raw) == 0 {
+			continue
+		}
+		if len(records) >= MaxImportRows {
+			return nil, fmt.Errorf("%w: more than %d rows", ErrInvalidInput, MaxImportRows)
+		}
+
+		var row importJSONRow
+		decoder := json.NewDecoder(bytes.NewReader(raw))
+		decoder.DisallowUnknownFields()
+
+		rec := importRecord{line: line}
+		if err := decoder.Decode(&row); err != nil {
+			rec.err = fmt.Errorf("invalid JSON: %v", err)
+		} else {
+			rec.input = CreateProductInput{
+				Slug:        strings.TrimSpace(row.Slug),
+				Title:       strings.TrimSpace(row.Title),
+				Description: strings.TrimSpace(row.Description),
+				PriceCents:  row.PriceCents,
+				Currency:    strings.TrimSpace(row.Currency),
+			}
+		}
+
+		records = append(records, rec)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("%w: read jsonl: %v", ErrInvalidInput, err)
+	}
+
+	return records, nil
+}
@@ -0,0 +1,34 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// RunPurgeJob раз в interval удаляет продукты, мягко удалённые больше retention назад.
+// Блокируется до отмены ctx, запускать в отдельной горутине.
+func (s *ProductService) RunPurgeJob(ctx context.Context, interval, retention time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			rows, err := s.PurgeDeleted(ctx, retention)
+			if err != nil {
+				logrus.WithError(err).Error("purge deleted products")
+				continue
+			}
+			if rows > 0 {
+				logrus.WithFields(logrus.Fields{
+					"purged":    rows,
+					"retention": retention.String(),
+				}).Info("purged deleted products")
+			}
+		}
+	}
+}
@@ -6,50 +6,109 @@ import (
 	"database/sql"
 	"errors"
 	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
 
 	productsdb "db200/internal/db/products"
+	"db200/internal/money"
+	"db200/internal/slug"
 	"db200/internal/store"
 )
 
 type ProductService struct {
-	store *store.ProductStore
+	store ProductStore
 }
 
-func NewProductService(productStore *store.ProductStore) *ProductService {
+func NewProductService(productStore ProductStore) *ProductService {
 	return &ProductService{
 		store: productStore,
 	}
 }
 
+// DefaultListLimit - размер страницы, если клиент его не указал
+const DefaultListLimit = 10
+
 // Ошибки объявляются в сервисе
 var (
 	ErrNotFound     = errors.New("not found")
 	ErrInvalidInput = errors.New("invalid input")
+	ErrConflict     = errors.New("conflict")
+	ErrForbidden    = errors.New("forbidden")
 )
 
+// CreateProductInput - если Slug пустой, он строится из Title,
+// если Currency пустая - берётся money.DefaultCurrency
 type CreateProductInput struct {
 	Slug        string
 	Title       string
 	Description string
-	PriceCents  int32
+	PriceCents  int64
+	Currency    string
 }
 
-func (s *ProductService) Create(ctx context.Context, input CreateProductInput) (productsdb.Product, error) {
-	// Валидация
-	if input.Slug == "" {
-		return productsdb.Product{}, fmt.Errorf("slug is required")
+// validate - общие правила для Create и импорта
+func (input CreateProductInput) validate() error {
+	if input.Slug == "" && strings.TrimSpace(input.Title) == "" {
+		return fmt.Errorf("%w: slug or title is required", ErrInvalidInput)
+	}
+	if input.Slug != "" && !slug.Valid(input.Slug) {
+		return fmt.Errorf("%w: malformed slug %q", ErrInvalidInput, input.Slug)
 	}
 	if input.PriceCents <= 0 {
-		return productsdb.Product{}, fmt.Errorf("price must be positive")
+		return fmt.Errorf("%w: price must be positive", ErrInvalidInput)
+	}
+	if _, err := input.currency(); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (input CreateProductInput) currency() (money.Currency, error) {
+	if input.Currency == "" {
+		return money.DefaultCurrency, nil
 	}
+	currency, err := money.ParseCurrency(input.Currency)
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", ErrInvalidInput, err)
+	}
+	return currency, nil
+}
 
-	product, err := s.store.Create(ctx, productsdb.CreateProductParams{
+func (s *ProductService) Create(ctx context.Context, input CreateProductInput) (productsdb.Product, error) {
+	// Валидация
+	if err := input.validate(); err != nil {
+		return productsdb.Product{}, fmt.Errorf("service: create product: %w", err)
+	}
+	currency, _ := input.currency()
+
+	params := productsdb.CreateProductParams{
 		Slug:        input.Slug,
 		Title:       input.Title,
 		Description: input.Description,
 		PriceCents:  input.PriceCents,
-	})
+		Currency:    string(currency),
+	}
+
+	// Явно заданный slug не меняем: занят - значит конфликт
+	if params.Slug != "" {
+		product, err := s.store.Create(ctx, params)
+		if err != nil {
+			if errors.Is(err, store.ErrSlugTaken) {
+				return product, fmt.Errorf("service: create product: %w: slug %q is taken",
+					ErrConflict, params.Slug)
+			}
+			return product, fmt.Errorf("create: %w", err)
+		}
+		return product, nil
+	}
+
+	params.Slug = slug.Make(input.Title)
+	product, err := s.store.CreateWithUniqueSlug(ctx, params)
 	if err != nil {
+		if errors.Is(err, store.ErrSlugTaken) {
+			return product, fmt.Errorf("service: create product: %w: %v", ErrConflict, err)
+		}
 		return product, fmt.Errorf("create: %w", err)
 	}
 	return product, nil
@@ -67,64 +126,429 @@ func (s *ProductService) Get(ctx context.Context, id int32) (productsdb.Product,
 	return product, nil
 }
 
-func (s *ProductService) List(ctx context.Context, limit, offset int32) ([]productsdb.Product, error) {
+// GetIncludingDeleted - как Get, но видит и мягко удалённые продукты
+func (s *ProductService) GetIncludingDeleted(ctx context.Context, id int32) (productsdb.Product, error) {
+
+	product, err := s.store.GetIncludingDeleted(ctx, id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return product, fmt.Errorf("product %d not found: %w", id, ErrNotFound)
+		}
+		return product, fmt.Errorf("get product %d: %w", id, err)
+	}
+	return product, nil
+}
+
+// GetInCurrency - как Get, но цена пересчитана в валюту currency по локальной таблице курсов
+func (s *ProductService) GetInCurrency(ctx context.Context, id int32, currency string) (productsdb.Product, error) {
+	product, err := s.Get(ctx, id)
+	if err != nil {
+		return product, err
+	}
+
+	converted, err := s.convertProducts(ctx, []productsdb.Product{product}, currency)
+	if err != nil {
+		return productsdb.Product{}, fmt.Errorf("service: get product %d: %w", id, err)
+	}
+	return converted[0], nil
+}
+
+func (s *ProductService) GetBySlug(ctx context.Context, productSlug string) (productsdb.Product, error) {
+	if !slug.Valid(productSlug) {
+		return productsdb.Product{}, fmt.Errorf("service: get product: %w: malformed slug %q",
+			ErrInvalidInput, productSlug)
+	}
+
+	product, err := s.store.GetBySlug(ctx, productSlug)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return product, fmt.Errorf("product %q not found: %w", productSlug, ErrNotFound)
+		}
+		return product, fmt.Errorf("get product %q: %w", productSlug, err)
+	}
+	return product, nil
+}
+
+// ProductFilter - фильтры списка: категория вместе с подкатегориями,
+// хотя бы один из AnyTags и все AllTags, диапазон цены в минорных единицах,
+// окно создания [CreatedFrom, CreatedTo) и префикс slug. Нулевые значения не фильтруют.
+type ProductFilter struct {
+	CategoryID    int32
+	AnyTags       []string
+	AllTags       []string
+	MinPriceCents int64
+	MaxPriceCents int64
+	CreatedFrom   time.Time
+	CreatedTo     time.Time
+	SlugPrefix    string
+}
+
+func (f ProductFilter) toStore() (store.ProductFilter, error) {
+	if f.CategoryID < 0 {
+		return store.ProductFilter{}, fmt.Errorf("%w: invalid category id %d", ErrInvalidInput, f.CategoryID)
+	}
+	anyTags, err := normalizeTags(f.AnyTags)
+	if err != nil {
+		return store.ProductFilter{}, err
+	}
+	allTags, err := normalizeTags(f.AllTags)
+	if err != nil {
+		return store.ProductFilter{}, err
+	}
+	if f.MinPriceCents < 0 || f.MaxPriceCents < 0 {
+		return store.ProductFilter{}, fmt.Errorf("%w: negative price bound", ErrInvalidInput)
+	}
+	if f.MaxPriceCents > 0 && f.MinPriceCents > f.MaxPriceCents {
+		return store.ProductFilter{}, fmt.Errorf("%w: min price %d greater than max price %d",
+			ErrInvalidInput, f.MinPriceCents, f.MaxPriceCents)
+	}
+	if !f.CreatedFrom.IsZero() && !f.CreatedTo.IsZero() && !f.CreatedFrom.Before(f.CreatedTo) {
+		return store.ProductFilter{}, fmt.Errorf("%w: created_from must be before created_to", ErrInvalidInput)
+	}
+	slugPrefix := strings.ToLower(strings.TrimSpace(f.SlugPrefix))
+	if slugPrefix != "" && !validSlugPrefix(slugPrefix) {
+		return store.ProductFilter{}, fmt.Errorf("%w: malformed slug prefix %q", ErrInvalidInput, f.SlugPrefix)
+	}
+
+	return store.ProductFilter{
+		CategoryID:    f.CategoryID,
+		AnyTags:       anyTags,
+		AllTags:       allTags,
+		MinPriceCents: f.MinPriceCents,
+		MaxPriceCents: f.MaxPriceCents,
+		// Границы created_at сравниваются с TIMESTAMP без зоны, поэтому приводятся к UTC
+		CreatedFrom: f.CreatedFrom.UTC(),
+		CreatedTo:   f.CreatedTo.UTC(),
+		SlugPrefix:  slugPrefix,
+	}, nil
+}
+
+// validSlugPrefix - префикс из тех же символов, что и slug: a-z, 0-9 и дефис
+func validSlugPrefix(prefix string) bool {
+	if len(prefix) > slug.MaxLength {
+		return false
+	}
+	for _, r := range prefix {
+		if (r < 'a' || r > 'z') && (r < '0' || r > '9') && r != '-' {
+			return false
+		}
+	}
+	return true
+}
+
+type ListInput struct {
+	Limit          int32
+	Offset         int32
+	IncludeDeleted bool
+	// Currency - валюта, в которой вернуть цены; пустая - как хранятся
+	Currency string
+	Filter   ProductFilter
+	// Sort - created_asc (по умолчанию), created_desc, price_asc, price_desc, title_asc, title_desc
+	Sort string
+}
+
+// List - постраничный список по offset
+func (s *ProductService) List(ctx context.Context, input ListInput) ([]productsdb.Product, error) {
 	// Бизнес-правила для пагинации
-	if limit <= 0 {
-		limit = 10 // дефолтное значение
+	if input.Limit <= 0 {
+		input.Limit = DefaultListLimit // дефолтное значение
 	}
-	if limit > 50 {
+	if input.Limit > store.MaxListLimit {
 		return nil, fmt.Errorf("service: list products: %w: limit too large %d",
-			ErrInvalidInput, limit)
+			ErrInvalidInput, input.Limit)
+	}
+	if input.Offset < 0 {
+		return nil, fmt.Errorf("service: list products: %w: negative offset %d",
+			ErrInvalidInput, input.Offset)
+	}
+	if input.Sort != "" && !store.ValidSort(input.Sort) {
+		return nil, fmt.Errorf("service: list products: %w: unknown sort %q", ErrInvalidInput, input.Sort)
+	}
+	filter, err := input.Filter.toStore()
+	if err != nil {
+		return nil, fmt.Errorf("service: list products: %w", err)
+	}
+
+	products, err := s.store.List(ctx, store.ListParams{
+		Limit:          input.Limit,
+		Offset:         input.Offset,
+		IncludeDeleted: input.IncludeDeleted,
+		Filter:         filter,
+		Sort:           input.Sort,
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("service: list products: %w: category %d not found",
+				ErrNotFound, filter.CategoryID)
+		}
+		return nil, fmt.Errorf("service: list products: %w", err)
 	}
 
-	products, err := s.store.List(ctx, limit, offset)
+	products, err = s.convertProducts(ctx, products, input.Currency)
 	if err != nil {
 		return nil, fmt.Errorf("service: list products: %w", err)
 	}
 
-	// Можно добавить бизнес-логику (фильтрацию, форматирование и т.д.)
 	return products, nil
 }
 
-func (s *ProductService) UpdatePrice(ctx context.Context, id, priceCents int32) error {
+type ListPageInput struct {
+	Limit          int32
+	After          string
+	Before         string
+	IncludeDeleted bool
+	// Currency - валюта, в которой вернуть цены; пустая - как хранятся
+	Currency string
+	Filter   ProductFilter
+}
+
+// ListPage - курсорная пагинация по (created_at, id)
+func (s *ProductService) ListPage(ctx context.Context, input ListPageInput) (store.ProductPage, error) {
+	if input.Limit <= 0 {
+		input.Limit = DefaultListLimit
+	}
+	if input.Limit > store.MaxListLimit {
+		return store.ProductPage{}, fmt.Errorf("service: list products page: %w: limit too large %d",
+			ErrInvalidInput, input.Limit)
+	}
+	if input.After != "" && input.Before != "" {
+		return store.ProductPage{}, fmt.Errorf("service: list products page: %w: after and before are mutually exclusive",
+			ErrInvalidInput)
+	}
+	filter, err := input.Filter.toStore()
+	if err != nil {
+		return store.ProductPage{}, fmt.Errorf("service: list products page: %w", err)
+	}
+
+	page, err := s.store.ListPage(ctx, store.PageParams{
+		Limit:          input.Limit,
+		After:          input.After,
+		Before:         input.Before,
+		IncludeDeleted: input.IncludeDeleted,
+		Filter:         filter,
+	})
+	if err != nil {
+		if errors.Is(err, store.ErrInvalidCursor) {
+			return page, fmt.Errorf("service: list products page: %w: %v", ErrInvalidInput, err)
+		}
+		if errors.Is(err, sql.ErrNoRows) {
+			return page, fmt.Errorf("service: list products page: %w: category %d not found",
+				ErrNotFound, filter.CategoryID)
+		}
+		return page, fmt.Errorf("service: list products page: %w", err)
+	}
+
+	page.Products, err = s.convertProducts(ctx, page.Products, input.Currency)
+	if err != nil {
+		return store.ProductPage{}, fmt.Errorf("service: list products page: %w", err)
+	}
+
+	return page, nil
+}
+
+// maxSearchQueryLength - ограничение на длину поискового запроса в символах
+const maxSearchQueryLength = 200
+
+type SearchInput struct {
+	Query  string
+	Limit  int32
+	Offset int32
+}
+
+type SearchResult struct {
+	Items []productsdb.SearchProductsRow
+	Total int64
+}
+
+// Search - полнотекстовый поиск по названию и описанию
+func (s *ProductService) Search(ctx context.Context, input SearchInput) (SearchResult, error) {
+	query := strings.TrimSpace(input.Query)
+	if query == "" {
+		return SearchResult{}, fmt.Errorf("service: search products: %w: query is required",
+			ErrInvalidInput)
+	}
+	if utf8.RuneCountInString(query) > maxSearchQueryLength {
+		return SearchResult{}, fmt.Errorf("service: search products: %w: query too long",
+			ErrInvalidInput)
+	}
+	if input.Limit <= 0 {
+		input.Limit = DefaultListLimit
+	}
+	if input.Limit > store.MaxListLimit {
+		return SearchResult{}, fmt.Errorf("service: search products: %w: limit too large %d",
+			ErrInvalidInput, input.Limit)
+	}
+	if input.Offset < 0 {
+		return SearchResult{}, fmt.Errorf("service: search products: %w: negative offset %d",
+			ErrInvalidInput, input.Offset)
+	}
+
+	items, total, err := s.store.Search(ctx, query, input.Limit, input.Offset)
+	if err != nil {
+		return SearchResult{}, fmt.Errorf("service: search products: %w", err)
+	}
+
+	return SearchResult{
+		Items: items,
+		Total: total,
+	}, nil
+}
+
+// UpdatePrice меняет цену продукта. Пустая currency - валюта продукта не меняется.
+// expectedVersion - версия, которую видел клиент (store.AnyVersion - без проверки);
+// при расхождении возвращается *VersionConflictError.
+func (s *ProductService) UpdatePrice(ctx context.Context, id int32, priceCents int64, currency string, expectedVersion int32) (productsdb.Product, error) {
 	// Бизнес-правила
 	if id <= 0 {
-		return fmt.Errorf("service: update price: %w: invalid id %d",
+		return productsdb.Product{}, fmt.Errorf("service: update price: %w: invalid id %d",
 			ErrInvalidInput, id)
 	}
 	if priceCents <= 0 {
-		return fmt.Errorf("service: update price: %w: price must be positive, got %d",
+		return productsdb.Product{}, fmt.Errorf("service: update price: %w: price must be positive, got %d",
 			ErrInvalidInput, priceCents)
 	}
+	if expectedVersion < 0 {
+		return productsdb.Product{}, fmt.Errorf("service: update price: %w: invalid version %d",
+			ErrInvalidInput, expectedVersion)
+	}
+
+	// Пустую валюту store оставит прежней в том же UPDATE, без чтения заранее
+	price := money.Money{Amount: priceCents}
+	if currency != "" {
+		parsed, err := money.ParseCurrency(currency)
+		if err != nil {
+			return productsdb.Product{}, fmt.Errorf("service: update price: %w: %v", ErrInvalidInput, err)
+		}
+		price = money.New(priceCents, parsed)
+	}
 
-	rows, err := s.store.UpdatePrice(ctx, id, priceCents)
+	product, err := s.store.UpdatePrice(ctx, id, price, expectedVersion)
 	if err != nil {
-		return fmt.Errorf("service: update price: %w", err)
+		if errors.Is(err, sql.ErrNoRows) {
+			return product, fmt.Errorf("service: update price: %w",
+				s.unchangedError(ctx, id, expectedVersion, "product %d not found"))
+		}
+		return product, fmt.Errorf("service: update price: %w", err)
 	}
 
-	if rows == 0 {
-		return fmt.Errorf("service: update price: %w: product %d not found",
-			ErrNotFound, id)
+	return product, nil
+}
+
+// PriceHistory - история изменения цены, новые записи первыми
+func (s *ProductService) PriceHistory(ctx context.Context, id, limit int32) ([]productsdb.ProductPrice, error) {
+	if id <= 0 {
+		return nil, fmt.Errorf("service: price history: %w: invalid id %d",
+			ErrInvalidInput, id)
+	}
+	if limit <= 0 {
+		limit = store.MaxListLimit
+	}
+	if limit > store.MaxListLimit {
+		return nil, fmt.Errorf("service: price history: %w: limit too large %d",
+			ErrInvalidInput, limit)
 	}
 
-	return nil
+	// Пустая история у несуществующего продукта - это 404, а не пустой список.
+	// Удалённые продукты нужны для аудита, поэтому их историю тоже отдаём.
+	if _, err := s.GetIncludingDeleted(ctx, id); err != nil {
+		return nil, fmt.Errorf("service: price history: %w", err)
+	}
+
+	prices, err := s.store.PriceHistory(ctx, id, limit)
+	if err != nil {
+		return nil, fmt.Errorf("service: price history: %w", err)
+	}
+	return prices, nil
+}
+
+// PriceAt - цена продукта, действовавшая в момент at
+func (s *ProductService) PriceAt(ctx context.Context, id int32, at time.Time) (productsdb.ProductPrice, error) {
+	if id <= 0 {
+		return productsdb.ProductPrice{}, fmt.Errorf("service: price at: %w: invalid id %d",
+			ErrInvalidInput, id)
+	}
+	if at.IsZero() {
+		return productsdb.ProductPrice{}, fmt.Errorf("service: price at: %w: time is required",
+			ErrInvalidInput)
+	}
+
+	price, err := s.store.PriceAt(ctx, id, at)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return price, fmt.Errorf("service: price at: %w: product %d has no price at %s",
+				ErrNotFound, id, at.Format(time.RFC3339))
+		}
+		return price, fmt.Errorf("service: price at: %w", err)
+	}
+	return price, nil
 }
 
-func (s *ProductService) Delete(ctx context.Context, id int32) error {
+// Delete мягко удаляет продукт; expectedVersion - как в UpdatePrice
+func (s *ProductService) Delete(ctx context.Context, id, expectedVersion int32) error {
 	// Бизнес-правила
 	if id <= 0 {
 		return fmt.Errorf("service: delete product: %w: invalid id %d",
 			ErrInvalidInput, id)
 	}
 
-	rows, err := s.store.Delete(ctx, id)
+	rows, err := s.store.Delete(ctx, id, expectedVersion)
 	if err != nil {
 		return fmt.Errorf("service: delete product: %w", err)
 	}
 
 	if rows == 0 {
-		return fmt.Errorf("service: delete product: %w: product %d not found",
-			ErrNotFound, id)
+		return fmt.Errorf("service: delete product: %w",
+			s.unchangedError(ctx, id, expectedVersion, "product %d not found"))
+	}
+
+	return nil
+}
+
+// Restore восстанавливает мягко удалённый продукт; expectedVersion - как в UpdatePrice
+func (s *ProductService) Restore(ctx context.Context, id, expectedVersion int32) error {
+	if id <= 0 {
+		return fmt.Errorf("service: restore product: %w: invalid id %d",
+			ErrInvalidInput, id)
+	}
+
+	rows, err := s.store.Restore(ctx, id, expectedVersion)
+	if err != nil {
+		return fmt.Errorf("service: restore product: %w", err)
+	}
+
+	if rows == 0 {
+		return fmt.Errorf("service: restore product: %w",
+			s.unchangedError(ctx, id, expectedVersion, "deleted product %d not found"))
+	}
+
+	return nil
+}
+
+// PurgeDeleted безвозвратно удаляет продукты, которые лежат в корзине дольше retention
+func (s *ProductService) PurgeDeleted(ctx context.Context, retention time.Duration) (int64, error) {
+	if retention <= 0 {
+		return 0, fmt.Errorf("service: purge products: %w: retention must be positive, got %s",
+			ErrInvalidInput, retention)
+	}
+
+	rows, err := s.store.PurgeDeleted(ctx, time.Now().Add(-retention))
+	if err != nil {
+		return 0, fmt.Errorf("service: purge products: %w", err)
+	}
+
+	return rows, nil
+}
+
+// DeleteAll удаляет ВСЕ продукты без возможности восстановления.
+// Работает только с явно переданным admin = true.
+func (s *ProductService) DeleteAll(ctx context.Context, admin bool) error {
+	if !admin {
+		return fmt.Errorf("service: delete all products: %w: admin flag required",
+			ErrForbidden)
+	}
+
+	if err := s.store.DeleteAll(ctx); err != nil {
+		return fmt.Errorf("service: delete all products: %w", err)
 	}
 
 	return nil
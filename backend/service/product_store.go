@@ -0,0 +1,44 @@
+package service
+
+import (
+	"context"
+	"math/big"
+	"time"
+
+	productsdb "db200/internal/db/products"
+	"db200/internal/money"
+	"db200/internal/store"
+)
+
+// ProductStore - то, что ProductService берёт из хранилища.
+// Реализации: *store.ProductStore и кэширующая обёртка *store.CachedProductStore.
+type ProductStore interface {
+	Create(ctx context.Context, params productsdb.CreateProductParams) (productsdb.Product, error)
+	CreateWithUniqueSlug(ctx context.Context, params productsdb.CreateProductParams) (productsdb.Product, error)
+	Import(ctx context.Context, products []store.ImportProduct, upsert bool) ([]store.ImportedProduct, error)
+
+	Get(ctx context.Context, id int32) (productsdb.Product, error)
+	GetIncludingDeleted(ctx context.Context, id int32) (productsdb.Product, error)
+	GetBySlug(ctx context.Context, productSlug string) (productsdb.Product, error)
+	List(ctx context.Context, params store.ListParams) ([]productsdb.Product, error)
+	ListPage(ctx context.Context, params store.PageParams) (store.ProductPage, error)
+	Search(ctx context.Context, query string, limit, offset int32) ([]productsdb.SearchProductsRow, int64, error)
+
+	UpdatePrice(ctx context.Context, id int32, price money.Money, expectedVersion int32) (productsdb.Product, error)
+	PriceHistory(ctx context.Context, id, limit int32) ([]productsdb.ProductPrice, error)
+	PriceAt(ctx context.Context, id int32, at time.Time) (productsdb.ProductPrice, error)
+
+	Delete(ctx context.Context, id, expectedVersion int32) (int64, error)
+	Restore(ctx context.Context, id, expectedVersion int32) (int64, error)
+	PurgeDeleted(ctx context.Context, before time.Time) (int64, error)
+	DeleteAll(ctx context.Context) error
+
+	SetExchangeRate(ctx context.Context, base, quote money.Currency, rate *big.Rat) (productsdb.ExchangeRate, error)
+	ExchangeRate(ctx context.Context, from, to money.Currency) (*big.Rat, error)
+	ListExchangeRates(ctx context.Context) ([]productsdb.ExchangeRate, error)
+}
+
+var (
+	_ ProductStore = (*store.ProductStore)(nil)
+	_ ProductStore = (*store.CachedProductStore)(nil)
+)
@@ -0,0 +1,45 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"db200/internal/store"
+)
+
+// VersionConflictError - продукт изменили после того, как клиент его прочитал.
+// errors.Is(err, ErrConflict) для неё истинно.
+type VersionConflictError struct {
+	ProductID int32
+	Expected  int32
+	Actual    int32
+}
+
+func (e *VersionConflictError) Error() string {
+	return fmt.Sprintf("conflict: product %d version is %d, expected %d", e.ProductID, e.Actual, e.Expected)
+}
+
+func (e *VersionConflictError) Unwrap() error {
+	return ErrConflict
+}
+
+// unchangedError объясняет, почему условный UPDATE не задел ни одной строки:
+// другая версия продукта - *VersionConflictError, иначе ErrNotFound с сообщением notFound.
+func (s *ProductService) unchangedError(ctx context.Context, id, expectedVersion int32, notFound string) error {
+	if expectedVersion != store.AnyVersion {
+		product, err := s.GetIncludingDeleted(ctx, id)
+		if err != nil && !errors.Is(err, ErrNotFound) {
+			return err
+		}
+		if err == nil && product.Version != expectedVersion {
+			return &VersionConflictError{
+				ProductID: id,
+				Expected:  expectedVersion,
+				Actual:    product.Version,
+			}
+		}
+	}
+
+	return fmt.Errorf("%w: "+notFound, ErrNotFound, id)
+}
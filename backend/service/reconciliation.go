@@ -0,0 +1,571 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	paymentsdb "db200/internal/db/payments"
+	"db200/internal/money"
+	"db200/internal/store"
+)
+
+// Результат сверки по строке файла или по платежу
+const (
+	// ReconciliationMatched - строка файла совпала с платежом по счёту и сумме
+	ReconciliationMatched = "matched"
+	// ReconciliationMissing - списание за период есть, в файле провайдера его нет
+	ReconciliationMissing = "missing"
+	// ReconciliationExtra - в файле есть строка, для которой нет списания за период
+	ReconciliationExtra = "extra"
+	// ReconciliationAmountMismatch - счёт совпал, сумма нет
+	ReconciliationAmountMismatch = "amount_mismatch"
+	// ReconciliationInvalid - строку файла не удалось разобрать
+	ReconciliationInvalid = "invalid"
+)
+
+// Как в файле записана сумма
+const (
+	SettlementAmountMinor = "minor"
+	SettlementAmountMajor = "major"
+)
+
+// Чем в файле обозначен счёт
+const (
+	SettlementInvoiceByID     = "id"
+	SettlementInvoiceByNumber = "number"
+)
+
+// maxReconciliationSourceLength - ограничение на имя источника (обычно имя файла)
+const maxReconciliationSourceLength = 255
+
+// SettlementMapping - как читать файл расчётов конкретного провайдера.
+// Пустые поля получают значения по умолчанию, см. normalize.
+type SettlementMapping struct {
+	// InvoiceColumn - колонка со счётом, по умолчанию invoice_id
+	InvoiceColumn string
+	// AmountColumn - колонка с суммой, по умолчанию amount
+	AmountColumn string
+	// ReferenceColumn - необязательная колонка с id операции у провайдера, попадает в отчёт
+	ReferenceColumn string
+	// AmountFormat - minor (копейки, по умолчанию) или major ("1234.50")
+	AmountFormat string
+	// Currency - валюта для major-сумм, по умолчанию валюта каталога
+	Currency string
+	// InvoiceKey - id (по умолчанию) или number ("2026-000042")
+	InvoiceKey string
+	// Delimiter - разделитель полей, по умолчанию запятая
+	Delimiter rune
+}
+
+func (m *SettlementMapping) normalize() error {
+	column := func(name *string, def string) {
+		*name = strings.ToLower(strings.TrimSpace(*name))
+		if *name == "" {
+			*name = def
+		}
+	}
+	column(&m.InvoiceColumn, "invoice_id")
+	column(&m.AmountColumn, "amount")
+	column(&m.ReferenceColumn, "")
+	if m.InvoiceColumn == m.AmountColumn {
+		return fmt.Errorf("%w: invoice and amount columns must differ", ErrInvalidInput)
+	}
+
+	m.AmountFormat = strings.ToLower(strings.TrimSpace(m.AmountFormat))
+	switch m.AmountFormat {
+	case "":
+		m.AmountFormat = SettlementAmountMinor
+	case SettlementAmountMinor, SettlementAmountMajor:
+	default:
+		return fmt.Errorf("%w: unknown amount format %q", ErrInvalidInput, m.AmountFormat)
+	}
+
+	if strings.TrimSpace(m.Currency) == "" {
+		m.Currency = string(money.DefaultCurrency)
+	}
+	currency, err := money.ParseCurrency(m.Currency)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidInput, err)
+	}
+	m.Currency = string(currency)
+
+	m.InvoiceKey = strings.ToLower(strings.TrimSpace(m.InvoiceKey))
+	switch m.InvoiceKey {
+	case "":
+		m.InvoiceKey = SettlementInvoiceByID
+	case SettlementInvoiceByID, SettlementInvoiceByNumber:
+	default:
+		return fmt.Errorf("%w: unknown invoice key %q", ErrInvalidInput, m.InvoiceKey)
+	}
+
+	if m.Delimiter == 0 {
+		m.Delimiter = ','
+	}
+	if m.Delimiter == '"' || m.Delimiter == '\r' || m.Delimiter == '\n' || m.Delimiter == utf8.RuneError {
+		return fmt.Errorf("%w: invalid delimiter %q", ErrInvalidInput, m.Delimiter)
+	}
+	return nil
+}
+
+// ReconcileInput - файл сверяется со списаниями за период [From, To)
+type ReconcileInput struct {
+	// Source - откуда файл, обычно его имя
+	Source  string
+	From    time.Time
+	To      time.Time
+	Mapping SettlementMapping
+}
+
+func (input *ReconcileInput) normalize() error {
+	input.Source = strings.TrimSpace(input.Source)
+	if input.Source == "" {
+		return fmt.Errorf("%w: source is required", ErrInvalidInput)
+	}
+	if utf8.RuneCountInString(input.Source) > maxReconciliationSourceLength {
+		return fmt.Errorf("%w: source too long", ErrInvalidInput)
+	}
+	if input.From.IsZero() || input.To.IsZero() || !input.From.Before(input.To) {
+		return fmt.Errorf("%w: period start must be before its end", ErrInvalidInput)
+	}
+	return input.Mapping.normalize()
+}
+
+// ReconciliationDetails - отчёт вместе с результатами по строкам
+type ReconciliationDetails struct {
+	Report  paymentsdb.ReconciliationReport
+	Entries []paymentsdb.ReconciliationEntry
+}
+
+type ReconciliationService struct {
+	store *store.ReconciliationStore
+}
+
+func NewReconciliationService(reconciliationStore *store.ReconciliationStore) *ReconciliationService {
+	return &ReconciliationService{
+		store: reconciliationStore,
+	}
+}
+
+// settlementRow - строка файла после разбора
+type settlementRow struct {
+	line       int32
+	invoiceRef string
+	reference  string
+	amount     int64
+	err        error
+}
+
+// Reconcile сверяет файл расчётов провайдера со списаниями за период и сохраняет отчёт.
+// Строка файла сопоставляется с платежом по счёту: сначала с тем, у которого
+// сумма списаний совпадает, иначе с первым ещё не сопоставленным (amount_mismatch).
+// Каждый платёж сопоставляется не больше одного раза, поэтому повтор строки - extra.
+// Платежи, для которых строки не нашлось, попадают в отчёт как missing.
+func (s *ReconciliationService) Reconcile(ctx context.Context, r io.Reader, input ReconcileInput) (ReconciliationDetails, error) {
+	if err := input.normalize(); err != nil {
+		return ReconciliationDetails{}, fmt.Errorf("service: reconcile: %w", err)
+	}
+
+	rows, err := parseSettlementCSV(r, input.Mapping)
+	if err != nil {
+		return ReconciliationDetails{}, fmt.Errorf("service: reconcile: %w", err)
+	}
+
+	candidates, err := s.store.SettlementCandidates(ctx, input.From, input.To)
+	if err != nil {
+		return ReconciliationDetails{}, fmt.Errorf("service: reconcile: %w", err)
+	}
+
+	entries := matchSettlement(rows, candidates, input.Mapping.InvoiceKey)
+
+	report := paymentsdb.CreateReconciliationReportParams{
+		Source:     input.Source,
+		PeriodFrom: input.From,
+		PeriodTo:   input.To,
+		RowsTotal:  int32(len(rows)),
+	}
+	for _, entry := range entries {
+		switch entry.Status {
+		case ReconciliationMatched:
+			report.Matched++
+		case ReconciliationMissing:
+			report.Missing++
+		case ReconciliationExtra:
+			report.Extra++
+		case ReconciliationAmountMismatch:
+			report.Mismatched++
+		case ReconciliationInvalid:
+			report.Invalid++
+		}
+	}
+
+	saved, err := s.store.Save(ctx, report, entries)
+	if err != nil {
+		return ReconciliationDetails{}, fmt.Errorf("service: reconcile: %w", err)
+	}
+	return s.Get(ctx, saved.ID)
+}
+
+func (s *ReconciliationService) Get(ctx context.Context, id int32) (ReconciliationDetails, error) {
+	report, err := s.store.Get(ctx, id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ReconciliationDetails{}, fmt.Errorf("service: get reconciliation: %w: report %d not found", ErrNotFound, id)
+		}
+		return ReconciliationDetails{}, fmt.Errorf("service: get reconciliation %d: %w", id, err)
+	}
+
+	entries, err := s.store.Entries(ctx, id)
+	if err != nil {
+		return ReconciliationDetails{}, fmt.Errorf("service: get reconciliation: %w", err)
+	}
+	return ReconciliationDetails{Report: report, Entries: entries}, nil
+}
+
+// List - последние отчёты без строк, новые первыми
+func (s *ReconciliationService) List(ctx context.Context, limit int32) ([]paymentsdb.ReconciliationReport, error) {
+	if limit <= 0 {
+		limit = store.MaxListLimit
+	}
+	if limit > store.MaxListLimit {
+		return nil, fmt.Errorf("service: list reconciliations: %w: limit too large %d",
+			ErrInvalidInput, limit)
+	}
+
+	reports, err := s.store.List(ctx, limit)
+	if err != nil {
+		return nil, fmt.Errorf("service: list reconciliations: %w", err)
+	}
+	return reports, nil
+}
+
+// parseSettlementCSV читает файл по mapping; колонки счёта и суммы обязательны,
+// порядок колонок произвольный
+func parseSettlementCSV(r io.Reader, mapping SettlementMapping) ([]settlementRow, error) {
+	reader := csv.NewReader(r)
+	reader.Comma = mapping.Delimiter
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil, fmt.Errorf("%w: empty file", ErrInvalidInput)
+		}
+		return nil, fmt.Errorf("%w: read csv header: %v", ErrInvalidInput, err)
+	}
+
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		name = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))
+		columns[name] = i
+	}
+	required := []string{mapping.InvoiceColumn, mapping.AmountColumn}
+	if mapping.ReferenceColumn != "" {
+		required = append(required, mapping.ReferenceColumn)
+	}
+	for _, name := range required {
+		if _, ok := columns[name]; !ok {
+			return nil, fmt.Errorf("%w: csv header has no %q column", ErrInvalidInput, name)
+		}
+	}
+
+	field := func(record []string, name string) string {
+		i, ok := columns[name]
+		if !ok || i >= len(record) {
+			return ""
+		}
+		return strings.TrimSpace(record[i])
+	}
+
+	var rows []settlementRow
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+
+		// Неверное число полей - ошибка строки, остальное (битые кавычки) - всего файла
+		if err != nil && !errors.Is(err, csv.ErrFieldCount) {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidInput, err)
+		}
+		line, _ := reader.FieldPos(0)
+		if len(rows) >= MaxImportRows {
+			return nil, fmt.Errorf("%w: more than %d rows", ErrInvalidInput, MaxImportRows)
+		}
+
+		row := settlementRow{
+			line:       int32(line),
+			invoiceRef: field(record, mapping.InvoiceColumn),
+		}
+		if mapping.ReferenceColumn != "" {
+			row.reference = field(record, mapping.ReferenceColumn)
+		}
+		switch {
+		case err != nil:
+			row.err = fmt.Errorf("wrong number of fields")
+		case row.invoiceRef == "":
+			row.err = fmt.Errorf("%s is empty", mapping.InvoiceColumn)
+		default:
+			row.amount, row.err = parseSettlementAmount(field(record, mapping.AmountColumn), mapping)
+		}
+
+		rows = append(rows, row)
+	}
+
+	return rows, nil
+}
+
+func parseSettlementAmount(s string, mapping SettlementMapping) (int64, error) {
+	var amount int64
+	if mapping.AmountFormat == SettlementAmountMajor {
+		m, err := money.Parse(s, money.Currency(mapping.Currency))
+		if err != nil {
+			return 0, fmt.Errorf("%s: %v", mapping.AmountColumn, err)
+		}
+		amount = m.Amount
+	} else {
+		var err error
+		amount, err = strconv.ParseInt(s, 10, 64)
+		if err != nil {
+			return 0, fmt.Errorf("%s must be an integer", mapping.AmountColumn)
+		}
+	}
+
+	if amount <= 0 {
+		return 0, fmt.Errorf("%s must be positive", mapping.AmountColumn)
+	}
+	return amount, nil
+}
+
+// matchSettlement сопоставляет строки файла с платежами в порядке строк файла
+func matchSettlement(rows []settlementRow, candidates []paymentsdb.ListSettlementCandidatesRow, invoiceKey string) []paymentsdb.InsertReconciliationEntryParams {
+	key := func(c paymentsdb.ListSettlementCandidatesRow) string {
+		if invoiceKey == SettlementInvoiceByNumber {
+			return c.InvoiceNumber
+		}
+		return strconv.FormatInt(int64(c.InvoiceID), 10)
+	}
+
+	byInvoice := make(map[string][]int, len(candidates))
+	for i, c := range candidates {
+		byInvoice[key(c)] = append(byInvoice[key(c)], i)
+	}
+	matched := make([]bool, len(candidates))
+
+	entries := make([]paymentsdb.InsertReconciliationEntryParams, 0, len(rows)+len(candidates))
+	for _, row := range rows {
+		entry := paymentsdb.InsertReconciliationEntryParams{
+			Line:          sql.NullInt32{Int32: row.line, Valid: true},
+			InvoiceRef:    row.invoiceRef,
+			SettlementRef: row.reference,
+		}
+		if row.err != nil {
+			entry.Status = ReconciliationInvalid
+			entry.Note = row.err.Error()
+			entries = append(entries, entry)
+			continue
+		}
+		entry.SettledCents = sql.NullInt64{Int64: row.amount, Valid: true}
+
+		ref := row.invoiceRef
+		if invoiceKey == SettlementInvoiceByID {
+			// "0042" и "42" - один и тот же счёт
+			if id, err := strconv.ParseInt(ref, 10, 32); err == nil {
+				ref = strconv.FormatInt(id, 10)
+			}
+		}
+
+		found := -1
+		for _, i := range byInvoice[ref] {
+			if matched[i] {
+				continue
+			}
+			if candidates[i].CapturedCents == row.amount {
+				found = i
+				break
+			}
+			if found < 0 {
+				found = i
+			}
+		}
+
+		if found < 0 {
+			entry.Status = ReconciliationExtra
+			entry.Note = "no unmatched capture for this invoice in the period"
+			entries = append(entries, entry)
+			continue
+		}
+
+		matched[found] = true
+		candidate := candidates[found]
+		entry.PaymentID = sql.NullInt32{Int32: candidate.PaymentID, Valid: true}
+		entry.ExpectedCents = sql.NullInt64{Int64: candidate.CapturedCents, Valid: true}
+		if candidate.CapturedCents == row.amount {
+			entry.Status = ReconciliationMatched
+		} else {
+			entry.Status = ReconciliationAmountMismatch
+			entry.Note = fmt.Sprintf("settled %d, captured %d", row.amount, candidate.CapturedCents)
+		}
+		entries = append(entries, entry)
+	}
+
+	for i, c := range candidates {
+		if matched[i] {
+			continue
+		}
+		entries = append(entries, paymentsdb.InsertReconciliationEntryParams{
+			Status:        ReconciliationMissing,
+			InvoiceRef:    key(c),
+			PaymentID:     sql.NullInt32{Int32: c.PaymentID, Valid: true},
+			ExpectedCents: sql.NullInt64{Int64: c.CapturedCents, Valid: true},
+			Note:          "capture not found in settlement file",
+		})
+	}
+	return entries
+}
+
+type ReconciliationFormat string
+
+const (
+	ReconciliationFormatJSON ReconciliationFormat = "json"
+	ReconciliationFormatCSV  ReconciliationFormat = "csv"
+)
+
+// ParseReconciliationFormat понимает "json" и "csv"
+func ParseReconciliationFormat(s string) (ReconciliationFormat, error) {
+	switch f := ReconciliationFormat(strings.ToLower(strings.TrimSpace(s))); f {
+	case ReconciliationFormatJSON, ReconciliationFormatCSV:
+		return f, nil
+	}
+	return "", fmt.Errorf("%w: unknown export format %q", ErrInvalidInput, s)
+}
+
+// ReconciliationExport - отчёт в том виде, в котором он выгружается
+type ReconciliationExport struct {
+	ID         int32                       `json:"id"`
+	Source     string                      `json:"source"`
+	PeriodFrom time.Time                   `json:"period_from"`
+	PeriodTo   time.Time                   `json:"period_to"`
+	RowsTotal  int32                       `json:"rows_total"`
+	Matched    int32                       `json:"matched"`
+	Missing    int32                       `json:"missing"`
+	Extra      int32                       `json:"extra"`
+	Mismatched int32                       `json:"mismatched"`
+	Invalid    int32                       `json:"invalid"`
+	CreatedAt  time.Time                   `json:"created_at"`
+	Entries    []ReconciliationExportEntry `json:"entries"`
+}
+
+type ReconciliationExportEntry struct {
+	Status        string `json:"status"`
+	Line          *int32 `json:"line,omitempty"`
+	InvoiceRef    string `json:"invoice_ref"`
+	SettlementRef string `json:"settlement_ref,omitempty"`
+	PaymentID     *int32 `json:"payment_id,omitempty"`
+	SettledCents  *int64 `json:"settled_cents,omitempty"`
+	ExpectedCents *int64 `json:"expected_cents,omitempty"`
+	Note          string `json:"note,omitempty"`
+}
+
+// NewReconciliationExport переводит отчёт в формат выгрузки
+func NewReconciliationExport(details ReconciliationDetails) ReconciliationExport {
+	report := details.Report
+	export := ReconciliationExport{
+		ID:         report.ID,
+		Source:     report.Source,
+		PeriodFrom: report.PeriodFrom,
+		PeriodTo:   report.PeriodTo,
+		RowsTotal:  report.RowsTotal,
+		Matched:    report.Matched,
+		Missing:    report.Missing,
+		Extra:      report.Extra,
+		Mismatched: report.Mismatched,
+		Invalid:    report.Invalid,
+		CreatedAt:  report.CreatedAt,
+		Entries:    make([]ReconciliationExportEntry, 0, len(details.Entries)),
+	}
+
+	for _, entry := range details.Entries {
+		e := ReconciliationExportEntry{
+			Status:        entry.Status,
+			InvoiceRef:    entry.InvoiceRef,
+			SettlementRef: entry.SettlementRef,
+			Note:          entry.Note,
+		}
+		if entry.Line.Valid {
+			e.Line = &entry.Line.Int32
+		}
+		if entry.PaymentID.Valid {
+			e.PaymentID = &entry.PaymentID.Int32
+		}
+		if entry.SettledCents.Valid {
+			e.SettledCents = &entry.SettledCents.Int64
+		}
+		if entry.ExpectedCents.Valid {
+			e.ExpectedCents = &entry.ExpectedCents.Int64
+		}
+		export.Entries = append(export.Entries, e)
+	}
+	return export
+}
+
+// WriteReconciliation выгружает отчёт: json - отчёт целиком,
+// csv - по строке на результат, пустые ячейки там, где значения нет
+func WriteReconciliation(w io.Writer, details ReconciliationDetails, format ReconciliationFormat) error {
+	export := NewReconciliationExport(details)
+
+	switch format {
+	case ReconciliationFormatJSON:
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(export)
+
+	case ReconciliationFormatCSV:
+		writer := csv.NewWriter(w)
+		if err := writer.Write([]string{"status", "line", "invoice_ref", "settlement_ref", "payment_id", "settled_cents", "expected_cents", "note"}); err != nil {
+			return err
+		}
+		for _, e := range export.Entries {
+			if err := writer.Write([]string{
+				e.Status,
+				optionalInt(e.Line),
+				csvText(e.InvoiceRef),
+				csvText(e.SettlementRef),
+				optionalInt(e.PaymentID),
+				optionalInt(e.SettledCents),
+				optionalInt(e.ExpectedCents),
+				csvText(e.Note),
+			}); err != nil {
+				return err
+			}
+		}
+		writer.Flush()
+		return writer.Error()
+	}
+	return fmt.Errorf("%w: unknown export format %q", ErrInvalidInput, format)
+}
+
+// csvText защищает текст из выписки банка от CSV-инъекции: ячейку, которая
+// начинается с =, +, -, @, табуляции или перевода строки, табличный редактор
+// принял бы за формулу, поэтому перед ней ставится апостроф
+func csvText(s string) string {
+	if s != "" && strings.ContainsRune("=+-@\t\r", rune(s[0])) {
+		return "'" + s
+	}
+	return s
+}
+
+func optionalInt[T int32 | int64](v *T) string {
+	if v == nil {
+		return ""
+	}
+	return strconv.FormatInt(int64(*v), 10)
+}
@@ -0,0 +1,172 @@
+package service
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"strings"
+	"testing"
+
+	paymentsdb "db200/internal/db/payments"
+)
+
+func TestParseSettlementCSVAmounts(t *testing.T) {
+	mapping := SettlementMapping{AmountFormat: SettlementAmountMajor, ReferenceColumn: "ref"}
+	if err := mapping.normalize(); err != nil {
+		t.Fatal(err)
+	}
+	file := strings.Join([]string{
+		"\ufeffRef;Invoice_ID;Amount",
+		"op-1;42;1234.50",
+		"op-2;43;0.01",
+		"op-3;44;12.345",
+		"op-4;45;1e3",
+		"op-5;46;12,50",
+		"op-6;47;abc",
+		"op-7;48;-5",
+		"op-8;49;0",
+		"op-11;51;0x10",
+		"op-12;52;1e1000000000",
+		"op-9;;10",
+		"op-10;50",
+	}, "\n")
+	mapping.Delimiter = ';'
+
+	rows, err := parseSettlementCSV(strings.NewReader(file), mapping)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []struct {
+		amount int64
+		valid  bool
+	}{
+		{amount: 123450, valid: true},
+		{amount: 1, valid: true},
+		{}, {}, {}, {}, {}, {}, {}, {}, {}, {},
+	}
+	if len(rows) != len(want) {
+		t.Fatalf("got %d rows, want %d", len(rows), len(want))
+	}
+	for i, row := range rows {
+		if (row.err == nil) != want[i].valid || row.amount != want[i].amount {
+			t.Errorf("line %d (%s): amount %d, err %v; want amount %d, valid %v",
+				row.line, row.reference, row.amount, row.err, want[i].amount, want[i].valid)
+		}
+	}
+	if rows[0].line != 2 || rows[0].invoiceRef != "42" || rows[0].reference != "op-1" {
+		t.Errorf("first row = %+v, want line 2, invoice 42, reference op-1", rows[0])
+	}
+}
+
+func TestParseSettlementCSVMinorAmounts(t *testing.T) {
+	var mapping SettlementMapping
+	if err := mapping.normalize(); err != nil {
+		t.Fatal(err)
+	}
+	rows, err := parseSettlementCSV(strings.NewReader("invoice_id,amount\n1,1000\n2,10.00\n3, 7 \n"), mapping)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 3 || rows[0].err != nil || rows[0].amount != 1000 || rows[1].err == nil || rows[2].amount != 7 {
+		t.Errorf("rows = %+v, want 1000, an error for 10.00, then 7", rows)
+	}
+
+	if _, err := parseSettlementCSV(strings.NewReader("invoice,sum\n1,1\n"), mapping); !errors.Is(err, ErrInvalidInput) {
+		t.Errorf("file without mapped columns: error = %v, want %v", err, ErrInvalidInput)
+	}
+}
+
+func TestMatchSettlement(t *testing.T) {
+	candidates := []paymentsdb.ListSettlementCandidatesRow{
+		{PaymentID: 1, InvoiceID: 42, CapturedCents: 1000},
+		{PaymentID: 2, InvoiceID: 43, CapturedCents: 500},
+		{PaymentID: 3, InvoiceID: 44, CapturedCents: 700},
+		// Два платежа по одному счёту: строка берёт тот, у которого сумма совпала
+		{PaymentID: 4, InvoiceID: 45, CapturedCents: 300},
+		{PaymentID: 5, InvoiceID: 45, CapturedCents: 200},
+	}
+	rows := []settlementRow{
+		{line: 2, invoiceRef: "0042", amount: 1000},
+		{line: 3, invoiceRef: "43", amount: 499},
+		{line: 4, invoiceRef: "42", amount: 1000},
+		{line: 5, invoiceRef: "99", amount: 100},
+		{line: 6, invoiceRef: "45", amount: 200},
+		{line: 7, invoiceRef: "45", err: errors.New("amount must be positive")},
+	}
+
+	entries := matchSettlement(rows, candidates, SettlementInvoiceByID)
+
+	want := []struct {
+		status    string
+		paymentID int32
+	}{
+		{status: ReconciliationMatched, paymentID: 1},
+		{status: ReconciliationAmountMismatch, paymentID: 2},
+		{status: ReconciliationExtra},
+		{status: ReconciliationExtra},
+		{status: ReconciliationMatched, paymentID: 5},
+		{status: ReconciliationInvalid},
+		{status: ReconciliationMissing, paymentID: 3},
+		{status: ReconciliationMissing, paymentID: 4},
+	}
+	if len(entries) != len(want) {
+		t.Fatalf("got %d entries, want %d: %+v", len(entries), len(want), entries)
+	}
+	for i, e := range entries {
+		if e.Status != want[i].status || e.PaymentID.Int32 != want[i].paymentID {
+			t.Errorf("entry %d = %s payment %d, want %s payment %d", i, e.Status, e.PaymentID.Int32, want[i].status, want[i].paymentID)
+		}
+	}
+	if note := entries[1].Note; note != "settled 499, captured 500" {
+		t.Errorf("mismatch note = %q", note)
+	}
+	if entries[6].InvoiceRef != "44" || entries[6].Line.Valid {
+		t.Errorf("missing entry = %+v, want invoice 44 without a file line", entries[6])
+	}
+}
+
+func TestMatchSettlementByNumber(t *testing.T) {
+	candidates := []paymentsdb.ListSettlementCandidatesRow{
+		{PaymentID: 1, InvoiceID: 42, InvoiceNumber: "2026-000042", CapturedCents: 1000},
+	}
+	rows := []settlementRow{
+		{line: 2, invoiceRef: "42", amount: 1000},
+		{line: 3, invoiceRef: "2026-000042", amount: 1000},
+	}
+
+	entries := matchSettlement(rows, candidates, SettlementInvoiceByNumber)
+	if len(entries) != 2 || entries[0].Status != ReconciliationExtra || entries[1].Status != ReconciliationMatched {
+		t.Errorf("entries = %+v, want extra for the id and matched for the number", entries)
+	}
+}
+
+// Текст из файла провайдера не должен стать формулой в табличном редакторе
+func TestWriteReconciliationCSVEscapesFormulas(t *testing.T) {
+	details := ReconciliationDetails{Entries: []paymentsdb.ReconciliationEntry{
+		{Status: ReconciliationExtra, InvoiceRef: "=HYPERLINK(\"http://evil\")", SettlementRef: "+1", Note: "@SUM(A1)"},
+		{Status: ReconciliationInvalid, InvoiceRef: "-5", SettlementRef: "\tcmd", Note: "amount must be positive"},
+		{Status: ReconciliationMatched, InvoiceRef: "42", SettlementRef: "op-1"},
+	}}
+
+	var buf bytes.Buffer
+	if err := WriteReconciliation(&buf, details, ReconciliationFormatCSV); err != nil {
+		t.Fatal(err)
+	}
+	records, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := [][]string{
+		{"'=HYPERLINK(\"http://evil\")", "'+1", "'@SUM(A1)"},
+		{"'-5", "'\tcmd", "amount must be positive"},
+		{"42", "op-1", ""},
+	}
+	for i, w := range want {
+		record := records[i+1]
+		if got := []string{record[2], record[3], record[7]}; strings.Join(got, "|") != strings.Join(w, "|") {
+			t.Errorf("row %d text cells = %q, want %q", i+1, got, w)
+		}
+	}
+}
@@ -0,0 +1,183 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	usersdb "db200/internal/db/users"
+	"db200/internal/store"
+)
+
+// Разрешения, которые проверяют маршруты; выдаются ролям миграциями
+const (
+	PermissionProductsWrite = "products:write"
+	PermissionInventoryRead = "inventory:read"
+	PermissionPaymentsRead  = "payments:read"
+	PermissionPaymentsWrite = "payments:write"
+	PermissionUsersManage   = "users:manage"
+	PermissionSystemDebug   = "system:debug"
+)
+
+// RoleDetails - роль со списком её разрешений
+type RoleDetails struct {
+	Role        usersdb.Role
+	Permissions []string
+}
+
+// RoleService проверяет разрешения по ролям из токена доступа.
+// Разрешения ролей меняются только миграциями, поэтому держатся в памяти
+// и перечитываются раз в cacheTTL. Роли пользователя попадают в токен
+// при входе и обновлении, так что изменения назначений вступают в силу
+// с очередным токеном доступа.
+type RoleService struct {
+	store    *store.RoleStore
+	users    *store.UserStore
+	cacheTTL time.Duration
+	now      func() time.Time
+
+	mu          sync.RWMutex
+	permissions map[string]map[string]bool
+	loadedAt    time.Time
+}
+
+func NewRoleService(roleStore *store.RoleStore, userStore *store.UserStore, cacheTTL time.Duration) *RoleService {
+	return &RoleService{
+		store:    roleStore,
+		users:    userStore,
+		cacheTTL: cacheTTL,
+		now:      time.Now,
+	}
+}
+
+// HasPermission - есть ли permission хотя бы у одной из ролей
+func (s *RoleService) HasPermission(ctx context.Context, roles []string, permission string) (bool, error) {
+	if len(roles) == 0 {
+		return false, nil
+	}
+
+	permissions, err := s.rolePermissions(ctx)
+	if err != nil {
+		return false, fmt.Errorf("service: check permission %q: %w", permission, err)
+	}
+	for _, role := range roles {
+		if permissions[role][permission] {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// Roles - все роли с разрешениями
+func (s *RoleService) Roles(ctx context.Context) ([]RoleDetails, error) {
+	roles, err := s.store.Roles(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("service: list roles: %w", err)
+	}
+	permissions, err := s.store.Permissions(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("service: list roles: %w", err)
+	}
+
+	details := make([]RoleDetails, 0, len(roles))
+	for _, role := range roles {
+		details = append(details, RoleDetails{
+			Role:        role,
+			Permissions: permissions[role.Name],
+		})
+	}
+	return details, nil
+}
+
+// UserRoles - роли существующего пользователя
+func (s *RoleService) UserRoles(ctx context.Context, userID int32) ([]string, error) {
+	if _, err := s.users.Get(ctx, userID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("service: user roles: %w: user %d not found", ErrNotFound, userID)
+		}
+		return nil, fmt.Errorf("service: user %d roles: %w", userID, err)
+	}
+
+	roles, err := s.store.UserRoles(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("service: user %d roles: %w", userID, err)
+	}
+	return roles, nil
+}
+
+// AssignRole выдаёт роль; actorID - кто выдаёт, 0 - консольная команда.
+// false - роль у пользователя уже была.
+func (s *RoleService) AssignRole(ctx context.Context, userID int32, role string, actorID int32) (bool, error) {
+	role = normalizeRoleName(role)
+	if role == "" {
+		return false, fmt.Errorf("service: assign role: %w: role is required", ErrInvalidInput)
+	}
+
+	assigned, err := s.store.Assign(ctx, userID, role, actorID)
+	if err != nil {
+		switch {
+		case errors.Is(err, store.ErrRoleNotFound):
+			return false, fmt.Errorf("service: assign role: %w: role %q not found", ErrNotFound, role)
+		case errors.Is(err, store.ErrReferenceNotFound):
+			return false, fmt.Errorf("service: assign role: %w: user %d not found", ErrNotFound, userID)
+		}
+		return false, fmt.Errorf("service: assign role: %w", err)
+	}
+	return assigned, nil
+}
+
+// RevokeRole забирает роль. Свои роли забрать нельзя, чтобы администратор
+// не лишил себя доступа к этому API. false - роли у пользователя не было.
+func (s *RoleService) RevokeRole(ctx context.Context, userID int32, role string, actorID int32) (bool, error) {
+	role = normalizeRoleName(role)
+	if role == "" {
+		return false, fmt.Errorf("service: revoke role: %w: role is required", ErrInvalidInput)
+	}
+	if userID == actorID {
+		return false, fmt.Errorf("service: revoke role: %w: cannot revoke your own role", ErrForbidden)
+	}
+
+	revoked, err := s.store.Revoke(ctx, userID, role)
+	if err != nil {
+		if errors.Is(err, store.ErrRoleNotFound) {
+			return false, fmt.Errorf("service: revoke role: %w: role %q not found", ErrNotFound, role)
+		}
+		return false, fmt.Errorf("service: revoke role: %w", err)
+	}
+	return revoked, nil
+}
+
+func (s *RoleService) rolePermissions(ctx context.Context) (map[string]map[string]bool, error) {
+	s.mu.RLock()
+	permissions, loadedAt := s.permissions, s.loadedAt
+	s.mu.RUnlock()
+	if permissions != nil && s.now().Sub(loadedAt) < s.cacheTTL {
+		return permissions, nil
+	}
+
+	byRole, err := s.store.Permissions(ctx)
+	if err != nil {
+		return nil, err
+	}
+	permissions = make(map[string]map[string]bool, len(byRole))
+	for role, names := range byRole {
+		set := make(map[string]bool, len(names))
+		for _, name := range names {
+			set[name] = true
+		}
+		permissions[role] = set
+	}
+
+	s.mu.Lock()
+	s.permissions, s.loadedAt = permissions, s.now()
+	s.mu.Unlock()
+	return permissions, nil
+}
+
+func normalizeRoleName(role string) string {
+	return strings.ToLower(strings.TrimSpace(role))
+}
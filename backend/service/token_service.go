@@ -0,0 +1,259 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/sirupsen/logrus"
+
+	usersdb "db200/internal/db/users"
+	"db200/internal/store"
+	"db200/internal/token"
+)
+
+// Сроки жизни токенов по умолчанию
+const (
+	DefaultAccessTokenTTL  = 15 * time.Minute
+	DefaultRefreshTokenTTL = 30 * 24 * time.Hour
+)
+
+// secretTokenBytes - энтропия refresh-токенов и токенов из писем
+const secretTokenBytes = 32
+
+var (
+	// ErrInvalidToken - токен не найден, истёк или отозван; клиенту нужно войти заново
+	ErrInvalidToken = errors.New("invalid or expired token")
+	// ErrTokenReused - refresh-токен предъявлен повторно, сессия отозвана целиком
+	ErrTokenReused = fmt.Errorf("%w: refresh token reuse detected, session revoked", ErrInvalidToken)
+)
+
+// TokenPair - выдаётся при входе и при каждом обновлении.
+// Refresh-токен одноразовый: после обмена действует только новый.
+type TokenPair struct {
+	AccessToken      string
+	AccessExpiresAt  time.Time
+	RefreshToken     string
+	RefreshExpiresAt time.Time
+}
+
+type TokenService struct {
+	store      *store.TokenStore
+	users      *store.UserStore
+	roles      *store.RoleStore
+	keys       *token.Keyring
+	accessTTL  time.Duration
+	refreshTTL time.Duration
+	now        func() time.Time
+}
+
+func NewTokenService(tokenStore *store.TokenStore, userStore *store.UserStore, roleStore *store.RoleStore, keys *token.Keyring, accessTTL, refreshTTL time.Duration) *TokenService {
+	return &TokenService{
+		store:      tokenStore,
+		users:      userStore,
+		roles:      roleStore,
+		keys:       keys,
+		accessTTL:  accessTTL,
+		refreshTTL: refreshTTL,
+		now:        time.Now,
+	}
+}
+
+// Issue начинает новую сессию (семейство refresh-токенов) для вошедшего пользователя
+func (s *TokenService) Issue(ctx context.Context, user usersdb.User) (TokenPair, error) {
+	familyID, err := newFamilyID()
+	if err != nil {
+		return TokenPair{}, fmt.Errorf("service: issue tokens: %w", err)
+	}
+	refresh, hash, err := newSecretToken()
+	if err != nil {
+		return TokenPair{}, fmt.Errorf("service: issue tokens: %w", err)
+	}
+
+	now := s.now().UTC()
+	created, err := s.store.Create(ctx, usersdb.InsertRefreshTokenParams{
+		UserID:    user.ID,
+		FamilyID:  familyID,
+		TokenHash: hash,
+		ExpiresAt: now.Add(s.refreshTTL),
+	})
+	if err != nil {
+		return TokenPair{}, fmt.Errorf("service: issue tokens: %w", err)
+	}
+
+	pair, err := s.pair(ctx, user, created, refresh, now)
+	if err != nil {
+		return TokenPair{}, fmt.Errorf("service: issue tokens: %w", err)
+	}
+	return pair, nil
+}
+
+// Refresh обменивает refresh-токен на новую пару. Повторное предъявление
+// уже обменянного токена отзывает всю сессию - ErrTokenReused.
+func (s *TokenService) Refresh(ctx context.Context, refreshToken string) (TokenPair, error) {
+	hash, err := hashSecretToken(refreshToken)
+	if err != nil {
+		return TokenPair{}, fmt.Errorf("service: refresh tokens: %w", err)
+	}
+	next, nextHash, err := newSecretToken()
+	if err != nil {
+		return TokenPair{}, fmt.Errorf("service: refresh tokens: %w", err)
+	}
+
+	now := s.now().UTC()
+	rotated, err := s.store.Rotate(ctx, hash, now, func(usersdb.RefreshToken) (usersdb.InsertRefreshTokenParams, error) {
+		return usersdb.InsertRefreshTokenParams{
+			TokenHash: nextHash,
+			ExpiresAt: now.Add(s.refreshTTL),
+		}, nil
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, store.ErrRefreshTokenReused):
+			logrus.WithFields(logrus.Fields{
+				"user_id":   rotated.UserID,
+				"family_id": rotated.FamilyID,
+			}).Warn("refresh token reuse, session revoked")
+			return TokenPair{}, fmt.Errorf("service: refresh tokens: %w", ErrTokenReused)
+		case errors.Is(err, store.ErrRefreshTokenNotFound),
+			errors.Is(err, store.ErrRefreshTokenRevoked),
+			errors.Is(err, store.ErrRefreshTokenExpired):
+			return TokenPair{}, fmt.Errorf("service: refresh tokens: %w", ErrInvalidToken)
+		}
+		return TokenPair{}, fmt.Errorf("service: refresh tokens: %w", err)
+	}
+
+	user, err := s.users.Get(ctx, rotated.UserID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return TokenPair{}, fmt.Errorf("service: refresh tokens: %w", ErrInvalidToken)
+		}
+		return TokenPair{}, fmt.Errorf("service: refresh tokens: %w", err)
+	}
+
+	pair, err := s.pair(ctx, user, rotated, next, now)
+	if err != nil {
+		return TokenPair{}, fmt.Errorf("service: refresh tokens: %w", err)
+	}
+	return pair, nil
+}
+
+// Revoke завершает сессию, к которой относится refresh-токен (выход).
+// Уже выданные токены доступа действуют до своего истечения.
+func (s *TokenService) Revoke(ctx context.Context, refreshToken string) error {
+	hash, err := hashSecretToken(refreshToken)
+	if err != nil {
+		return fmt.Errorf("service: revoke token: %w", err)
+	}
+
+	if _, err := s.store.RevokeFamily(ctx, hash, store.RevokedLogout); err != nil {
+		if errors.Is(err, store.ErrRefreshTokenNotFound) {
+			return fmt.Errorf("service: revoke token: %w", ErrInvalidToken)
+		}
+		return fmt.Errorf("service: revoke token: %w", err)
+	}
+	return nil
+}
+
+// RevokeAll завершает все сессии пользователя, возвращает число отозванных токенов
+func (s *TokenService) RevokeAll(ctx context.Context, userID int32) (int64, error) {
+	count, err := s.store.RevokeUser(ctx, userID, store.RevokedLogoutAll)
+	if err != nil {
+		return 0, fmt.Errorf("service: revoke all tokens: %w", err)
+	}
+	return count, nil
+}
+
+// Keyfunc - ключ проверки токенов доступа для JWT-middleware
+func (s *TokenService) Keyfunc(t *jwt.Token) (interface{}, error) {
+	return s.keys.Keyfunc(t)
+}
+
+// RunExpiryJob раз в interval удаляет истёкшие refresh-токены.
+// Блокируется до отмены ctx, запускать в отдельной горутине.
+func (s *TokenService) RunExpiryJob(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			count, err := s.store.DeleteExpired(ctx, s.now().UTC())
+			if err != nil {
+				logrus.WithError(err).Error("delete expired refresh tokens")
+				continue
+			}
+			if count > 0 {
+				logrus.WithField("deleted", count).Info("deleted expired refresh tokens")
+			}
+		}
+	}
+}
+
+// pair подписывает токен доступа с текущими ролями пользователя:
+// изменения ролей доходят до клиента при следующем обновлении
+func (s *TokenService) pair(ctx context.Context, user usersdb.User, refresh usersdb.RefreshToken, refreshToken string, now time.Time) (TokenPair, error) {
+	roles, err := s.roles.UserRoles(ctx, user.ID)
+	if err != nil {
+		return TokenPair{}, err
+	}
+
+	expiresAt := now.Add(s.accessTTL)
+	access, err := s.keys.Sign(token.AccessClaims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject:   strconv.FormatInt(int64(user.ID), 10),
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
+		},
+		SessionID: refresh.FamilyID,
+		Roles:     roles,
+	})
+	if err != nil {
+		return TokenPair{}, err
+	}
+
+	return TokenPair{
+		AccessToken:      access,
+		AccessExpiresAt:  expiresAt,
+		RefreshToken:     refreshToken,
+		RefreshExpiresAt: refresh.ExpiresAt,
+	}, nil
+}
+
+// newSecretToken - случайный токен для клиента и его хэш для базы
+func newSecretToken() (string, []byte, error) {
+	raw := make([]byte, secretTokenBytes)
+	if _, err := rand.Read(raw); err != nil {
+		return "", nil, err
+	}
+	sum := sha256.Sum256(raw)
+	return base64.RawURLEncoding.EncodeToString(raw), sum[:], nil
+}
+
+// hashSecretToken - хэш токена, который прислал клиент; чужой формат - ErrInvalidToken
+func hashSecretToken(t string) ([]byte, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(t)
+	if err != nil || len(raw) != secretTokenBytes {
+		return nil, ErrInvalidToken
+	}
+	sum := sha256.Sum256(raw)
+	return sum[:], nil
+}
+
+func newFamilyID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}